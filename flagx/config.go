@@ -0,0 +1,71 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package flagx
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+// SetConfigFiles sets the envkv files which are read, instead of .envkv in the current directory.
+// Later files override earlier ones, so they can be layered, e.g. local overrides of shared settings:
+//
+//	flagx.SetConfigFiles(append(flagx.ConfigPaths("myapp/config.envkv"), ".envkv")...)
+//
+// Files which don't exist are skipped. See envkv.LoadAll.
+func (f *FlagSet) SetConfigFiles(paths ...string) {
+	f.configFiles = slices.Clone(paths)
+	if f.configFiles == nil {
+		f.configFiles = []string{}
+	}
+}
+
+// ConfigFlag defines a flag, called name (e.g. "config"), giving the envkv files to read instead of
+// those from SetConfigFiles, which then aren't read at all. It may be given more than once,
+// or as a comma-separated list. Unlike other flags, it can't be set from the environment or envkv.
+func (f *FlagSet) ConfigFlag(name string) {
+	f.configFlag = new([]string)
+	f.fs.Var(&stringSliceValue{p: f.configFlag}, name, "envkv `files` to read settings from, instead of the defaults")
+}
+
+// ConfigPaths returns where a config file called name (e.g. "myapp/config.envkv") may be found
+// under the XDG base directories, in increasing order of precedence: each of $XDG_CONFIG_DIRS
+// (or /etc/xdg), and then the user's config directory ($XDG_CONFIG_HOME, or ~/.config).
+// On platforms without XDG conventions, the user's config directory is that of [os.UserConfigDir].
+//
+// The paths are returned whether or not the files exist, for use with SetConfigFiles.
+func ConfigPaths(name string) []string {
+	var paths []string
+	if runtime.GOOS != "windows" && runtime.GOOS != "darwin" && runtime.GOOS != "ios" && runtime.GOOS != "plan9" {
+		dirs := os.Getenv("XDG_CONFIG_DIRS")
+		if dirs == "" {
+			dirs = "/etc/xdg"
+		}
+		// The first directory is the most important.
+		list := strings.Split(dirs, ":")
+		for i := len(list) - 1; i >= 0; i-- {
+			if filepath.IsAbs(list[i]) {
+				paths = append(paths, filepath.Join(list[i], name))
+			}
+		}
+	}
+	if dir, err := os.UserConfigDir(); err == nil {
+		paths = append(paths, filepath.Join(dir, name))
+	}
+	return paths
+}
+
+// See FlagSet.SetConfigFiles.
+func SetConfigFiles(paths ...string) {
+	CommandLine.SetConfigFiles(paths...)
+}
+
+// See FlagSet.ConfigFlag.
+func ConfigFlag(name string) {
+	CommandLine.ConfigFlag(name)
+}
@@ -0,0 +1,110 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package flagx
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestConfigFiles(t *testing.T) {
+	dir := t.TempDir()
+	shared := filepath.Join(dir, "shared.envkv")
+	local := filepath.Join(dir, "local.envkv")
+	extra := filepath.Join(dir, "extra.envkv")
+	os.WriteFile(shared, []byte("A=shared\nB=shared\nC=shared\n"), 0644)
+	os.WriteFile(local, []byte("B=local\n"), 0644)
+	os.WriteFile(extra, []byte("C=extra\n"), 0644)
+
+	tests := []struct {
+		name    string
+		args    []string
+		want    [3]string
+		wantErr bool
+	}{
+		{"layered", nil, [3]string{"shared", "local", "shared"}, false},
+		{"config flag replaces them", []string{"-config", extra}, [3]string{"def", "def", "extra"}, false},
+		{"config flag layers", []string{"-config", shared + "," + extra}, [3]string{"shared", "shared", "extra"}, false},
+		{"config flag with missing file", []string{"-config", filepath.Join(dir, "missing")}, [3]string{"def", "def", "def"}, false},
+		{"flags still win", []string{"-config=" + extra, "-c=flag"}, [3]string{"def", "def", "flag"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewFlagSet("test", flag.ContinueOnError)
+			f.SetEnv(func(string) (string, bool) { return "", false })
+			f.SetConfigFiles(shared, filepath.Join(dir, "missing"), local)
+			f.ConfigFlag("config")
+			var got [3]string
+			f.StringVar(&got[0], "a", "def", "help")
+			f.StringVar(&got[1], "b", "def", "help")
+			f.StringVar(&got[2], "c", "def", "help")
+
+			if err := f.Parse(tt.args); (err != nil) != tt.wantErr {
+				t.Fatalf("Parse error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// A broken config file is only an error if it is used, and doesn't stop -h.
+	broken := filepath.Join(dir, "broken.envkv")
+	os.WriteFile(broken, []byte("not valid\n"), 0644)
+	for _, tt := range []struct {
+		args    []string
+		want    string
+		wantErr bool
+	}{
+		{nil, "def", true},
+		{[]string{"-config", extra}, "extra", false},
+		{[]string{"-h"}, "def", true},
+	} {
+		f := NewFlagSet("test", flag.ContinueOnError)
+		f.FlagSet().SetOutput(io.Discard)
+		f.SetConfigFiles(broken)
+		f.ConfigFlag("config")
+		var c string
+		f.StringVar(&c, "c", "def", "help")
+		if err := f.Parse(tt.args); (err != nil) != tt.wantErr || c != tt.want {
+			t.Errorf("with a broken config file, %q: got %q, %v", tt.args, c, err)
+		} else if len(tt.args) > 0 && tt.args[0] == "-h" && err != flag.ErrHelp {
+			t.Errorf("-h with a broken config file: %v", err)
+		}
+	}
+
+	// An empty list reads nothing, not even .envkv.
+	os.WriteFile(".envkv", []byte("A=cwd\n"), 0644)
+	defer os.Remove(".envkv")
+	f := NewFlagSet("test", flag.ContinueOnError)
+	f.SetConfigFiles()
+	var a string
+	f.StringVar(&a, "a", "def", "help")
+	if err := f.Parse(nil); err != nil || a != "def" {
+		t.Errorf("with no config files, got %q, %v", a, err)
+	}
+}
+
+func TestConfigPaths(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("XDG paths are tested on linux")
+	}
+	t.Setenv("XDG_CONFIG_DIRS", "/etc/first:relative:/etc/second")
+	t.Setenv("XDG_CONFIG_HOME", "/home/me/.config")
+	want := []string{"/etc/second/app/config.envkv", "/etc/first/app/config.envkv", "/home/me/.config/app/config.envkv"}
+	if got := ConfigPaths("app/config.envkv"); !reflect.DeepEqual(got, want) {
+		t.Errorf("ConfigPaths = %q, want %q", got, want)
+	}
+
+	t.Setenv("XDG_CONFIG_DIRS", "")
+	if got := ConfigPaths("app.envkv"); len(got) != 2 || got[0] != "/etc/xdg/app.envkv" {
+		t.Errorf("ConfigPaths with default dirs = %q", got)
+	}
+}
@@ -0,0 +1,361 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package flagx
+
+import (
+	"encoding"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/rburchell/gosh/text/envkv"
+)
+
+// A FlagSet is a set of flags, like [flag.FlagSet], whose values are also looked up in the environment and envkv.
+// The package-level functions use CommandLine; a FlagSet allows libraries and tests to have their own,
+// and to supply the environment and envkv values themselves.
+type FlagSet struct {
+	fs   *flag.FlagSet
+	vars []varRec
+
+	// Where environment variables are looked up. If nil, os.LookupEnv is used.
+	lookupEnv func(key string) (string, bool)
+	// See SetEnvPrefix.
+	envPrefix string
+
+	// The envkv values, if they were given with SetEnvkv, rather than read from files.
+	envkvs   []envkv.KV
+	envkvSet bool
+
+	// The envkv files read, if they were set with SetConfigFiles, and those given with ConfigFlag.
+	configFiles []string
+	configFlag  *[]string
+}
+
+type varRec struct {
+	key        string
+	val        any
+	defaultVal any
+	help       string
+	// Where the value came from, before the command line was parsed, and the value, if it wasn't the default.
+	source source
+	value  string
+	// The envkv file the value came from, if it is known.
+	file string
+	// See Required, Validate, and Secret.
+	required bool
+	checks   []Validator
+	secret   bool
+}
+
+// Where the value of a flag came from.
+type source int
+
+const (
+	sourceDefault source = iota
+	sourceEnvkv
+	sourceEnv
+	sourceFlag
+)
+
+func (s source) String() string {
+	switch s {
+	case sourceEnvkv:
+		return "envkv"
+	case sourceEnv:
+		return "env"
+	case sourceFlag:
+		return "flag"
+	default:
+		return "default"
+	}
+}
+
+// NewFlagSet returns a new, empty flag set with the given name and error handling. See [flag.NewFlagSet].
+func NewFlagSet(name string, errorHandling flag.ErrorHandling) *FlagSet {
+	return newFlagSet(flag.NewFlagSet(name, errorHandling))
+}
+
+func newFlagSet(fs *flag.FlagSet) *FlagSet {
+	f := &FlagSet{fs: fs}
+	fs.Usage = f.defaultUsage
+	return f
+}
+
+// Records a flag, so it is looked up in the environment and envkv.
+func (f *FlagSet) add(key string, val any, defaultVal any, help string) {
+	f.vars = append(f.vars, varRec{key: key, val: val, defaultVal: defaultVal, help: help})
+}
+
+// SetEnvPrefix sets a prefix for the environment variables flags are looked up as, so that they
+// don't collide with unrelated variables, e.g. with "MYAPP", the flag port is MYAPP_PORT rather than PORT.
+// envkv keys aren't prefixed, since the files belong to the program.
+func (f *FlagSet) SetEnvPrefix(prefix string) {
+	f.envPrefix = strings.ToUpper(strings.TrimSuffix(prefix, "_"))
+}
+
+// Returns the environment variable which the flag key is looked up as.
+func (f *FlagSet) envKey(key string) string {
+	if f.envPrefix != "" {
+		return f.envPrefix + "_" + normalizeKey(key)
+	}
+	return normalizeKey(key)
+}
+
+// Returns the envkv key which the flag key is looked up as.
+func (f *FlagSet) envkvKey(key string) string {
+	return normalizeKey(key)
+}
+
+// Returns key in upper case, with dashes and dots replaced by underscores, which envkv and shells don't allow,
+// e.g. "listen-addr" is LISTEN_ADDR.
+func normalizeKey(key string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == '.' {
+			return '_'
+		}
+		return unicode.ToUpper(r)
+	}, key)
+}
+
+// Returns where the value of v came from.
+func (f *FlagSet) sourceOf(v varRec) source {
+	src := v.source
+	f.fs.Visit(func(fl *flag.Flag) {
+		if fl.Name == v.key {
+			src = sourceFlag
+		}
+	})
+	return src
+}
+
+// SetEnv sets the function used to look up environment variables, instead of os.LookupEnv.
+func (f *FlagSet) SetEnv(lookupEnv func(key string) (string, bool)) {
+	f.lookupEnv = lookupEnv
+}
+
+// SetEnvkv sets the envkv values, instead of reading them from files.
+func (f *FlagSet) SetEnvkv(kvs []envkv.KV) {
+	f.envkvs = kvs
+	f.envkvSet = true
+}
+
+// Returns the underlying [flag.FlagSet], e.g. to define flags which aren't looked up elsewhere.
+func (f *FlagSet) FlagSet() *flag.FlagSet {
+	return f.fs
+}
+
+// See [flag.FlagSet.StringVar]
+func (f *FlagSet) StringVar(val *string, key string, defaultVal string, help string) {
+	f.add(key, val, defaultVal, help)
+	f.fs.StringVar(val, key, defaultVal, help)
+}
+
+// See [flag.FlagSet.BoolVar]
+func (f *FlagSet) BoolVar(val *bool, key string, defaultVal bool, help string) {
+	f.add(key, val, defaultVal, help)
+	f.fs.BoolVar(val, key, defaultVal, help)
+}
+
+// See [flag.FlagSet.IntVar]
+func (f *FlagSet) IntVar(val *int, key string, defaultVal int, help string) {
+	f.add(key, val, defaultVal, help)
+	f.fs.IntVar(val, key, defaultVal, help)
+}
+
+// See [flag.FlagSet.Int64Var]
+func (f *FlagSet) Int64Var(val *int64, key string, defaultVal int64, help string) {
+	f.add(key, val, defaultVal, help)
+	f.fs.Int64Var(val, key, defaultVal, help)
+}
+
+// See [flag.FlagSet.UintVar]
+func (f *FlagSet) UintVar(val *uint, key string, defaultVal uint, help string) {
+	f.add(key, val, defaultVal, help)
+	f.fs.UintVar(val, key, defaultVal, help)
+}
+
+// See [flag.FlagSet.Uint64Var]
+func (f *FlagSet) Uint64Var(val *uint64, key string, defaultVal uint64, help string) {
+	f.add(key, val, defaultVal, help)
+	f.fs.Uint64Var(val, key, defaultVal, help)
+}
+
+// See [flag.FlagSet.Float64Var]
+func (f *FlagSet) Float64Var(val *float64, key string, defaultVal float64, help string) {
+	f.add(key, val, defaultVal, help)
+	f.fs.Float64Var(val, key, defaultVal, help)
+}
+
+// See [flag.FlagSet.DurationVar]
+//
+// Values in the environment and envkv are parsed with [time.ParseDuration], e.g. "1m30s".
+func (f *FlagSet) DurationVar(val *time.Duration, key string, defaultVal time.Duration, help string) {
+	f.add(key, val, defaultVal, help)
+	f.fs.DurationVar(val, key, defaultVal, help)
+}
+
+// See [flag.FlagSet.Var]
+//
+// This allows custom types to be set from the environment and envkv too, with value's Set method.
+func (f *FlagSet) Var(value flag.Value, key string, help string) {
+	f.add(key, value, value.String(), help)
+	f.fs.Var(value, key, help)
+}
+
+// See [flag.FlagSet.TextVar]
+//
+// Values in the environment and envkv are parsed with val's UnmarshalText method.
+func (f *FlagSet) TextVar(val encoding.TextUnmarshaler, key string, defaultVal encoding.TextMarshaler, help string) {
+	f.add(key, val, defaultVal, help)
+	f.fs.TextVar(val, key, defaultVal, help)
+}
+
+// Sets v from s, a value from the environment or envkv.
+func (f *FlagSet) setVar(v varRec, s string) error {
+	if b, ok := v.val.(*bool); ok && s == "" {
+		*b = false
+		return nil
+	}
+	// The flag knows how to parse its own type.
+	value := f.fs.Lookup(v.key).Value
+	if sv, ok := value.(sourceValue); ok {
+		return sv.setSource(s)
+	}
+	return value.Set(s)
+}
+
+// Returns the envkv values, from SetEnvkv, or paths, or if paths is nil, the config files.
+// The files each value was read from are returned too, unless they were given with SetEnvkv.
+func (f *FlagSet) readEnvkv(paths []string) ([]envkv.KV, envkv.Sources, error) {
+	if f.envkvSet {
+		return f.envkvs, nil, nil
+	}
+	if paths == nil {
+		paths = f.configFiles
+	}
+	if paths == nil {
+		paths = []string{envkv.DefaultFile}
+	}
+	return envkv.LoadAll(paths...)
+}
+
+// Parse parses args (which should not include the command name), and then sets the flags which weren't given
+// in args from the environment, or failing that, envkv, so the order of precedence is flag, environment, envkv,
+// and then the default. See [flag.FlagSet.Parse].
+//
+// Invalid values in the environment or envkv, or an invalid envkv file, are errors, handled as the FlagSet's
+// [flag.ErrorHandling] says, as errors in args are.
+func (f *FlagSet) Parse(args []string) error {
+	err := f.parse(args)
+	if err == nil || err == flag.ErrHelp {
+		// The flag package has already handled these.
+		return err
+	}
+	if _, ok := err.(envError); !ok {
+		return err
+	}
+	switch f.fs.ErrorHandling() {
+	case flag.ExitOnError:
+		fmt.Fprintln(f.fs.Output(), err)
+		os.Exit(2)
+	case flag.PanicOnError:
+		panic(err)
+	}
+	return err
+}
+
+// An error from the environment or envkv, or validation.
+type envError struct{ error }
+
+// Finds where the value of each flag will come from (other than the command line), and what it is.
+func (f *FlagSet) resolve(envkvs []envkv.KV, sources envkv.Sources) {
+	lookupEnv := f.lookupEnv
+	if lookupEnv == nil {
+		lookupEnv = os.LookupEnv
+	}
+	for i, v := range f.vars {
+		f.vars[i].source = sourceDefault
+		f.vars[i].file = ""
+		if val, ok := lookupEnv(f.envKey(v.key)); ok {
+			f.vars[i].source = sourceEnv
+			f.vars[i].value = val
+			continue
+		}
+		envkvKey := f.envkvKey(v.key)
+		for _, kv := range envkvs {
+			if kv.Key == envkvKey {
+				f.vars[i].source = sourceEnvkv
+				f.vars[i].value = kv.Value
+				f.vars[i].file = sources[kv.Key]
+			}
+		}
+	}
+}
+
+func (f *FlagSet) parse(args []string) error {
+	// Find the values first, so that the usage message can say where they are from.
+	// An error in the config files is held back until args are parsed, so that -h still works,
+	// and so that the config flag can be used instead of a broken file.
+	envkvs, sources, envkvErr := f.readEnvkv(nil)
+	f.resolve(envkvs, sources)
+
+	if err := f.fs.Parse(args); err != nil {
+		return err
+	}
+
+	if f.configFlag != nil && len(*f.configFlag) > 0 {
+		envkvs, sources, envkvErr = f.readEnvkv(*f.configFlag)
+		f.resolve(envkvs, sources)
+	}
+	if envkvErr != nil {
+		return envError{envkvErr}
+	}
+
+	var errs []error
+	for _, v := range f.vars {
+		if v.source == sourceDefault || f.sourceOf(v) == sourceFlag {
+			continue
+		}
+		if err := f.setVar(v, v.value); err != nil {
+			errs = append(errs, fmt.Errorf("invalid value %q for %s: %w", v.value, f.describe(v), err))
+		}
+	}
+	if len(errs) > 0 {
+		return envError{errors.Join(errs...)}
+	}
+	if err := f.validate(); err != nil {
+		return envError{err}
+	}
+	return nil
+}
+
+// Returns where the value of v was set, e.g. "env PORT".
+func (f *FlagSet) describe(v varRec) string {
+	switch src := f.sourceOf(v); src {
+	case sourceEnv:
+		return "env " + f.envKey(v.key)
+	case sourceEnvkv:
+		return "envkv " + f.envkvKey(v.key)
+	case sourceFlag:
+		return "flag -" + v.key
+	default:
+		return "default of -" + v.key
+	}
+}
+
+// See [flag.FlagSet.Parsed]
+func (f *FlagSet) Parsed() bool {
+	return f.fs.Parsed()
+}
+
+// See [flag.FlagSet.Args]
+func (f *FlagSet) Args() []string {
+	return f.fs.Args()
+}
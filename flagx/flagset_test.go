@@ -0,0 +1,188 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package flagx
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rburchell/gosh/text/envkv"
+)
+
+func TestFlagSet(t *testing.T) {
+	env := map[string]string{"PORT": "9090", "NAME": "fromenv"}
+
+	f := NewFlagSet("test", flag.ContinueOnError)
+	f.SetEnv(func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	})
+	f.SetEnvkv([]envkv.KV{{Key: "PORT", Value: "8081"}, {Key: "TIMEOUT", Value: "3s"}, {Key: "DEBUG", Value: "true"}})
+
+	var port int
+	var name, mode string
+	var timeout time.Duration
+	var debug bool
+	f.IntVar(&port, "port", 8080, "help")
+	f.StringVar(&name, "name", "def", "help")
+	f.StringVar(&mode, "mode", "def", "help")
+	f.DurationVar(&timeout, "timeout", time.Second, "help")
+	f.BoolVar(&debug, "debug", false, "help")
+
+	if err := f.Parse([]string{"-name=fromflag", "rest"}); err != nil {
+		t.Fatal(err)
+	}
+	if port != 9090 || name != "fromflag" || mode != "def" || timeout != 3*time.Second || !debug {
+		t.Errorf("got port=%d name=%q mode=%q timeout=%v debug=%v", port, name, mode, timeout, debug)
+	}
+	if !f.Parsed() || len(f.Args()) != 1 || f.Args()[0] != "rest" {
+		t.Errorf("Parsed() = %v, Args() = %q", f.Parsed(), f.Args())
+	}
+
+	// The global flags are untouched.
+	if CommandLine.FlagSet().Lookup("port") != nil {
+		t.Errorf("FlagSet defined a global flag")
+	}
+
+	f = NewFlagSet("test", flag.ContinueOnError)
+	f.FlagSet().SetOutput(io.Discard)
+	f.SetEnvkv(nil)
+	if err := f.Parse([]string{"-nope"}); err == nil {
+		t.Errorf("Parse of an undefined flag should fail")
+	}
+}
+
+func TestFlagSet_Precedence(t *testing.T) {
+	tests := []struct {
+		name    string
+		envkv   []envkv.KV
+		env     map[string]string
+		args    []string
+		want    string
+		wantErr string
+	}{
+		{"default", nil, nil, nil, "def", ""},
+		{"envkv", []envkv.KV{{Key: "NAME", Value: "envkv"}}, nil, nil, "envkv", ""},
+		{"env over envkv", []envkv.KV{{Key: "NAME", Value: "envkv"}}, map[string]string{"NAME": "env"}, nil, "env", ""},
+		{"flag over env", nil, map[string]string{"NAME": "env"}, []string{"-name=flag"}, "flag", ""},
+		{"flag set to the default", nil, map[string]string{"NAME": "env"}, []string{"-name=def"}, "def", ""},
+		{
+			name:    "invalid values",
+			envkv:   []envkv.KV{{Key: "COUNT", Value: "many"}},
+			env:     map[string]string{"DEBUG": "yes"},
+			want:    "def",
+			wantErr: "invalid value \"many\" for envkv COUNT: parse error\ninvalid value \"yes\" for env DEBUG: parse error",
+		},
+		{"invalid value overridden by flag", nil, map[string]string{"COUNT": "many"}, []string{"-count=2"}, "def", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewFlagSet("test", flag.ContinueOnError)
+			f.SetEnvkv(tt.envkv)
+			f.SetEnv(func(key string) (string, bool) {
+				v, ok := tt.env[key]
+				return v, ok
+			})
+			var name string
+			var count int
+			var debug bool
+			f.StringVar(&name, "name", "def", "help")
+			f.IntVar(&count, "count", 1, "help")
+			f.BoolVar(&debug, "debug", false, "help")
+
+			err := f.Parse(tt.args)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("Parse error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if name != tt.want {
+				t.Errorf("name = %q, want %q", name, tt.want)
+			}
+		})
+	}
+}
+
+func TestFlagSet_BoolValues(t *testing.T) {
+	for _, tt := range []struct {
+		val  string
+		want bool
+	}{{"", false}, {"false", false}, {"0", false}, {"true", true}, {"1", true}} {
+		f := NewFlagSet("test", flag.ContinueOnError)
+		f.SetEnvkv(nil)
+		f.SetEnv(func(string) (string, bool) { return tt.val, true })
+		b := !tt.want
+		f.BoolVar(&b, "b", !tt.want, "help")
+		if err := f.Parse(nil); err != nil || b != tt.want {
+			t.Errorf("B=%q: got %v, %v, want %v", tt.val, b, err, tt.want)
+		}
+	}
+}
+
+func TestFlagSet_EnvPrefix(t *testing.T) {
+	env := map[string]string{"PORT": "1", "MYAPP_PORT": "2", "MYAPP_HOST": "env"}
+	f := NewFlagSet("test", flag.ContinueOnError)
+	f.SetEnvPrefix("myapp_")
+	f.SetEnv(func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	})
+	f.SetEnvkv([]envkv.KV{{Key: "PORT", Value: "3"}, {Key: "NAME", Value: "envkv"}})
+
+	var port int
+	var host, name string
+	f.IntVar(&port, "port", 0, "help")
+	f.StringVar(&host, "host", "def", "help")
+	f.StringVar(&name, "name", "def", "help")
+	if err := f.Parse(nil); err != nil {
+		t.Fatal(err)
+	}
+	if port != 2 || host != "env" || name != "envkv" {
+		t.Errorf("got port=%d host=%q name=%q", port, host, name)
+	}
+
+	var buf bytes.Buffer
+	f.FlagSet().SetOutput(&buf)
+	f.PrintDefaults()
+	if !strings.Contains(buf.String(), "env MYAPP_PORT, envkv PORT; set from env") {
+		t.Errorf("usage doesn't show the prefix:\n%s", buf.String())
+	}
+}
+
+func TestNormalizeKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"port", "PORT"},
+		{"tls_cert", "TLS_CERT"},
+		{"listen-addr", "LISTEN_ADDR"},
+		{"db.max-conns", "DB_MAX_CONNS"},
+	}
+	for _, tt := range tests {
+		if got := normalizeKey(tt.key); got != tt.want {
+			t.Errorf("normalizeKey(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+
+	f := NewFlagSet("test", flag.ContinueOnError)
+	f.SetEnv(func(key string) (string, bool) { return "env", key == "LISTEN_ADDR" })
+	f.SetEnvkv([]envkv.KV{{Key: "DB_MAX_CONNS", Value: "5"}})
+	var addr string
+	var conns int
+	f.StringVar(&addr, "listen-addr", "def", "help")
+	f.IntVar(&conns, "db.max-conns", 1, "help")
+	if err := f.Parse(nil); err != nil || addr != "env" || conns != 5 {
+		t.Errorf("got %q, %d, %v", addr, conns, err)
+	}
+}
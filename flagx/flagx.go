@@ -9,8 +9,13 @@
 //  1. flag
 //  2. environment
 //  3. envkv
+//  4. the default
 //
-// When looking up keys in the environment or envkv, keys are forced to uppercase, to match convention.
+// Each flag takes its value from the first of these it is set in. Invalid values are errors, wherever they are.
+//
+// When looking up keys in the environment or envkv, keys are forced to uppercase, to match convention,
+// and dashes and dots are replaced by underscores, so that the flag listen-addr is LISTEN_ADDR.
+// Environment variables may also be given a prefix (see SetEnvPrefix).
 //
 // The API is a subset of the stdlib's flag package, i.e:
 //
@@ -20,123 +25,109 @@
 //	    flagx.Parse()
 //	}
 //
+// As with the flag package, the package-level functions use a global set of flags (CommandLine),
+// and a FlagSet can be used to have another, e.g. in tests.
+//
 // The implementation is not exhaustive; new API can be added as needed.
 package flagx
 
 import (
-	"errors"
+	"encoding"
 	"flag"
-	"fmt"
-	"io/fs"
-	"log/slog"
 	"os"
-	"strconv"
-	"strings"
-
-	"github.com/rburchell/gosh/log/slogx"
-	"github.com/rburchell/gosh/text/envkv"
+	"time"
 )
 
-var log *slog.Logger = slogx.NewCategory("flagx", slogx.TextHandler, slog.LevelDebug)
-
-type varRec struct {
-	key        string
-	val        any
-	defaultVal any
-	help       string
-}
-
-var allVars []varRec
+// The flags of the command line, which the package-level functions use.
+// Its underlying [flag.FlagSet] is [flag.CommandLine], so flags defined directly with the flag package are parsed too.
+var CommandLine = newFlagSet(flag.CommandLine)
 
 func clearVars() {
-	allVars = []varRec{}
 	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
-
+	CommandLine = newFlagSet(flag.CommandLine)
 }
 
 // See [flag.StringVar]
 func StringVar(val *string, key string, defaultVal string, help string) {
-	allVars = append(allVars, varRec{key, val, defaultVal, help})
-	flag.StringVar(val, key, defaultVal, help)
+	CommandLine.StringVar(val, key, defaultVal, help)
 }
 
 // See [flag.BoolVar]
 func BoolVar(val *bool, key string, defaultVal bool, help string) {
-	allVars = append(allVars, varRec{key, val, defaultVal, help})
-	flag.BoolVar(val, key, defaultVal, help)
+	CommandLine.BoolVar(val, key, defaultVal, help)
 }
 
 // See [flag.IntVar]
 func IntVar(val *int, key string, defaultVal int, help string) {
-	allVars = append(allVars, varRec{key, val, defaultVal, help})
-	flag.IntVar(val, key, defaultVal, help)
+	CommandLine.IntVar(val, key, defaultVal, help)
+}
+
+// See [flag.Int64Var]
+func Int64Var(val *int64, key string, defaultVal int64, help string) {
+	CommandLine.Int64Var(val, key, defaultVal, help)
+}
+
+// See [flag.UintVar]
+func UintVar(val *uint, key string, defaultVal uint, help string) {
+	CommandLine.UintVar(val, key, defaultVal, help)
+}
+
+// See [flag.Uint64Var]
+func Uint64Var(val *uint64, key string, defaultVal uint64, help string) {
+	CommandLine.Uint64Var(val, key, defaultVal, help)
+}
+
+// See [flag.Float64Var]
+func Float64Var(val *float64, key string, defaultVal float64, help string) {
+	CommandLine.Float64Var(val, key, defaultVal, help)
+}
+
+// See [flag.DurationVar]
+//
+// Values in the environment and envkv are parsed with [time.ParseDuration], e.g. "1m30s".
+func DurationVar(val *time.Duration, key string, defaultVal time.Duration, help string) {
+	CommandLine.DurationVar(val, key, defaultVal, help)
+}
+
+// See [flag.Var]
+//
+// This allows custom types to be set from the environment and envkv too, with value's Set method.
+func Var(value flag.Value, key string, help string) {
+	CommandLine.Var(value, key, help)
+}
+
+// See [flag.TextVar]
+//
+// Values in the environment and envkv are parsed with val's UnmarshalText method.
+func TextVar(val encoding.TextUnmarshaler, key string, defaultVal encoding.TextMarshaler, help string) {
+	CommandLine.TextVar(val, key, defaultVal, help)
+}
+
+// See FlagSet.SetEnvPrefix.
+func SetEnvPrefix(prefix string) {
+	CommandLine.SetEnvPrefix(prefix)
 }
 
 // See [flag.Parse]
 //
-// The one difference here is that values are also looked for in envkv (as a .envkv file),
-// and environment. Flag vars are searched for in envkv and environment as uppercase keys.
+// The one difference here is that values are also looked for in envkv (a .envkv file, or those given
+// with SetConfigFiles), and environment. Flag vars are searched for in envkv and environment as uppercase keys.
+// Invalid values there exit the program, as invalid flags do.
 func Parse() {
-	bytes, err := os.ReadFile(".envkv")
-	if err != nil && !errors.Is(err, fs.ErrNotExist) {
-		log.Error("envkv: read", "err", err)
-	}
-
-	var envkvs []envkv.KV
-	if err == nil {
-		envkvs, err = envkv.Unmarshal(bytes)
-		if err != nil {
-			log.Error("envkv: unmarshal", "err", err)
-		}
-	}
-
-	toBool := func(v string) bool {
-		if v == "false" || v == "" {
-			return false
-		}
-		return true
-	}
-	toInt := func(v string) int {
-		var ival int64
-		ival, err = strconv.ParseInt(v, 10, 64)
-		return int(ival)
-	}
-
-	for _, v := range allVars {
-		upperKey := strings.ToUpper(v.key)
-
-		// 1. Write from envkv
-		for _, val := range envkvs {
-			if val.Key == upperKey {
-				switch tv := v.val.(type) {
-				case *string:
-					*tv = val.Value
-				case *bool:
-					*tv = toBool(val.Value)
-				case *int:
-					*tv = toInt(val.Value)
-				default:
-					panic(fmt.Sprintf("unsupported envkv type: %T", v.val))
-				}
-			}
-		}
-
-		// 2: Write from environment
-		val, ok := os.LookupEnv(upperKey)
-		if ok {
-			switch tv := v.val.(type) {
-			case *string:
-				*tv = val
-			case *bool:
-				*tv = toBool(val)
-			case *int:
-				*tv = toInt(val)
-			default:
-				panic(fmt.Sprintf("unsupported env type: %T", v.val))
-			}
-		}
-	}
-
-	// Step 3: overwrite with flag
-	flag.Parse()
+	// CommandLine exits on error.
+	CommandLine.Parse(os.Args[1:])
+}
+
+// ParseArgs is like Parse, but parses args (which should not include the command name), and returns
+// errors rather than exiting, as if CommandLine were created with [flag.ContinueOnError].
+// This allows programs which embed others, and tests, to handle bad input themselves.
+// If -h or -help is given, after the usage message is printed, [flag.ErrHelp] is returned.
+//
+// For a FlagSet, use [flag.ContinueOnError] with NewFlagSet instead.
+func ParseArgs(args []string) error {
+	fs := CommandLine.fs
+	errorHandling := fs.ErrorHandling()
+	fs.Init(fs.Name(), flag.ContinueOnError)
+	defer fs.Init(fs.Name(), errorHandling)
+	return CommandLine.Parse(args)
 }
@@ -5,8 +5,14 @@
 package flagx
 
 import (
+	"errors"
+	"flag"
+	"io"
+	"log/slog"
 	"os"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestFromEnvkv(t *testing.T) {
@@ -102,3 +108,158 @@ func TestFromFlag(t *testing.T) {
 		t.Errorf("expected int 42, got %d", i)
 	}
 }
+
+func TestScalarTypes(t *testing.T) {
+	tests := []struct {
+		name   string
+		envkv  string
+		env    map[string]string
+		args   []string
+		wantD  time.Duration
+		wantF  float64
+		wantI  int64
+		wantU  uint
+		wantU6 uint64
+	}{
+		{
+			name:  "defaults",
+			wantD: time.Second, wantF: 0.5, wantI: -1, wantU: 1, wantU6: 2,
+		},
+		{
+			name:  "envkv",
+			envkv: "TIMEOUT=1m30s\nRATIO=1.5\nOFFSET=-42\nWORKERS=8\nMAX_BYTES=1099511627776\n",
+			wantD: 90 * time.Second, wantF: 1.5, wantI: -42, wantU: 8, wantU6: 1 << 40,
+		},
+		{
+			name:  "env",
+			env:   map[string]string{"TIMEOUT": "250ms", "RATIO": "2", "OFFSET": "7", "WORKERS": "3", "MAX_BYTES": "9"},
+			wantD: 250 * time.Millisecond, wantF: 2, wantI: 7, wantU: 3, wantU6: 9,
+		},
+		{
+			name:  "flag",
+			args:  []string{"-timeout=5s", "-ratio=0.25", "-offset=100", "-workers=16", "-max_bytes=10"},
+			wantD: 5 * time.Second, wantF: 0.25, wantI: 100, wantU: 16, wantU6: 10,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer clearVars()
+
+			var d time.Duration
+			var f float64
+			var i int64
+			var u uint
+			var u6 uint64
+			DurationVar(&d, "timeout", time.Second, "help")
+			Float64Var(&f, "ratio", 0.5, "help")
+			Int64Var(&i, "offset", -1, "help")
+			UintVar(&u, "workers", 1, "help")
+			Uint64Var(&u6, "max_bytes", 2, "help")
+
+			if tt.envkv != "" {
+				os.WriteFile(".envkv", []byte(tt.envkv), 0644)
+				defer os.Remove(".envkv")
+			}
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			origArgs := os.Args
+			os.Args = append([]string{"cmd"}, tt.args...)
+			defer func() { os.Args = origArgs }()
+
+			Parse()
+
+			if d != tt.wantD || f != tt.wantF || i != tt.wantI || u != tt.wantU || u6 != tt.wantU6 {
+				t.Errorf("got %v, %v, %v, %v, %v, want %v, %v, %v, %v, %v",
+					d, f, i, u, u6, tt.wantD, tt.wantF, tt.wantI, tt.wantU, tt.wantU6)
+			}
+		})
+	}
+}
+
+// A flag.Value for testing Var.
+type listValue []string
+
+func (l *listValue) String() string { return strings.Join(*l, ",") }
+
+func (l *listValue) Set(s string) error {
+	*l = strings.Split(s, ",")
+	return nil
+}
+
+func TestVar(t *testing.T) {
+	defer clearVars()
+
+	var hosts listValue
+	var level slog.Level
+	Var(&hosts, "hosts", "help")
+	TextVar(&level, "level", slog.LevelInfo, "help")
+
+	os.WriteFile(".envkv", []byte("HOSTS=\"a,b\"\n"), 0644)
+	defer os.Remove(".envkv")
+	t.Setenv("LEVEL", "warn")
+
+	origArgs := os.Args
+	os.Args = []string{"cmd"}
+	defer func() { os.Args = origArgs }()
+
+	Parse()
+
+	if hosts.String() != "a,b" {
+		t.Errorf("expected hosts 'a,b', got %q", hosts.String())
+	}
+	if level != slog.LevelWarn {
+		t.Errorf("expected level WARN, got %v", level)
+	}
+}
+
+func TestParseArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr error
+		want    int
+	}{
+		{"ok", []string{"-int=3"}, nil, 3},
+		{"help", []string{"-h"}, flag.ErrHelp, 1},
+		{"undefined", []string{"-nope"}, errAny, 1},
+		{"invalid", []string{"-int=x"}, errAny, 0}, // the flag package zeroes it
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer clearVars()
+			flag.CommandLine.SetOutput(io.Discard)
+
+			var i int
+			IntVar(&i, "int", 1, "help")
+			err := ParseArgs(tt.args)
+			if tt.wantErr == errAny && err == nil || tt.wantErr != errAny && err != tt.wantErr {
+				t.Errorf("ParseArgs(%q) error = %v, want %v", tt.args, err, tt.wantErr)
+			}
+			if i != tt.want {
+				t.Errorf("got %d, want %d", i, tt.want)
+			}
+			if h := flag.CommandLine.ErrorHandling(); h != flag.ExitOnError {
+				t.Errorf("error handling left as %v", h)
+			}
+		})
+	}
+}
+
+// Stands for any error in test tables.
+var errAny = errors.New("any error")
+
+func TestParseArgs_BadEnvkv(t *testing.T) {
+	defer clearVars()
+
+	var s string
+	StringVar(&s, "str", "def", "help")
+
+	os.WriteFile(".envkv", []byte("STR=\"unterminated\n"), 0644)
+	defer os.Remove(".envkv")
+
+	err := ParseArgs(nil)
+	if err == nil || !strings.HasPrefix(err.Error(), ".envkv: line 1") {
+		t.Errorf("ParseArgs error = %v", err)
+	}
+}
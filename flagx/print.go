@@ -0,0 +1,72 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package flagx
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+	"text/tabwriter"
+)
+
+// The value PrintConfig shows for secrets.
+const redacted = "[redacted]"
+
+// Secret marks the flag key as holding a secret (e.g. a password or token), so that PrintConfig
+// doesn't show its value.
+func (f *FlagSet) Secret(key string) {
+	f.mustFind(key).secret = true
+}
+
+// PrintConfig writes the value of each flag to w, after Parse, along with where it came from,
+// to help debug configuration, e.g:
+//
+//	FLAG          VALUE        SOURCE
+//	addr          ":8080"      default
+//	db-password   [redacted]   envkv DB_PASSWORD (/etc/myapp/config.envkv)
+//	port          "9090"       env PORT
+//
+// The values of flags marked with Secret are redacted, unless they are empty.
+// Only flags defined with flagx are shown.
+func (f *FlagSet) PrintConfig(w io.Writer) error {
+	vars := slices.SortedFunc(slices.Values(f.vars), func(a, b varRec) int {
+		return cmp.Compare(a.key, b.key)
+	})
+
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(tw, "FLAG\tVALUE\tSOURCE")
+	for _, v := range vars {
+		value := fmt.Sprintf("%q", f.fs.Lookup(v.key).Value.String())
+		if v.secret && value != `""` {
+			value = redacted
+		}
+		src := f.sourceOf(v)
+		where := src.String()
+		switch src {
+		case sourceFlag:
+			where += " -" + v.key
+		case sourceEnv:
+			where += " " + f.envKey(v.key)
+		case sourceEnvkv:
+			where += " " + f.envkvKey(v.key)
+			if v.file != "" {
+				where += " (" + v.file + ")"
+			}
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", v.key, value, where)
+	}
+	return tw.Flush()
+}
+
+// See FlagSet.Secret.
+func Secret(key string) {
+	CommandLine.Secret(key)
+}
+
+// See FlagSet.PrintConfig.
+func PrintConfig(w io.Writer) error {
+	return CommandLine.PrintConfig(w)
+}
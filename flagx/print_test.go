@@ -0,0 +1,53 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package flagx
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPrintConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.envkv")
+	os.WriteFile(path, []byte("DB_PASSWORD=hunter2\nNAME=envkv\n"), 0644)
+
+	f := NewFlagSet("test", flag.ContinueOnError)
+	f.SetConfigFiles(path)
+	f.SetEnv(func(key string) (string, bool) { return "9090", key == "PORT" })
+
+	var addr, password, token, name string
+	var port int
+	var verbose bool
+	f.StringVar(&addr, "addr", ":8080", "help")
+	f.StringVar(&password, "db-password", "", "help")
+	f.StringVar(&token, "token", "", "help")
+	f.StringVar(&name, "name", "def", "help")
+	f.IntVar(&port, "port", 80, "help")
+	f.BoolVar(&verbose, "v", false, "help")
+	f.Secret("db-password")
+	f.Secret("token")
+
+	if err := f.Parse([]string{"-v"}); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := f.PrintConfig(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want := `FLAG          VALUE        SOURCE
+addr          ":8080"      default
+db-password   [redacted]   envkv DB_PASSWORD (` + path + `)
+name          "envkv"      envkv NAME (` + path + `)
+port          "9090"       env PORT
+token         ""           default
+v             "true"       flag -v
+`
+	if buf.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
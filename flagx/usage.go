@@ -0,0 +1,67 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package flagx
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// The usage message shown for -h, or a bad flag, unless the [flag.FlagSet]'s Usage is replaced.
+func (f *FlagSet) defaultUsage() {
+	if f.fs.Name() == "" {
+		fmt.Fprintf(f.fs.Output(), "Usage:\n")
+	} else {
+		fmt.Fprintf(f.fs.Output(), "Usage of %s:\n", f.fs.Name())
+	}
+	f.PrintDefaults()
+}
+
+// PrintDefaults prints the flags, as [flag.FlagSet.PrintDefaults] does, adding the environment variable
+// and envkv key each can also be set with, and where its current value came from, e.g:
+//
+//	-port int
+//	  	the port to listen on (default 8080)
+//	  	env PORT, envkv PORT; set from env
+func (f *FlagSet) PrintDefaults() {
+	f.fs.VisitAll(func(fl *flag.Flag) {
+		var b strings.Builder
+		fmt.Fprintf(&b, "  -%s", fl.Name)
+		name, usage := flag.UnquoteUsage(fl)
+		if len(name) > 0 {
+			b.WriteString(" " + name)
+		}
+		// Short names fit on the same line, as with the flag package.
+		if b.Len() <= 4 {
+			b.WriteString("\t")
+		} else {
+			b.WriteString("\n    \t")
+		}
+		b.WriteString(strings.ReplaceAll(usage, "\n", "\n    \t"))
+
+		switch fl.DefValue {
+		case "", "0", "false", "0s":
+		default:
+			if name == "string" {
+				fmt.Fprintf(&b, " (default %q)", fl.DefValue)
+			} else {
+				fmt.Fprintf(&b, " (default %v)", fl.DefValue)
+			}
+		}
+
+		for _, v := range f.vars {
+			if v.key == fl.Name {
+				fmt.Fprintf(&b, "\n    \tenv %s, envkv %s; set from %s", f.envKey(v.key), f.envkvKey(v.key), f.sourceOf(v))
+			}
+		}
+		fmt.Fprint(f.fs.Output(), b.String(), "\n")
+	})
+}
+
+// See [flag.PrintDefaults], and FlagSet.PrintDefaults.
+func PrintDefaults() {
+	CommandLine.PrintDefaults()
+}
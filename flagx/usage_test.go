@@ -0,0 +1,53 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package flagx
+
+import (
+	"bytes"
+	"flag"
+	"testing"
+
+	"github.com/rburchell/gosh/text/envkv"
+)
+
+func TestUsage(t *testing.T) {
+	var buf bytes.Buffer
+	f := NewFlagSet("app", flag.ContinueOnError)
+	f.FlagSet().SetOutput(&buf)
+	f.SetEnv(func(key string) (string, bool) { return "9090", key == "PORT" })
+	f.SetEnvkv([]envkv.KV{{Key: "NAME", Value: "fromenvkv"}})
+
+	var port int
+	var name, mode string
+	var verbose bool
+	f.IntVar(&port, "port", 8080, "the `port` to listen on")
+	f.StringVar(&name, "name", "def", "the name")
+	f.StringVar(&mode, "mode", "", "the mode")
+	f.BoolVar(&verbose, "v", false, "verbose")
+	f.FlagSet().Int("plain", 1, "not looked up elsewhere")
+
+	if err := f.Parse([]string{"-v", "-h"}); err != flag.ErrHelp {
+		t.Fatalf("Parse error = %v, want ErrHelp", err)
+	}
+
+	want := `Usage of app:
+  -mode string
+    	the mode
+    	env MODE, envkv MODE; set from default
+  -name string
+    	the name (default "def")
+    	env NAME, envkv NAME; set from envkv
+  -plain int
+    	not looked up elsewhere (default 1)
+  -port port
+    	the port to listen on (default 8080)
+    	env PORT, envkv PORT; set from env
+  -v	verbose
+    	env V, envkv V; set from flag
+`
+	if buf.String() != want {
+		t.Errorf("usage:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package flagx
+
+import (
+	"cmp"
+	"errors"
+	"flag"
+	"fmt"
+	"regexp"
+)
+
+// A Validator checks the value of a flag. See Validate.
+//
+// The value is that of the flag's [flag.Getter] (e.g. an int for IntVar), or its string form
+// for values which aren't Getters.
+type Validator func(value any) error
+
+// Returns a Validator checking that the value is between min and max, inclusive.
+// The flag must be of type T, e.g. int for IntVar, or time.Duration for DurationVar.
+func InRange[T cmp.Ordered](min, max T) Validator {
+	return func(value any) error {
+		v, ok := value.(T)
+		if !ok {
+			return fmt.Errorf("%T isn't a %T", value, min)
+		}
+		if v < min || v > max {
+			return fmt.Errorf("must be between %v and %v", min, max)
+		}
+		return nil
+	}
+}
+
+// Returns a Validator checking that the string form of the value matches the regular expression pattern,
+// which must compile. It isn't anchored, so to match the whole value, use ^ and $.
+func Matches(pattern string) Validator {
+	re := regexp.MustCompile(pattern)
+	return func(value any) error {
+		if !re.MatchString(fmt.Sprint(value)) {
+			return fmt.Errorf("must match %s", pattern)
+		}
+		return nil
+	}
+}
+
+// Returns the record of the flag key, panicking if it wasn't defined with f.
+func (f *FlagSet) mustFind(key string) *varRec {
+	for i := range f.vars {
+		if f.vars[i].key == key {
+			return &f.vars[i]
+		}
+	}
+	panic(fmt.Sprintf("flagx: no such flag -%s", key))
+}
+
+// Required marks the flag key as required, so that Parse fails if it isn't set on the command line,
+// in the environment, or in envkv.
+func (f *FlagSet) Required(key string) {
+	f.mustFind(key).required = true
+}
+
+// Validate adds a check of the value of the flag key, which Parse makes once it is set, wherever it was set from
+// (including the default). Parse reports all of the values which fail, not just the first.
+//
+//	flagx.IntVar(&port, "port", 8080, "the port to listen on")
+//	flagx.Validate("port", flagx.InRange(1, 65535))
+func (f *FlagSet) Validate(key string, check Validator) {
+	v := f.mustFind(key)
+	v.checks = append(v.checks, check)
+}
+
+// Checks required flags, and runs the Validators, returning all of their errors.
+func (f *FlagSet) validate() error {
+	var errs []error
+	for _, v := range f.vars {
+		src := f.sourceOf(v)
+		if v.required && src == sourceDefault {
+			errs = append(errs, fmt.Errorf("flag -%s is required (or env %s, or envkv %s)", v.key, f.envKey(v.key), f.envkvKey(v.key)))
+			continue
+		}
+		fl := f.fs.Lookup(v.key)
+		var value any = fl.Value.String()
+		if g, ok := fl.Value.(flag.Getter); ok {
+			value = g.Get()
+		}
+		for _, check := range v.checks {
+			if err := check(value); err != nil {
+				errs = append(errs, fmt.Errorf("invalid value %q for %s: %w", fl.Value.String(), f.describe(v), err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// See FlagSet.Required.
+func Required(key string) {
+	CommandLine.Required(key)
+}
+
+// See FlagSet.Validate.
+func Validate(key string, check Validator) {
+	CommandLine.Validate(key, check)
+}
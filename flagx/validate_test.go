@@ -0,0 +1,89 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package flagx
+
+import (
+	"errors"
+	"flag"
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		args    []string
+		wantErr string
+	}{
+		{
+			name: "valid",
+			args: []string{"-token=abc", "-port=443", "-timeout=5s", "-name=web-1"},
+		},
+		{
+			name:    "required",
+			wantErr: "flag -token is required (or env TOKEN, or envkv TOKEN)",
+		},
+		{
+			name: "required from env",
+			env:  map[string]string{"TOKEN": "abc"},
+		},
+		{
+			name: "all failures are reported",
+			env:  map[string]string{"TOKEN": "abc", "PORT": "0"},
+			args: []string{"-timeout=1h", "-name=Web 1"},
+			wantErr: "invalid value \"0\" for env PORT: must be between 1 and 65535\n" +
+				"invalid value \"1h0m0s\" for flag -timeout: must be between 1s and 1m0s\n" +
+				"invalid value \"Web 1\" for flag -name: must match ^[a-z0-9-]+$",
+		},
+		{
+			name:    "empty values are checked",
+			env:     map[string]string{"TOKEN": "abc", "NAME": ""},
+			wantErr: "invalid value \"\" for env NAME: must match ^[a-z0-9-]+$",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewFlagSet("test", flag.ContinueOnError)
+			f.SetEnvkv(nil)
+			f.SetEnv(func(key string) (string, bool) {
+				v, ok := tt.env[key]
+				return v, ok
+			})
+			var token, name string
+			var port int
+			var timeout time.Duration
+			f.StringVar(&token, "token", "", "help")
+			f.IntVar(&port, "port", 8080, "help")
+			f.DurationVar(&timeout, "timeout", 10*time.Second, "help")
+			f.StringVar(&name, "name", "app", "help")
+			f.Required("token")
+			f.Validate("port", InRange(1, 65535))
+			f.Validate("timeout", InRange(time.Second, time.Minute))
+			f.Validate("name", Matches(`^[a-z0-9-]+$`))
+
+			err := f.Parse(tt.args)
+			if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+				t.Errorf("Parse error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_Misuse(t *testing.T) {
+	if err := InRange(1, 2)("x"); err == nil {
+		t.Errorf("InRange of the wrong type should fail")
+	}
+	if err := errors.Join(InRange(1.0, 2.0)(1.5), Matches("^a")("abc")); err != nil {
+		t.Errorf("valid values failed: %v", err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Required of an undefined flag should panic")
+		}
+	}()
+	NewFlagSet("test", flag.ContinueOnError).Required("nope")
+}
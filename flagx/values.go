@@ -0,0 +1,145 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package flagx
+
+import (
+	"flag"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// A flag.Value which can be set more than once on the command line, building up its value,
+// but which takes all of its value from the environment or envkv. See setVar.
+type sourceValue interface {
+	flag.Value
+	setSource(s string) error
+}
+
+// Splits a comma-separated list, ignoring whitespace around items, and empty items.
+func splitList(s string) []string {
+	var out []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+type stringSliceValue struct {
+	p *[]string
+	// Whether it has been set on the command line, so later flags are added to it,
+	// rather than replacing the value from elsewhere.
+	set bool
+}
+
+func (v *stringSliceValue) String() string {
+	if v.p == nil {
+		return ""
+	}
+	return strings.Join(*v.p, ",")
+}
+
+func (v *stringSliceValue) Get() any {
+	return *v.p
+}
+
+func (v *stringSliceValue) Set(s string) error {
+	if !v.set {
+		*v.p = nil
+		v.set = true
+	}
+	*v.p = append(*v.p, splitList(s)...)
+	return nil
+}
+
+func (v *stringSliceValue) setSource(s string) error {
+	*v.p = splitList(s)
+	v.set = false
+	return nil
+}
+
+// StringSliceVar defines a flag holding a list of strings. It may be given more than once on the command line,
+// and each may be a comma-separated list, e.g. "-host a,b -host c" is [a b c].
+// In the environment and envkv, it is a comma-separated list.
+//
+// Each source replaces the list from the sources it takes precedence over; they aren't combined.
+func StringSliceVar(val *[]string, key string, defaultVal []string, help string) {
+	CommandLine.StringSliceVar(val, key, defaultVal, help)
+}
+
+// See StringSliceVar.
+func (f *FlagSet) StringSliceVar(val *[]string, key string, defaultVal []string, help string) {
+	*val = slices.Clone(defaultVal)
+	f.add(key, val, defaultVal, help)
+	f.fs.Var(&stringSliceValue{p: val}, key, help)
+}
+
+type stringToStringValue struct {
+	p   *map[string]string
+	set bool
+}
+
+func (v *stringToStringValue) String() string {
+	if v.p == nil {
+		return ""
+	}
+	var pairs []string
+	for _, k := range slices.Sorted(maps.Keys(*v.p)) {
+		pairs = append(pairs, k+"="+(*v.p)[k])
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (v *stringToStringValue) Get() any {
+	return *v.p
+}
+
+func (v *stringToStringValue) parse(s string, into map[string]string) error {
+	for _, pair := range splitList(s) {
+		k, val, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(k) == "" {
+			return fmt.Errorf("%q isn't of the form key=value", pair)
+		}
+		into[strings.TrimSpace(k)] = strings.TrimSpace(val)
+	}
+	return nil
+}
+
+func (v *stringToStringValue) Set(s string) error {
+	if !v.set {
+		*v.p = map[string]string{}
+		v.set = true
+	}
+	return v.parse(s, *v.p)
+}
+
+func (v *stringToStringValue) setSource(s string) error {
+	m := map[string]string{}
+	if err := v.parse(s, m); err != nil {
+		return err
+	}
+	*v.p = m
+	v.set = false
+	return nil
+}
+
+// StringToStringVar defines a flag holding a map of strings, given as comma-separated key=value pairs,
+// e.g. "-label env=prod,team=web". It may be given more than once on the command line, and later
+// keys replace earlier ones.
+//
+// Each source replaces the map from the sources it takes precedence over; they aren't combined.
+func StringToStringVar(val *map[string]string, key string, defaultVal map[string]string, help string) {
+	CommandLine.StringToStringVar(val, key, defaultVal, help)
+}
+
+// See StringToStringVar.
+func (f *FlagSet) StringToStringVar(val *map[string]string, key string, defaultVal map[string]string, help string) {
+	*val = maps.Clone(defaultVal)
+	f.add(key, val, defaultVal, help)
+	f.fs.Var(&stringToStringValue{p: val}, key, help)
+}
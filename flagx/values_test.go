@@ -0,0 +1,97 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package flagx
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestStringSliceAndMap(t *testing.T) {
+	tests := []struct {
+		name       string
+		envkv      string
+		env        map[string]string
+		args       []string
+		wantHosts  []string
+		wantLabels map[string]string
+	}{
+		{
+			name:       "defaults",
+			wantHosts:  []string{"localhost"},
+			wantLabels: map[string]string{"env": "dev"},
+		},
+		{
+			name:       "envkv",
+			envkv:      "HOSTS=\"a, b\"\nLABELS=\"env=prod,team=web\"\n",
+			wantHosts:  []string{"a", "b"},
+			wantLabels: map[string]string{"env": "prod", "team": "web"},
+		},
+		{
+			name:       "env replaces envkv",
+			envkv:      "HOSTS=\"a,b\"\nLABELS=\"env=prod,team=web\"\n",
+			env:        map[string]string{"HOSTS": "c", "LABELS": "team=db"},
+			wantHosts:  []string{"c"},
+			wantLabels: map[string]string{"team": "db"},
+		},
+		{
+			name:       "repeated flags accumulate, replacing env",
+			env:        map[string]string{"HOSTS": "c", "LABELS": "team=db"},
+			args:       []string{"-hosts", "x,y", "-hosts=z", "-labels", "a=1,b=2", "-labels", "b=3"},
+			wantHosts:  []string{"x", "y", "z"},
+			wantLabels: map[string]string{"a": "1", "b": "3"},
+		},
+		{
+			name:       "empty",
+			env:        map[string]string{"HOSTS": "", "LABELS": ""},
+			wantHosts:  nil,
+			wantLabels: map[string]string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer clearVars()
+
+			var hosts []string
+			var labels map[string]string
+			StringSliceVar(&hosts, "hosts", []string{"localhost"}, "help")
+			StringToStringVar(&labels, "labels", map[string]string{"env": "dev"}, "help")
+
+			if tt.envkv != "" {
+				os.WriteFile(".envkv", []byte(tt.envkv), 0644)
+				defer os.Remove(".envkv")
+			}
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			origArgs := os.Args
+			os.Args = append([]string{"cmd"}, tt.args...)
+			defer func() { os.Args = origArgs }()
+
+			Parse()
+
+			if !reflect.DeepEqual(hosts, tt.wantHosts) {
+				t.Errorf("hosts = %q, want %q", hosts, tt.wantHosts)
+			}
+			if !reflect.DeepEqual(labels, tt.wantLabels) {
+				t.Errorf("labels = %q, want %q", labels, tt.wantLabels)
+			}
+		})
+	}
+}
+
+func TestStringToString_Invalid(t *testing.T) {
+	var m map[string]string
+	v := &stringToStringValue{p: &m}
+	for _, s := range []string{"novalue", "=x", "a=1,b"} {
+		if err := v.Set(s); err == nil {
+			t.Errorf("Set(%q) should fail", s)
+		}
+	}
+	if err := v.Set("a=1"); err != nil || v.String() != "a=1" {
+		t.Errorf("Set(a=1) = %v, String() = %q", err, v.String())
+	}
+}
@@ -28,6 +28,7 @@ func WriteFile(file string, data []byte, perm os.FileMode) error {
 		return fmt.Errorf("tmp create: %w", err)
 	}
 	tmp := tmpfile.Name()
+	tmpfile.Close()
 
 	// Clean up the temp file if something went wrong.
 	removeTemp := true
@@ -41,6 +42,11 @@ func WriteFile(file string, data []byte, perm os.FileMode) error {
 	if err != nil {
 		return fmt.Errorf("tmp write: %w", err)
 	}
+	// The temp file was created 0600, which WriteFile doesn't change.
+	err = os.Chmod(tmp, perm)
+	if err != nil {
+		return fmt.Errorf("tmp chmod: %w", err)
+	}
 	fh, err := os.Open(tmp)
 	if err != nil {
 		return fmt.Errorf("tmp open: %w", err)
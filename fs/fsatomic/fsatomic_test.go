@@ -66,3 +66,17 @@ func TestWriteFileAtomicBadPath(t *testing.T) {
 		t.Fatal("Expected failure on bad path, got nil")
 	}
 }
+
+func TestWriteFileAtomicPerm(t *testing.T) {
+	target := filepath.Join(t.TempDir(), "test.txt")
+	if err := WriteFile(target, []byte("hello"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	fi, err := os.Stat(target)
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	if perm := fi.Mode().Perm(); perm != 0644 {
+		t.Errorf("perm = %v, want %v", perm, os.FileMode(0644))
+	}
+}
@@ -0,0 +1,164 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slogx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+// Returned by an AsyncHandler after it has been closed.
+var ErrHandlerClosed = errors.New("slogx: handler closed")
+
+// Configures NewAsyncHandler.
+type AsyncOptions struct {
+	// How many records can be queued. If zero, 1024 is used.
+	BufferSize int
+
+	// If true, records are dropped when the queue is full, rather than blocking the caller
+	// until there is room. See AsyncHandler.Dropped.
+	Drop bool
+}
+
+// An AsyncHandler queues records, and hands them to another handler from a background goroutine,
+// so that formatting and writing them is kept out of latency-sensitive code.
+//
+// Errors from the base handler can't be returned, so they are written to stderr.
+// Call Flush or Close before exiting, so that queued records aren't lost.
+type AsyncHandler struct {
+	base slog.Handler
+	q    *asyncQueue
+}
+
+// A queue shared by an AsyncHandler, and the handlers derived from it by WithAttrs and WithGroup.
+type asyncQueue struct {
+	drop    bool
+	ch      chan asyncItem
+	done    chan struct{}
+	dropped atomic.Uint64
+
+	mu     sync.RWMutex // held for writing while closing
+	closed bool
+}
+
+type asyncItem struct {
+	h     slog.Handler
+	ctx   context.Context
+	r     slog.Record
+	flush chan struct{} // if set, this is a Flush request rather than a record
+}
+
+// Returns an AsyncHandler which writes to base, and starts its background goroutine.
+func NewAsyncHandler(base slog.Handler, opts AsyncOptions) *AsyncHandler {
+	if opts.BufferSize == 0 {
+		opts.BufferSize = 1024
+	}
+	q := &asyncQueue{
+		drop: opts.Drop,
+		ch:   make(chan asyncItem, opts.BufferSize),
+		done: make(chan struct{}),
+	}
+	go q.run()
+	return &AsyncHandler{base: base, q: q}
+}
+
+func (q *asyncQueue) run() {
+	defer close(q.done)
+	for item := range q.ch {
+		if item.flush != nil {
+			close(item.flush)
+			continue
+		}
+		if err := item.h.Handle(item.ctx, item.r); err != nil {
+			fmt.Fprintf(os.Stderr, "slogx: async handler: %v\n", err)
+		}
+	}
+}
+
+func (h *AsyncHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.base.Enabled(ctx, level)
+}
+
+// Queues r, returning ErrHandlerClosed if the handler has been closed.
+func (h *AsyncHandler) Handle(ctx context.Context, r slog.Record) error {
+	h.q.mu.RLock()
+	defer h.q.mu.RUnlock()
+	if h.q.closed {
+		return ErrHandlerClosed
+	}
+
+	// The record outlives the call, so it can't share the caller's attrs, or be cancelled with it.
+	item := asyncItem{h: h.base, ctx: context.WithoutCancel(ctx), r: r.Clone()}
+	if !h.q.drop {
+		h.q.ch <- item
+		return nil
+	}
+	select {
+	case h.q.ch <- item:
+	default:
+		h.q.dropped.Add(1)
+	}
+	return nil
+}
+
+func (h *AsyncHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &AsyncHandler{base: h.base.WithAttrs(attrs), q: h.q}
+}
+
+func (h *AsyncHandler) WithGroup(name string) slog.Handler {
+	return &AsyncHandler{base: h.base.WithGroup(name), q: h.q}
+}
+
+// Returns how many records have been dropped because the queue was full. See AsyncOptions.Drop.
+func (h *AsyncHandler) Dropped() uint64 {
+	return h.q.dropped.Load()
+}
+
+// Waits until the records queued so far have been handled, or ctx is done.
+func (h *AsyncHandler) Flush(ctx context.Context) error {
+	h.q.mu.RLock()
+	if h.q.closed {
+		h.q.mu.RUnlock()
+		return ErrHandlerClosed
+	}
+	flushed := make(chan struct{})
+	select {
+	case h.q.ch <- asyncItem{flush: flushed}:
+	case <-ctx.Done():
+		h.q.mu.RUnlock()
+		return ctx.Err()
+	}
+	h.q.mu.RUnlock()
+
+	select {
+	case <-flushed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Stops accepting records, and waits until the queued ones have been handled, or ctx is done.
+// It affects all handlers derived from h.
+func (h *AsyncHandler) Close(ctx context.Context) error {
+	h.q.mu.Lock()
+	if !h.q.closed {
+		h.q.closed = true
+		close(h.q.ch)
+	}
+	h.q.mu.Unlock()
+
+	select {
+	case <-h.q.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slogx
+
+import (
+	"bytes"
+	"context"
+	"github.com/rburchell/gosh/log/slogx/slogxtest"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// A handler which blocks until released, to fill up an AsyncHandler's queue.
+type blockingHandler struct {
+	*slogxtest.Handler
+	release chan struct{}
+}
+
+func (h *blockingHandler) Handle(ctx context.Context, r slog.Record) error {
+	<-h.release
+	return h.Handler.Handle(ctx, r)
+}
+
+func TestAsyncHandler(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewAsyncHandler(NewJSONHandler(&buf), AsyncOptions{})
+	logger := slog.New(h).With("req", 1)
+	for range 10 {
+		logger.Info("queued")
+	}
+	if err := h.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Count(buf.String(), `"req":1}`); got != 10 {
+		t.Errorf("got %d records after Flush, want 10:\n%s", got, buf.String())
+	}
+
+	logger.Info("last")
+	if err := h.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `"msg":"last"`) {
+		t.Errorf("record lost by Close")
+	}
+	if err := logger.Handler().Handle(context.Background(), slog.Record{}); err != ErrHandlerClosed {
+		t.Errorf("Handle after Close = %v", err)
+	}
+	if err := h.Flush(context.Background()); err != ErrHandlerClosed {
+		t.Errorf("Flush after Close = %v", err)
+	}
+}
+
+func TestAsyncHandler_Drop(t *testing.T) {
+	base := &blockingHandler{Handler: slogxtest.NewHandler(), release: make(chan struct{})}
+	h := NewAsyncHandler(base, AsyncOptions{BufferSize: 2, Drop: true})
+	logger := slog.New(h)
+
+	// One record may be taken by the background goroutine, and then two can be queued.
+	for range 10 {
+		logger.Info("flood")
+	}
+	if d := h.Dropped(); d < 7 || d > 8 {
+		t.Errorf("Dropped = %d, want 7 or 8", d)
+	}
+
+	close(base.release)
+	if err := h.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := uint64(len(base.Entries())) + h.Dropped(); got != 10 {
+		t.Errorf("handled + dropped = %d, want 10", got)
+	}
+}
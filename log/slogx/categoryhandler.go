@@ -13,11 +13,12 @@ import (
 // as well as providing the ability to set per-category minimum levels.
 type categoryHandler struct {
 	base     slog.Handler
+	category string
 	minLevel slog.Level
 }
 
 func (h *categoryHandler) Enabled(ctx context.Context, lvl slog.Level) bool {
-	return lvl >= h.minLevel
+	return lvl >= categoryLevel(h.category, h.minLevel)
 }
 
 func (h *categoryHandler) Handle(ctx context.Context, r slog.Record) error {
@@ -27,6 +28,7 @@ func (h *categoryHandler) Handle(ctx context.Context, r slog.Record) error {
 func (h *categoryHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
 	return &categoryHandler{
 		base:     h.base.WithAttrs(attrs),
+		category: h.category,
 		minLevel: h.minLevel,
 	}
 }
@@ -34,6 +36,7 @@ func (h *categoryHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
 func (h *categoryHandler) WithGroup(name string) slog.Handler {
 	return &categoryHandler{
 		base:     h.base.WithGroup(name),
+		category: h.category,
 		minLevel: h.minLevel,
 	}
 }
@@ -41,10 +44,13 @@ func (h *categoryHandler) WithGroup(name string) slog.Handler {
 // Creates a logger with a fixed category and minLevel, and a given underlying base handler.
 //
 // Note that minLevel only applies to filtering done by this handler; 'base' may do its own filtering.
+// It can be overridden at runtime; see Configure.
 func NewCategory(category string, base slog.Handler, minLevel slog.Level) *slog.Logger {
 	handler := &categoryHandler{
 		base:     base,
+		category: category,
 		minLevel: minLevel,
 	}
+	categories.Store(category, minLevel)
 	return slog.New(handler).With("category", category)
 }
@@ -0,0 +1,82 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slogx
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+// A ContextExtractor returns attrs describing ctx (e.g. a request ID), to add to records logged with it.
+type ContextExtractor func(ctx context.Context) []slog.Attr
+
+var (
+	extractorsMu sync.RWMutex
+	extractors   []ContextExtractor
+)
+
+// Registers fn to be used by every handler from NewContextHandler.
+//
+// This is intended to be called from init functions, by packages which put values in contexts.
+// For example, the middleware package registers the CID, RID, and trace IDs of requests.
+func RegisterContextExtractor(fn ContextExtractor) {
+	extractorsMu.Lock()
+	defer extractorsMu.Unlock()
+	extractors = append(extractors, fn)
+}
+
+// Returns a slog.Handler which adds attrs from the registered ContextExtractors (see RegisterContextExtractor),
+// and then extra, to each record logged with a context (e.g. with InfoContext), before passing it to base.
+//
+// This gives request-correlated logs, without passing attrs around by hand:
+//
+//	var log = slogx.NewCategory("app", slogx.NewContextHandler(slogx.TextHandler), slog.LevelInfo)
+//	...
+//	log.InfoContext(r.Context(), "Created user") // includes cid=... rid=...
+//
+// Like other record attrs, they are nested inside any groups from WithGroup.
+func NewContextHandler(base slog.Handler, extra ...ContextExtractor) slog.Handler {
+	return &contextHandler{base: base, extra: extra}
+}
+
+type contextHandler struct {
+	base  slog.Handler
+	extra []ContextExtractor
+}
+
+func (h *contextHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.base.Enabled(ctx, level)
+}
+
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if ctx == nil || ctx == context.Background() {
+		return h.base.Handle(ctx, r)
+	}
+
+	var attrs []slog.Attr
+	extractorsMu.RLock()
+	for _, fn := range extractors {
+		attrs = append(attrs, fn(ctx)...)
+	}
+	extractorsMu.RUnlock()
+	for _, fn := range h.extra {
+		attrs = append(attrs, fn(ctx)...)
+	}
+
+	if len(attrs) > 0 {
+		r = r.Clone()
+		r.AddAttrs(attrs...)
+	}
+	return h.base.Handle(ctx, r)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{base: h.base.WithAttrs(attrs), extra: h.extra}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{base: h.base.WithGroup(name), extra: h.extra}
+}
@@ -0,0 +1,43 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slogx
+
+import (
+	"context"
+	"github.com/rburchell/gosh/log/slogx/slogxtest"
+	"log/slog"
+	"testing"
+)
+
+type testCtxKey struct{}
+
+func TestContextHandler(t *testing.T) {
+	defer func(saved []ContextExtractor) { extractors = saved }(extractors)
+	extractors = nil
+
+	RegisterContextExtractor(func(ctx context.Context) []slog.Attr {
+		if id, ok := ctx.Value(testCtxKey{}).(string); ok {
+			return []slog.Attr{slog.String("rid", id)}
+		}
+		return nil
+	})
+	tenant := func(ctx context.Context) []slog.Attr {
+		return []slog.Attr{slog.String("tenant", "acme")}
+	}
+
+	base := slogxtest.NewHandler()
+	logger := slog.New(NewContextHandler(base, tenant))
+
+	ctx := context.WithValue(context.Background(), testCtxKey{}, "abc123")
+	logger.InfoContext(ctx, "with ctx")
+	logger.Info("without ctx")
+
+	if got := base.AttrsFor("with ctx"); got["rid"].String() != "abc123" || got["tenant"].String() != "acme" {
+		t.Errorf("with ctx: got %v", got)
+	}
+	if got := base.AttrsFor("without ctx"); len(got) != 0 {
+		t.Errorf("without ctx: got %v", got)
+	}
+}
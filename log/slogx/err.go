@@ -0,0 +1,86 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slogx
+
+import (
+	"fmt"
+	"log/slog"
+	"runtime"
+	"strings"
+)
+
+// The most stack frames ErrStack captures.
+const maxStackDepth = 64
+
+// Returns an "err" attr for err.
+//
+// If err wraps other errors (see errors.Unwrap), the attr is a group of its message ("msg"),
+// and the types of the errors in its chain ("chain"), outermost first, to help find where it came from.
+// Otherwise, it is just the message.
+func Err(err error) slog.Attr {
+	return slog.Any("err", errValue{err: err})
+}
+
+// The same as Err, but also captures the stack of the caller, in a "stack" attr in the group.
+// The text handler prints multi-line values like this on their own lines, after the record.
+func ErrStack(err error) slog.Attr {
+	pcs := make([]uintptr, maxStackDepth)
+	n := runtime.Callers(2, pcs)
+	return slog.Any("err", errValue{err: err, stack: pcs[:n]})
+}
+
+type errValue struct {
+	err   error
+	stack []uintptr
+}
+
+func (v errValue) LogValue() slog.Value {
+	if v.err == nil {
+		return slog.StringValue("<nil>")
+	}
+	chain := errChain(v.err, nil)
+	if len(chain) == 1 && v.stack == nil {
+		return slog.StringValue(v.err.Error())
+	}
+
+	attrs := []slog.Attr{slog.String("msg", v.err.Error())}
+	if len(chain) > 1 {
+		attrs = append(attrs, slog.String("chain", strings.Join(chain, " > ")))
+	}
+	if v.stack != nil {
+		attrs = append(attrs, slog.String("stack", formatStack(v.stack)))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// Appends the types of err, and the errors it wraps (depth first), to chain.
+func errChain(err error, chain []string) []string {
+	chain = append(chain, fmt.Sprintf("%T", err))
+	switch u := err.(type) {
+	case interface{ Unwrap() error }:
+		if inner := u.Unwrap(); inner != nil {
+			chain = errChain(inner, chain)
+		}
+	case interface{ Unwrap() []error }:
+		for _, inner := range u.Unwrap() {
+			chain = errChain(inner, chain)
+		}
+	}
+	return chain
+}
+
+// Formats pcs like a panic's stack trace.
+func formatStack(pcs []uintptr) string {
+	var b strings.Builder
+	frames := runtime.CallersFrames(pcs)
+	for {
+		frame, more := frames.Next()
+		fmt.Fprintf(&b, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+		if !more {
+			break
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,75 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slogx
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/rburchell/gosh/log/slogx/slogxtest"
+	"io/fs"
+	"log/slog"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestErr(t *testing.T) {
+	pathErr := &fs.PathError{Op: "open", Path: "/x", Err: fs.ErrNotExist}
+	tests := []struct {
+		name  string
+		err   error
+		attrs map[string]string
+	}{
+		{"plain", errors.New("boom"), map[string]string{"err": "boom"}},
+		{"nil", nil, map[string]string{"err": "<nil>"}},
+		{
+			"wrapped",
+			fmt.Errorf("loading config: %w", pathErr),
+			map[string]string{
+				"err.msg":   "loading config: open /x: file does not exist",
+				"err.chain": "*fmt.wrapError > *fs.PathError > *errors.errorString",
+			},
+		},
+		{
+			"joined",
+			errors.Join(errors.New("a"), pathErr),
+			map[string]string{
+				"err.msg":   "a\nopen /x: file does not exist",
+				"err.chain": "*errors.joinError > *errors.errorString > *fs.PathError > *errors.errorString",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := slogxtest.NewHandler()
+			slog.New(h).Error("failed", Err(tt.err))
+			got := map[string]string{}
+			for k, v := range h.AttrsFor("failed") {
+				got[k] = v.String()
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.attrs) {
+				t.Errorf("got %v, want %v", got, tt.attrs)
+			}
+		})
+	}
+}
+
+func TestErrStack(t *testing.T) {
+	var buf bytes.Buffer
+	slog.New(NewTextHandler(&buf)).Error("failed", "category", "tst", ErrStack(errors.New("boom")), "after", 1)
+
+	lines := strings.Split(buf.String(), "\n")
+	if lines[0] != "tst       failed err.msg=boom after=1" {
+		t.Errorf("first line = %q", lines[0])
+	}
+	if lines[1] != "    err.stack:" {
+		t.Errorf("second line = %q", lines[1])
+	}
+	if !regexp.MustCompile(`^        github.com/rburchell/gosh/log/slogx\.TestErrStack$`).MatchString(lines[2]) ||
+		!regexp.MustCompile(`^        \t.*/err_test.go:\d+$`).MatchString(lines[3]) {
+		t.Errorf("stack starts with:\n%s\n%s", lines[2], lines[3])
+	}
+}
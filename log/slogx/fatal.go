@@ -0,0 +1,58 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slogx
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"runtime"
+	"time"
+)
+
+// Levels above slog.LevelError, used by Panic and Fatal.
+const (
+	LevelPanic slog.Level = slog.LevelError + 4
+	LevelFatal slog.Level = slog.LevelError + 8
+)
+
+// Exits the process; replaced in tests.
+var exit = os.Exit
+
+// Returns the name of level, including LevelPanic and LevelFatal (as "PANIC" and "FATAL").
+func LevelString(level slog.Level) string {
+	switch level {
+	case LevelPanic:
+		return "PANIC"
+	case LevelFatal:
+		return "FATAL"
+	}
+	return level.String()
+}
+
+// Logs msg and args (as with slog.Logger.Error) to l at LevelFatal, and then exits the process with status 1.
+func Fatal(l *slog.Logger, msg string, args ...any) {
+	logAt(l, LevelFatal, msg, args...)
+	exit(1)
+}
+
+// Logs msg and args (as with slog.Logger.Error) to l at LevelPanic, and then panics with msg.
+func Panic(l *slog.Logger, msg string, args ...any) {
+	logAt(l, LevelPanic, msg, args...)
+	panic(msg)
+}
+
+// Logs to l at level, with the source being the caller of Fatal or Panic.
+func logAt(l *slog.Logger, level slog.Level, msg string, args ...any) {
+	ctx := context.Background()
+	if !l.Enabled(ctx, level) {
+		return
+	}
+	var pcs [1]uintptr
+	runtime.Callers(3, pcs[:]) // skip Callers, logAt, and Fatal/Panic
+	r := slog.NewRecord(time.Now(), level, msg, pcs[0])
+	r.Add(args...)
+	l.Handler().Handle(ctx, r)
+}
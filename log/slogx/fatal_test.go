@@ -0,0 +1,41 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slogx
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestFatal(t *testing.T) {
+	defer func(saved func(int)) { exit = saved }(exit)
+	code := -1
+	exit = func(c int) { code = c }
+
+	var buf bytes.Buffer
+	Fatal(slog.New(NewJSONHandler(&buf)), "Can't listen", "addr", ":80")
+	if code != 1 {
+		t.Errorf("exit code = %d", code)
+	}
+	got := buf.String()
+	if !strings.Contains(got, `"level":"FATAL","msg":"Can't listen"`) || !strings.Contains(got, `fatal_test.go"`) || !strings.Contains(got, `"addr":":80"`) {
+		t.Errorf("got %s", got)
+	}
+}
+
+func TestPanic(t *testing.T) {
+	var buf bytes.Buffer
+	defer func() {
+		if r := recover(); r != "Corrupt state" {
+			t.Errorf("recovered %v", r)
+		}
+		if got := buf.String(); !strings.Contains(got, "Corrupt state") {
+			t.Errorf("got %s", got)
+		}
+	}()
+	Panic(slog.New(NewTextHandler(&buf)), "Corrupt state")
+}
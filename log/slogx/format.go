@@ -0,0 +1,46 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slogx
+
+import (
+	"log/slog"
+	"strconv"
+	"time"
+)
+
+// A count of bytes, which is written by the text handler in a human-readable form (e.g. "1.5KiB"),
+// and by the JSON handler as a plain number. See Bytes.
+type ByteSize int64
+
+// Returns n in bytes (e.g. "512B"), or with a binary unit (e.g. "1.5KiB", "12.3MiB").
+func (n ByteSize) String() string {
+	const units = "KMGTPE"
+	if n < 1024 && n > -1024 {
+		return strconv.FormatInt(int64(n), 10) + "B"
+	}
+	f := float64(n)
+	i := -1
+	for (f >= 1024 || f <= -1024) && i < len(units)-1 {
+		f /= 1024
+		i++
+	}
+	return strconv.FormatFloat(f, 'f', 1, 64) + string(units[i]) + "iB"
+}
+
+// Returns an attr for a count of bytes, e.g. the size of a response. See ByteSize.
+func Bytes(key string, n int64) slog.Attr {
+	return slog.Any(key, ByteSize(n))
+}
+
+// Returns d rounded to three significant figures (e.g. "1.23s" rather than "1.23456789s").
+func formatDuration(d time.Duration) string {
+	abs := d.Abs()
+	quantum := time.Duration(1)
+	for abs >= 1000 {
+		abs /= 10
+		quantum *= 10
+	}
+	return d.Round(quantum).String()
+}
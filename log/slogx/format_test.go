@@ -0,0 +1,65 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slogx
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestByteSize(t *testing.T) {
+	tests := []struct {
+		n    int64
+		want string
+	}{
+		{0, "0B"},
+		{1023, "1023B"},
+		{1536, "1.5KiB"},
+		{12_900_000, "12.3MiB"},
+		{-2048, "-2.0KiB"},
+		{1 << 62, "4.0EiB"},
+	}
+	for _, tt := range tests {
+		if got := ByteSize(tt.n).String(); got != tt.want {
+			t.Errorf("ByteSize(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{0, "0s"},
+		{999, "999ns"},
+		{1234567890, "1.23s"},
+		{12345678, "12.3ms"},
+		{456789, "457µs"},
+		{-1500 * time.Millisecond, "-1.5s"},
+		{90 * time.Minute, "1h30m0s"},
+	}
+	for _, tt := range tests {
+		if got := formatDuration(tt.d); got != tt.want {
+			t.Errorf("formatDuration(%d) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestFormat_Handlers(t *testing.T) {
+	var text, json bytes.Buffer
+	for _, h := range []slog.Handler{NewTextHandler(&text, WithColor(false)), NewJSONHandler(&json)} {
+		slog.New(h).Info("served", "took", 1234567890*time.Nanosecond, Bytes("size", 1536))
+	}
+	if got := text.String(); !strings.HasSuffix(got, "served took=1.23s size=1.5KiB\n") {
+		t.Errorf("text got %q", got)
+	}
+	if got := json.String(); !strings.HasSuffix(got, `"took":1234567890,"size":1536}`+"\n") {
+		t.Errorf("json got %q", got)
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slogx
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// The socket that journald listens for native protocol messages on.
+const journalSocket = "/run/systemd/journal/socket"
+
+// Returns a handler which writes records to w using the journald native protocol.
+// See NewJournalHandler.
+func newJournalHandler(w io.Writer) slog.Handler {
+	return &journalHandler{mu: &sync.Mutex{}, w: w, identifier: filepath.Base(os.Args[0])}
+}
+
+type journalHandler struct {
+	mu         *sync.Mutex
+	w          io.Writer
+	identifier string
+	flatAttrs
+}
+
+// Returns key as a journal field name: uppercase letters, digits and underscores,
+// not starting with an underscore or digit (which are reserved, or invalid).
+func journalFieldName(key string) string {
+	var b strings.Builder
+	for _, c := range strings.ToUpper(key) {
+		if (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '_' {
+			b.WriteRune(c)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	name := strings.TrimLeft(b.String(), "_0123456789")
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	return name
+}
+
+// Appends a field to buf, in the native protocol's format.
+func appendJournalField(buf *bytes.Buffer, name, value string) {
+	if name == "" {
+		return
+	}
+	buf.WriteString(name)
+	if !strings.Contains(value, "\n") {
+		buf.WriteByte('=')
+		buf.WriteString(value)
+		buf.WriteByte('\n')
+		return
+	}
+	// Values with newlines are sent as the name, then a little-endian 64-bit length, then the value.
+	buf.WriteByte('\n')
+	binary.Write(buf, binary.LittleEndian, uint64(len(value)))
+	buf.WriteString(value)
+	buf.WriteByte('\n')
+}
+
+func (h *journalHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return true
+}
+
+func (h *journalHandler) Handle(ctx context.Context, r slog.Record) error {
+	var buf bytes.Buffer
+	appendJournalField(&buf, "MESSAGE", r.Message)
+	appendJournalField(&buf, "PRIORITY", strconv.Itoa(syslogSeverity(r.Level)))
+	appendJournalField(&buf, "SYSLOG_IDENTIFIER", h.identifier)
+	if r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		appendJournalField(&buf, "CODE_FILE", frame.File)
+		appendJournalField(&buf, "CODE_LINE", strconv.Itoa(frame.Line))
+		appendJournalField(&buf, "CODE_FUNC", frame.Function)
+	}
+
+	for _, attr := range h.recordAttrs(r) {
+		appendJournalField(&buf, journalFieldName(attr.Key), attr.Value.String())
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := h.w.Write(buf.Bytes())
+	return err
+}
+
+func (h *journalHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := *h
+	h2.flatAttrs = h.withAttrs(attrs)
+	return &h2
+}
+
+func (h *journalHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.flatAttrs = h.withGroup(name)
+	return &h2
+}
@@ -0,0 +1,25 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slogx
+
+import (
+	"log/slog"
+	"net"
+)
+
+// Returns a slog.Handler which sends records to the systemd journal, or an error if it isn't available.
+//
+// The level is mapped to a syslog priority (as with NewSyslogHandler), the source location is
+// recorded as CODE_FILE, CODE_LINE and CODE_FUNC, and attrs become fields, with their keys uppercased
+// (e.g. category becomes CATEGORY, and req.path becomes REQ_PATH).
+//
+// Each record is sent as a single datagram, so very large records may be rejected.
+func NewJournalHandler() (slog.Handler, error) {
+	conn, err := net.Dial("unixgram", journalSocket)
+	if err != nil {
+		return nil, err
+	}
+	return newJournalHandler(conn), nil
+}
@@ -0,0 +1,17 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !linux
+
+package slogx
+
+import (
+	"errors"
+	"log/slog"
+)
+
+// The systemd journal is only available on Linux, so this always returns an error.
+func NewJournalHandler() (slog.Handler, error) {
+	return nil, errors.New("slogx: the systemd journal is only available on Linux")
+}
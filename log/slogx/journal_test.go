@@ -0,0 +1,48 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slogx
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestJournalHandler(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewCategory("db", newJournalHandler(&buf), slog.LevelDebug)
+	logger.With(slog.Group("req", "path", "/x")).Error("Query failed", "_secret", "no", "err", "line1\nline2")
+
+	got := buf.String()
+	for _, want := range []string{
+		"MESSAGE=Query failed\n",
+		"PRIORITY=3\n",
+		"CATEGORY=db\n",
+		"REQ_PATH=/x\n",
+		"SECRET=no\n",
+		"CODE_FUNC=github.com/rburchell/gosh/log/slogx.TestJournalHandler\n",
+		"ERR\n\x0b\x00\x00\x00\x00\x00\x00\x00line1\nline2\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in:\n%q", want, got)
+		}
+	}
+}
+
+func TestJournalFieldName(t *testing.T) {
+	tests := map[string]string{
+		"category":  "CATEGORY",
+		"req.path":  "REQ_PATH",
+		"_internal": "INTERNAL",
+		"1st":       "ST",
+		"user-id":   "USER_ID",
+	}
+	for key, want := range tests {
+		if got := journalFieldName(key); got != want {
+			t.Errorf("journalFieldName(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slogx
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Returns a new slog.Handler which writes each record to w as a line of JSON,
+// for shipping to log aggregators (Loki, ELK, and so on).
+//
+// Keys are always in the same order: time, level, category, msg, source, and then attrs,
+// in the order they were added. The category (see NewCategory) is a top-level field,
+// and groups are nested objects.
+func NewJSONHandler(w io.Writer) slog.Handler {
+	return &jsonHandler{mu: &sync.Mutex{}, w: w}
+}
+
+type jsonHandler struct {
+	mu *sync.Mutex
+	w  io.Writer
+	// The category, if it has been set with WithAttrs.
+	category string
+	// Attrs from WithAttrs, along with the groups that were open at the time.
+	attrs []groupedAttr
+	// The groups from WithGroup.
+	groups []string
+}
+
+type groupedAttr struct {
+	groups []string
+	attr   slog.Attr
+}
+
+// A key in a JSON object. val is either a slog.Value, or a []jsonField for a nested object.
+type jsonField struct {
+	key string
+	val any
+}
+
+func (h *jsonHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return true
+}
+
+func (h *jsonHandler) Handle(ctx context.Context, r slog.Record) error {
+	category := h.category
+	var fields []jsonField
+	for _, ga := range h.attrs {
+		fields = insertField(fields, ga.groups, ga.attr)
+	}
+	r.Attrs(func(attr slog.Attr) bool {
+		if len(h.groups) == 0 && attr.Key == "category" {
+			if s, ok := attr.Value.Any().(string); ok && s != "" {
+				category = s
+				return true
+			}
+		}
+		fields = insertField(fields, h.groups, attr)
+		return true
+	})
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	if !r.Time.IsZero() {
+		writeJSONKey(&buf, slog.TimeKey)
+		writeJSONString(&buf, r.Time.Format(time.RFC3339Nano))
+		buf.WriteByte(',')
+	}
+	writeJSONKey(&buf, slog.LevelKey)
+	writeJSONString(&buf, LevelString(r.Level))
+	if category != "" {
+		buf.WriteByte(',')
+		writeJSONKey(&buf, "category")
+		writeJSONString(&buf, category)
+	}
+	buf.WriteByte(',')
+	writeJSONKey(&buf, slog.MessageKey)
+	writeJSONString(&buf, r.Message)
+	if r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		buf.WriteByte(',')
+		writeJSONKey(&buf, slog.SourceKey)
+		fmt.Fprintf(&buf, `{"function":%s,"file":%s,"line":%d}`, jsonString(frame.Function), jsonString(frame.File), frame.Line)
+	}
+	for _, f := range fields {
+		writeJSONField(&buf, f, true)
+	}
+	buf.WriteString("}\n")
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := h.w.Write(buf.Bytes())
+	return err
+}
+
+func (h *jsonHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := *h
+	h2.attrs = append([]groupedAttr(nil), h.attrs...)
+	for _, attr := range attrs {
+		if len(h.groups) == 0 && attr.Key == "category" {
+			if s, ok := attr.Value.Any().(string); ok && s != "" {
+				h2.category = s
+				continue
+			}
+		}
+		h2.attrs = append(h2.attrs, groupedAttr{groups: h.groups, attr: attr})
+	}
+	return &h2
+}
+
+func (h *jsonHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.groups = append(h.groups[:len(h.groups):len(h.groups)], name)
+	return &h2
+}
+
+// Adds attr to fields, nested inside groups. If a field with the same key is already there,
+// it is replaced (or merged into, if both are groups), so that later attrs win.
+func insertField(fields []jsonField, groups []string, attr slog.Attr) []jsonField {
+	if len(groups) == 0 {
+		attr.Value = attr.Value.Resolve()
+		if attr.Value.Kind() == slog.KindGroup {
+			if attr.Key == "" {
+				for _, a := range attr.Value.Group() {
+					fields = insertField(fields, nil, a)
+				}
+				return fields
+			}
+			for _, a := range attr.Value.Group() {
+				fields = insertField(fields, []string{attr.Key}, a)
+			}
+			return fields
+		}
+		if attr.Equal(slog.Attr{}) {
+			return fields
+		}
+		for i := range fields {
+			if fields[i].key == attr.Key {
+				fields[i].val = attr.Value
+				return fields
+			}
+		}
+		return append(fields, jsonField{attr.Key, attr.Value})
+	}
+
+	for i := range fields {
+		if fields[i].key == groups[0] {
+			sub, _ := fields[i].val.([]jsonField)
+			fields[i].val = insertField(sub, groups[1:], attr)
+			return fields
+		}
+	}
+	return append(fields, jsonField{groups[0], insertField(nil, groups[1:], attr)})
+}
+
+// Writes f (preceded by a comma), returning false if it was skipped, as empty groups are.
+func writeJSONField(buf *bytes.Buffer, f jsonField, comma bool) bool {
+	sub, isGroup := f.val.([]jsonField)
+	if isGroup && len(sub) == 0 {
+		return false
+	}
+	if comma {
+		buf.WriteByte(',')
+	}
+	writeJSONKey(buf, f.key)
+	if !isGroup {
+		writeJSONValue(buf, f.val.(slog.Value))
+		return true
+	}
+	buf.WriteByte('{')
+	first := true
+	for _, sf := range sub {
+		if writeJSONField(buf, sf, !first) {
+			first = false
+		}
+	}
+	buf.WriteByte('}')
+	return true
+}
+
+func writeJSONKey(buf *bytes.Buffer, key string) {
+	writeJSONString(buf, key)
+	buf.WriteByte(':')
+}
+
+func writeJSONString(buf *bytes.Buffer, s string) {
+	buf.WriteString(jsonString(s))
+}
+
+func jsonString(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+func writeJSONValue(buf *bytes.Buffer, v slog.Value) {
+	switch v.Kind() {
+	case slog.KindString:
+		writeJSONString(buf, v.String())
+	case slog.KindInt64:
+		buf.WriteString(strconv.FormatInt(v.Int64(), 10))
+	case slog.KindUint64:
+		buf.WriteString(strconv.FormatUint(v.Uint64(), 10))
+	case slog.KindFloat64:
+		f := v.Float64()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			writeJSONString(buf, strconv.FormatFloat(f, 'g', -1, 64))
+		} else {
+			buf.WriteString(strconv.FormatFloat(f, 'g', -1, 64))
+		}
+	case slog.KindBool:
+		buf.WriteString(strconv.FormatBool(v.Bool()))
+	case slog.KindDuration:
+		buf.WriteString(strconv.FormatInt(int64(v.Duration()), 10))
+	case slog.KindTime:
+		writeJSONString(buf, v.Time().Format(time.RFC3339Nano))
+	default:
+		a := v.Any()
+		if err, ok := a.(error); ok {
+			writeJSONString(buf, err.Error())
+			return
+		}
+		b, err := json.Marshal(a)
+		if err != nil {
+			writeJSONString(buf, fmt.Sprintf("!ERROR:%v", err))
+			return
+		}
+		buf.Write(b)
+	}
+}
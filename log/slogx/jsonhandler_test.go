@@ -0,0 +1,76 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slogx
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJSONHandler(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewCategory("db", NewJSONHandler(&buf), slog.LevelDebug)
+
+	logger.With("conn", 3).WithGroup("query").Info("Slow query",
+		"took", 2*time.Second,
+		"err", errors.New("timeout"),
+		slog.Group("args", "id", 42),
+		slog.Group("empty"),
+	)
+
+	line := buf.String()
+	re := regexp.MustCompile(`^\{"time":"[^"]+","level":"INFO","category":"db","msg":"Slow query","source":\{"function":"[^"]+TestJSONHandler","file":"[^"]+jsonhandler_test.go","line":\d+\},` +
+		`"conn":3,"query":\{"took":2000000000,"err":"timeout","args":\{"id":42\}\}\}\n$`)
+	if !re.MatchString(line) {
+		t.Errorf("got:\n%s", line)
+	}
+	if !json.Valid([]byte(line)) {
+		t.Errorf("invalid JSON: %s", line)
+	}
+}
+
+func TestJSONHandler_Values(t *testing.T) {
+	tests := []struct {
+		name string
+		attr slog.Attr
+		want string
+	}{
+		{"string", slog.String("k", "a\"b\n"), `"k":"a\"b\n"`},
+		{"uint", slog.Uint64("k", 7), `"k":7`},
+		{"float", slog.Float64("k", 1.5), `"k":1.5`},
+		{"bool", slog.Bool("k", true), `"k":true`},
+		{"time", slog.Time("k", time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)), `"k":"2025-01-02T03:04:05Z"`},
+		{"struct", slog.Any("k", struct{ A int }{1}), `"k":{"A":1}`},
+		{"unmarshalable", slog.Any("k", func() {}), `"k":"!ERROR:json: unsupported type: func()"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			slog.New(NewJSONHandler(&buf)).LogAttrs(context.Background(), slog.LevelInfo, "m", tt.attr)
+			if got := buf.String(); !strings.HasSuffix(got, ","+tt.want+"}\n") {
+				t.Errorf("got %s, want suffix %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONHandler_Dedup(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewCategory("db", NewJSONHandler(&buf), slog.LevelDebug)
+	logger.With("a", 1, "b", 2, slog.Group("g", "x", 1)).Info("m", "category", "mine", "a", 3, slog.Group("g", "y", 2), "g", 4)
+
+	want := `"a":3,"b":2,"g":4}`
+	if got := buf.String(); !strings.Contains(got, `"category":"mine","msg":"m"`) || !strings.HasSuffix(got, want+"\n") ||
+		strings.Count(got, `"category"`) != 1 {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,26 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slogx
+
+import (
+	"log/slog"
+	"sync"
+)
+
+// Returns a value which is only computed by calling fn when a record containing it is handled,
+// so that expensive values cost nothing when the record is filtered out by its level, e.g:
+//
+//	log.Debug("pool", "stats", slogx.Lazy(func() slog.Value { return slog.AnyValue(db.Stats()) }))
+//
+// fn is called at most once, even if the record is handled by several handlers.
+func Lazy(fn func() slog.Value) slog.Value {
+	return slog.AnyValue(lazyValuer(sync.OnceValue(fn)))
+}
+
+type lazyValuer func() slog.Value
+
+func (f lazyValuer) LogValue() slog.Value {
+	return f()
+}
@@ -0,0 +1,36 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slogx
+
+import (
+	"github.com/rburchell/gosh/log/slogx/slogxtest"
+	"log/slog"
+	"testing"
+)
+
+func TestLazy(t *testing.T) {
+	calls := 0
+	stats := func() slog.Value {
+		calls++
+		return slog.IntValue(42)
+	}
+	capture1, capture2 := slogxtest.NewHandler(), slogxtest.NewHandler()
+	log := NewCategory("lazy", NewMultiHandler(capture1, capture2), slog.LevelInfo)
+
+	log.Debug("filtered", "stats", Lazy(stats))
+	if calls != 0 {
+		t.Errorf("filtered record: %d calls, want 0", calls)
+	}
+
+	log.Info("shown", "stats", Lazy(stats))
+	if calls != 1 {
+		t.Errorf("handled record: %d calls, want 1", calls)
+	}
+	for _, capture := range []*slogxtest.Handler{capture1, capture2} {
+		if got := capture.AttrsFor("shown")["stats"]; got.Any() != int64(42) {
+			t.Errorf("stats = %v, want 42", got)
+		}
+	}
+}
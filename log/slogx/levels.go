@@ -0,0 +1,117 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slogx
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// The environment variable read by init to Configure category levels.
+const EnvLevels = "GOSH_LOG"
+
+// The category levels set by Configure, keyed by category name, or "*" for all others.
+// It is replaced rather than modified, under levelsMu.
+var levelOverrides atomic.Pointer[map[string]slog.Level]
+
+var levelsMu sync.Mutex
+
+// The minLevel of each category created by NewCategory, keyed by category name.
+var categories sync.Map
+
+func init() {
+	if err := Configure(os.Getenv(EnvLevels)); err != nil {
+		fmt.Fprintf(os.Stderr, "slogx: ignoring %s: %v\n", EnvLevels, err)
+	}
+}
+
+// Overrides the minimum levels of categories (see NewCategory), replacing any earlier configuration.
+//
+// spec is a comma-separated list of category=level pairs, where level is as understood by
+// slog.Level.UnmarshalText (e.g. "debug", "warn", "info+2"), and the category "*" applies
+// to all categories which aren't listed. For example:
+//
+//	flagx=debug,http=warn,*=info
+//
+// An empty spec removes all overrides, so categories use the level they were created with.
+// If spec is invalid, an error is returned, and the configuration is unchanged.
+//
+// At startup, spec is read from the GOSH_LOG environment variable.
+func Configure(spec string) error {
+	levels := map[string]slog.Level{}
+	for pair := range strings.SplitSeq(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		category, levelStr, ok := strings.Cut(pair, "=")
+		category = strings.TrimSpace(category)
+		if !ok || category == "" {
+			return fmt.Errorf("bad category level %q: want category=level", pair)
+		}
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(strings.TrimSpace(levelStr))); err != nil {
+			return fmt.Errorf("bad level for category %q: %w", category, err)
+		}
+		levels[category] = level
+	}
+	levelsMu.Lock()
+	defer levelsMu.Unlock()
+	levelOverrides.Store(&levels)
+	return nil
+}
+
+// Overrides the minimum level of category (or "*" for all categories without their own override),
+// leaving the rest of the configuration (see Configure) alone.
+func SetLevel(category string, level slog.Level) {
+	updateOverrides(func(levels map[string]slog.Level) { levels[category] = level })
+}
+
+// Removes any override of the minimum level of category, so it uses the level it was created with
+// (or the one for "*").
+func ResetLevel(category string) {
+	updateOverrides(func(levels map[string]slog.Level) { delete(levels, category) })
+}
+
+func updateOverrides(fn func(levels map[string]slog.Level)) {
+	levelsMu.Lock()
+	defer levelsMu.Unlock()
+	levels := map[string]slog.Level{}
+	if old := levelOverrides.Load(); old != nil {
+		maps.Copy(levels, *old)
+	}
+	fn(levels)
+	levelOverrides.Store(&levels)
+}
+
+// Returns the current minimum level of each category created by NewCategory, keyed by category name.
+func Levels() map[string]slog.Level {
+	levels := map[string]slog.Level{}
+	categories.Range(func(key, value any) bool {
+		levels[key.(string)] = categoryLevel(key.(string), value.(slog.Level))
+		return true
+	})
+	return levels
+}
+
+// Returns the minimum level for category, which is minLevel unless overridden by Configure.
+func categoryLevel(category string, minLevel slog.Level) slog.Level {
+	levels := levelOverrides.Load()
+	if levels == nil {
+		return minLevel
+	}
+	if level, ok := (*levels)[category]; ok {
+		return level
+	}
+	if level, ok := (*levels)["*"]; ok {
+		return level
+	}
+	return minLevel
+}
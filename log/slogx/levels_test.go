@@ -0,0 +1,57 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slogx
+
+import (
+	"github.com/rburchell/gosh/log/slogx/slogxtest"
+	"log/slog"
+	"testing"
+)
+
+func TestConfigure(t *testing.T) {
+	defer Configure("")
+
+	base := slogxtest.NewHandler()
+	flagx := NewCategory("flagx", base, slog.LevelInfo)
+	http := NewCategory("http", base, slog.LevelDebug)
+	db := NewCategory("db", base, slog.LevelError)
+
+	if err := Configure("flagx=debug, http=warn,*=info"); err != nil {
+		t.Fatal(err)
+	}
+	flagx.Debug("shown")
+	http.Info("dropped")
+	http.Warn("shown")
+	db.Info("shown")
+	db.Debug("dropped")
+	if n := len(base.Entries()); n != 3 {
+		t.Errorf("expected 3 records, got %d", n)
+	}
+
+	if err := Configure(""); err != nil {
+		t.Fatal(err)
+	}
+	base.Reset()
+	flagx.Debug("dropped")
+	db.Warn("dropped")
+	if n := len(base.Entries()); n != 0 {
+		t.Errorf("expected 0 records after reset, got %d", n)
+	}
+}
+
+func TestConfigure_Errors(t *testing.T) {
+	defer Configure("")
+	if err := Configure("http=warn"); err != nil {
+		t.Fatal(err)
+	}
+	for _, spec := range []string{"http", "=debug", "http=loud"} {
+		if err := Configure(spec); err == nil {
+			t.Errorf("Configure(%q) succeeded", spec)
+		}
+	}
+	if got := categoryLevel("http", slog.LevelDebug); got != slog.LevelWarn {
+		t.Errorf("invalid spec changed configuration: level = %v", got)
+	}
+}
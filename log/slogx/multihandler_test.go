@@ -0,0 +1,57 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slogx
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/rburchell/gosh/log/slogx/slogxtest"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+type failingHandler struct{ *slogxtest.Handler }
+
+func (h *failingHandler) Handle(ctx context.Context, r slog.Record) error {
+	return errors.New("disk full")
+}
+
+func TestMultiHandler(t *testing.T) {
+	var text, json bytes.Buffer
+	warnOnly := slog.NewTextHandler(&text, &slog.HandlerOptions{Level: slog.LevelWarn})
+	logger := NewCategory("db", NewMultiHandler(warnOnly, NewJSONHandler(&json)), slog.LevelDebug)
+
+	logger.WithGroup("q").Info("info", "id", 1)
+	logger.Warn("warn")
+
+	if got := text.String(); strings.Contains(got, "msg=info") || !strings.Contains(got, "msg=warn category=db") {
+		t.Errorf("text got:\n%s", got)
+	}
+	if got := json.String(); strings.Count(got, "\n") != 2 || !strings.Contains(got, `"q":{"id":1}`) {
+		t.Errorf("json got:\n%s", got)
+	}
+}
+
+func TestMultiHandler_Errors(t *testing.T) {
+	capture := slogxtest.NewHandler()
+	h := NewMultiHandler(&failingHandler{slogxtest.NewHandler()}, capture)
+	if !h.Enabled(context.Background(), slog.LevelDebug) {
+		t.Errorf("expected enabled")
+	}
+	err := h.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, "msg", 0))
+	if err == nil || err.Error() != "disk full" {
+		t.Errorf("err = %v", err)
+	}
+	if len(capture.Entries()) != 1 {
+		t.Errorf("other handlers should still get the record")
+	}
+
+	if NewMultiHandler().Enabled(context.Background(), slog.LevelError) {
+		t.Errorf("expected no handlers to mean disabled")
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slogx
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+)
+
+// The key patterns used by NewRedactingHandler, if none are given.
+var DefaultRedactKeys = []string{"password", "passwd", "secret", "token", "authorization", "cookie", "api_key", "apikey"}
+
+// The value that redacted attrs are given.
+const redacted = "[redacted]"
+
+// Returns a slog.Handler which replaces the values of sensitive attrs with "[redacted]", before passing
+// records to base, so that accidentally logging secrets is caught in one place.
+//
+// An attr is sensitive if its key contains any of keys, ignoring case (so "token" matches "RefreshToken").
+// Attrs inside groups are checked too, and a group with a sensitive key is redacted entirely.
+// If no keys are given, DefaultRedactKeys is used.
+func NewRedactingHandler(base slog.Handler, keys ...string) slog.Handler {
+	if len(keys) == 0 {
+		keys = DefaultRedactKeys
+	}
+	lower := make([]string, len(keys))
+	for i, k := range keys {
+		lower[i] = strings.ToLower(k)
+	}
+	return &redactingHandler{base: base, keys: lower}
+}
+
+type redactingHandler struct {
+	base slog.Handler
+	keys []string
+}
+
+func (h *redactingHandler) sensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, k := range h.keys {
+		if strings.Contains(key, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns attr, with its value redacted if it is sensitive.
+func (h *redactingHandler) redact(attr slog.Attr) slog.Attr {
+	if h.sensitive(attr.Key) {
+		return slog.String(attr.Key, redacted)
+	}
+	attr.Value = attr.Value.Resolve()
+	if attr.Value.Kind() != slog.KindGroup {
+		return attr
+	}
+	group := attr.Value.Group()
+	attrs := make([]slog.Attr, len(group))
+	for i, a := range group {
+		attrs[i] = h.redact(a)
+	}
+	return slog.Attr{Key: attr.Key, Value: slog.GroupValue(attrs...)}
+}
+
+func (h *redactingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.base.Enabled(ctx, level)
+}
+
+func (h *redactingHandler) Handle(ctx context.Context, r slog.Record) error {
+	r2 := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r.Attrs(func(attr slog.Attr) bool {
+		r2.AddAttrs(h.redact(attr))
+		return true
+	})
+	return h.base.Handle(ctx, r2)
+}
+
+func (h *redactingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redactedAttrs := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		redactedAttrs[i] = h.redact(a)
+	}
+	return &redactingHandler{base: h.base.WithAttrs(redactedAttrs), keys: h.keys}
+}
+
+func (h *redactingHandler) WithGroup(name string) slog.Handler {
+	return &redactingHandler{base: h.base.WithGroup(name), keys: h.keys}
+}
@@ -0,0 +1,49 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slogx
+
+import (
+	"github.com/rburchell/gosh/log/slogx/slogxtest"
+	"log/slog"
+	"testing"
+)
+
+func TestRedactingHandler(t *testing.T) {
+	capture := slogxtest.NewHandler()
+	logger := slog.New(NewRedactingHandler(capture)).With("db_password", "hunter2", "user", "alice")
+	logger.Info("Login",
+		"RefreshToken", "abc",
+		slog.Group("req", "path", "/login", slog.Group("headers", "Authorization", "Bearer xyz", "Accept", "*/*")),
+		slog.Group("secrets", "a", 1),
+	)
+
+	want := map[string]string{
+		"db_password":               "[redacted]",
+		"user":                      "alice",
+		"RefreshToken":              "[redacted]",
+		"req.path":                  "/login",
+		"req.headers.Authorization": "[redacted]",
+		"req.headers.Accept":        "*/*",
+		"secrets":                   "[redacted]",
+	}
+	got := capture.AttrsFor("Login")
+	if len(got) != len(want) {
+		t.Errorf("got %v", got)
+	}
+	for k, v := range want {
+		if got[k].String() != v {
+			t.Errorf("%s = %q, want %q", k, got[k], v)
+		}
+	}
+}
+
+func TestRedactingHandler_CustomKeys(t *testing.T) {
+	capture := slogxtest.NewHandler()
+	slog.New(NewRedactingHandler(capture, "SSN")).Info("msg", "ssn", "123", "password", "kept")
+	got := capture.AttrsFor("msg")
+	if got["ssn"].String() != "[redacted]" || got["password"].String() != "kept" {
+		t.Errorf("got %v", got)
+	}
+}
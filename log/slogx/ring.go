@@ -0,0 +1,151 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slogx
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync"
+)
+
+// Configures NewRingHandler.
+type RingOptions struct {
+	// How many records to keep. If zero, 1000 is used.
+	Size int
+
+	// The minimum level of records passed on to the base handler. All records are kept, regardless.
+	Level slog.Leveler
+}
+
+// A RingHandler keeps the most recent records of all levels in memory, and passes those at or above
+// a level on to another handler. When something goes wrong, Dump writes out what led up to it,
+// including the debug records which are normally filtered out, e.g:
+//
+//	ring := slogx.NewRingHandler(slogx.TextHandler, slogx.RingOptions{Level: slog.LevelInfo})
+//	log := slogx.NewCategory("db", ring, slog.LevelDebug)
+//	...
+//	if err != nil {
+//		log.Error("query failed", slogx.Err(err))
+//		ring.Dump(os.Stderr)
+//	}
+//
+// Note that the category's own level must be low enough for the records to reach the RingHandler.
+type RingHandler struct {
+	base  slog.Handler
+	level slog.Leveler
+	ring  *ring
+	flatAttrs
+}
+
+// The records shared by a RingHandler, and the handlers derived from it by WithAttrs and WithGroup.
+type ring struct {
+	mu      sync.Mutex
+	records []slog.Record
+	next    int // where the next record goes
+	full    bool
+}
+
+// Returns a RingHandler which passes records on to base. If base is nil, records are only kept.
+func NewRingHandler(base slog.Handler, opts RingOptions) *RingHandler {
+	if opts.Size <= 0 {
+		opts.Size = 1000
+	}
+	if opts.Level == nil {
+		opts.Level = slog.LevelInfo
+	}
+	return &RingHandler{
+		base:  base,
+		level: opts.Level,
+		ring:  &ring{records: make([]slog.Record, opts.Size)},
+	}
+}
+
+func (h *RingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return true
+}
+
+func (h *RingHandler) Handle(ctx context.Context, r slog.Record) error {
+	// Keep a copy with the handler's attrs, flattened, so it can be written without it.
+	kept := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	kept.AddAttrs(h.attrs...)
+	r.Attrs(func(attr slog.Attr) bool {
+		return flattenAttr(h.prefix, attr, func(attr slog.Attr) bool {
+			kept.AddAttrs(attr)
+			return true
+		})
+	})
+	h.ring.add(kept)
+
+	if h.base == nil || r.Level < h.level.Level() || !h.base.Enabled(ctx, r.Level) {
+		return nil
+	}
+	return h.base.Handle(ctx, r)
+}
+
+func (rg *ring) add(r slog.Record) {
+	rg.mu.Lock()
+	defer rg.mu.Unlock()
+	rg.records[rg.next] = r
+	rg.next++
+	if rg.next == len(rg.records) {
+		rg.next = 0
+		rg.full = true
+	}
+}
+
+// Returns the kept records, oldest first.
+func (h *RingHandler) Records() []slog.Record {
+	h.ring.mu.Lock()
+	defer h.ring.mu.Unlock()
+	var records []slog.Record
+	if h.ring.full {
+		records = append(records, h.ring.records[h.ring.next:]...)
+	}
+	return append(records, h.ring.records[:h.ring.next]...)
+}
+
+// Writes the kept records to w as text, oldest first, with their time and level.
+// The records are still kept afterwards; see Reset.
+func (h *RingHandler) Dump(w io.Writer) error {
+	out := NewTextHandler(w, WithColor(false), WithCategoryWidth(0),
+		WithLayout(TextTime, TextLevel, TextCategory, TextMessage, TextAttrs))
+	for _, r := range h.Records() {
+		if err := out.Handle(context.Background(), r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Discards the kept records.
+func (h *RingHandler) Reset() {
+	h.ring.mu.Lock()
+	defer h.ring.mu.Unlock()
+	clear(h.ring.records)
+	h.ring.next = 0
+	h.ring.full = false
+}
+
+func (h *RingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := *h
+	if h.base != nil {
+		h2.base = h.base.WithAttrs(attrs)
+	}
+	h2.flatAttrs = h.withAttrs(attrs)
+	return &h2
+}
+
+func (h *RingHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	if h.base != nil {
+		h2.base = h.base.WithGroup(name)
+	}
+	h2.flatAttrs = h.withGroup(name)
+	return &h2
+}
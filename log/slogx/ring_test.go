@@ -0,0 +1,53 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slogx
+
+import (
+	"bytes"
+	"github.com/rburchell/gosh/log/slogx/slogxtest"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestRingHandler(t *testing.T) {
+	capture := slogxtest.NewHandler()
+	ring := NewRingHandler(capture, RingOptions{Size: 3, Level: slog.LevelWarn})
+	log := NewCategory("db", ring, slog.LevelDebug).WithGroup("q")
+
+	log.Debug("one", "n", 1)
+	log.Debug("two", "n", 2)
+	log.Info("three", "n", 3)
+	log.Warn("four", "n", 4)
+
+	if got := capture.Entries(); len(got) != 1 || got[0].Message != "four" {
+		t.Errorf("base got %+v, want only the warning", got)
+	}
+
+	var buf bytes.Buffer
+	if err := ring.Dump(&buf); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	want := []string{
+		"DEBUG db two q.n=2",
+		"INFO  db three q.n=3",
+		"WARN  db four q.n=4",
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("dump got:\n%s", buf.String())
+	}
+	for i := range want {
+		// Skip the time.
+		if _, got, _ := strings.Cut(lines[i], " "); got != want[i] {
+			t.Errorf("line %d = %q, want %q", i, got, want[i])
+		}
+	}
+
+	ring.Reset()
+	if n := len(ring.Records()); n != 0 {
+		t.Errorf("%d records after Reset", n)
+	}
+}
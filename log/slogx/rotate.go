@@ -0,0 +1,318 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slogx
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Configures OpenRotatingFile.
+type RotateOptions struct {
+	// The size in bytes at which the file is rotated. If zero, it isn't rotated by size.
+	MaxSize int64
+
+	// How long the file is written to before being rotated, measured from when it was opened.
+	// If zero, it isn't rotated by age.
+	MaxAge time.Duration
+
+	// How many rotated files to keep. If zero, all are kept (subject to MaxBackupAge).
+	MaxBackups int
+
+	// How long to keep rotated files for. If zero, they are kept forever (subject to MaxBackups).
+	MaxBackupAge time.Duration
+
+	// If true, rotated files are compressed with gzip, in the background.
+	Compress bool
+
+	// The permissions used for new files. If zero, 0640 is used.
+	Perm os.FileMode
+}
+
+// The format of the timestamp in rotated file names. It sorts in time order.
+const rotateTimeFormat = "20060102-150405.000"
+
+// A RotatingFile is an io.Writer that writes to a log file, rotating it by size and age,
+// for use with e.g. NewJSONHandler by long-running daemons.
+//
+// When the file is rotated, it is renamed to path.TIMESTAMP (plus .gz, if compressed),
+// and a new file is started at path. The rename is synced to disk before the new file is used.
+//
+// It is safe for concurrent use.
+type RotatingFile struct {
+	path string
+	opts RotateOptions
+	now  func() time.Time
+
+	mu     sync.Mutex
+	f      *os.File // nil if closed, or if reopening failed during rotation
+	closed bool
+	size   int64
+	opened time.Time
+	wg     sync.WaitGroup // compression in progress
+}
+
+// Opens (or creates) the log file at path, appending to it. See RotatingFile.
+func OpenRotatingFile(path string, opts RotateOptions) (*RotatingFile, error) {
+	if opts.Perm == 0 {
+		opts.Perm = 0640
+	}
+	rf := &RotatingFile{path: path, opts: opts, now: time.Now}
+	if err := rf.open(); err != nil {
+		return nil, err
+	}
+	return rf, nil
+}
+
+func (rf *RotatingFile) open() error {
+	f, err := os.OpenFile(rf.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, rf.opts.Perm)
+	if err != nil {
+		return err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	rf.f = f
+	rf.size = fi.Size()
+	rf.opened = rf.now()
+	return nil
+}
+
+// Writes p to the file, first rotating it if p would take it over MaxSize, or it is older than MaxAge.
+func (rf *RotatingFile) Write(p []byte) (int, error) {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+	if rf.closed {
+		return 0, os.ErrClosed
+	}
+	if rf.f == nil {
+		if err := rf.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	tooBig := rf.opts.MaxSize > 0 && rf.size > 0 && rf.size+int64(len(p)) > rf.opts.MaxSize
+	tooOld := rf.opts.MaxAge > 0 && rf.now().Sub(rf.opened) >= rf.opts.MaxAge
+	if tooBig || tooOld {
+		if err := rf.rotate(); err != nil {
+			return 0, fmt.Errorf("rotate: %w", err)
+		}
+	}
+
+	n, err := rf.f.Write(p)
+	rf.size += int64(n)
+	return n, err
+}
+
+// Rotates the file now, regardless of its size or age.
+func (rf *RotatingFile) Rotate() error {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+	if rf.closed {
+		return os.ErrClosed
+	}
+	if rf.f == nil {
+		if err := rf.open(); err != nil {
+			return err
+		}
+	}
+	return rf.rotate()
+}
+
+func (rf *RotatingFile) rotate() error {
+	if err := rf.f.Sync(); err != nil {
+		return fmt.Errorf("sync: %w", err)
+	}
+	if err := rf.f.Close(); err != nil {
+		return fmt.Errorf("close: %w", err)
+	}
+	rf.f = nil
+
+	// If anything fails from here, go back to appending to path, so that logging carries on.
+	// If even that fails, the next write tries again.
+	backup := rf.backupName(rf.now())
+	if err := os.Rename(rf.path, backup); err != nil {
+		return errors.Join(fmt.Errorf("rename: %w", err), rf.open())
+	}
+	if err := syncDir(filepath.Dir(rf.path)); err != nil {
+		return errors.Join(err, rf.open())
+	}
+	if err := rf.open(); err != nil {
+		return err
+	}
+
+	if rf.opts.Compress {
+		rf.wg.Add(1)
+		go func() {
+			defer rf.wg.Done()
+			if err := compressFile(backup); err != nil {
+				fmt.Fprintf(os.Stderr, "slogx: compressing %s: %v\n", backup, err)
+			}
+			rf.prune()
+		}()
+	} else {
+		rf.prune()
+	}
+	return nil
+}
+
+// Returns an unused name for a backup taken at t.
+func (rf *RotatingFile) backupName(t time.Time) string {
+	name := rf.path + "." + t.UTC().Format(rotateTimeFormat)
+	for i := 1; ; i++ {
+		candidate := name
+		if i > 1 {
+			candidate = fmt.Sprintf("%s-%d", name, i)
+		}
+		_, err1 := os.Lstat(candidate)
+		_, err2 := os.Lstat(candidate + ".gz")
+		if errors.Is(err1, os.ErrNotExist) && errors.Is(err2, os.ErrNotExist) {
+			return candidate
+		}
+	}
+}
+
+// Returns the rotated files, oldest first.
+func (rf *RotatingFile) backups() ([]string, error) {
+	dir := filepath.Dir(rf.path)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	prefix := filepath.Base(rf.path) + "."
+	var names []string
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasPrefix(name, prefix) || e.IsDir() {
+			continue
+		}
+		stamp := strings.TrimSuffix(strings.TrimPrefix(name, prefix), ".gz")
+		if len(stamp) < len(rotateTimeFormat) {
+			continue
+		}
+		if _, err := time.Parse(rotateTimeFormat, stamp[:len(rotateTimeFormat)]); err != nil {
+			continue
+		}
+		names = append(names, filepath.Join(dir, name))
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Removes rotated files beyond MaxBackups, or older than MaxBackupAge.
+func (rf *RotatingFile) prune() {
+	if rf.opts.MaxBackups == 0 && rf.opts.MaxBackupAge == 0 {
+		return
+	}
+	names, err := rf.backups()
+	if err != nil {
+		return
+	}
+	prefix := filepath.Base(rf.path) + "."
+	for i, name := range names {
+		remove := rf.opts.MaxBackups > 0 && i < len(names)-rf.opts.MaxBackups
+		if !remove && rf.opts.MaxBackupAge > 0 {
+			stamp := strings.TrimPrefix(filepath.Base(name), prefix)[:len(rotateTimeFormat)]
+			t, _ := time.Parse(rotateTimeFormat, stamp)
+			remove = rf.now().Sub(t) > rf.opts.MaxBackupAge
+		}
+		if remove {
+			os.Remove(name)
+		}
+	}
+}
+
+// Closes the file, after waiting for any compression to finish.
+func (rf *RotatingFile) Close() error {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+	rf.wg.Wait()
+	if rf.closed {
+		return os.ErrClosed
+	}
+	rf.closed = true
+	if rf.f == nil {
+		return nil
+	}
+	err := rf.f.Close()
+	rf.f = nil
+	return err
+}
+
+// Compresses name to name.gz, only removing name once name.gz is safely on disk.
+func compressFile(name string) error {
+	in, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	fi, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	// The temporary name mustn't look like a backup, or it might be pruned.
+	tmp, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	removeTemp := true
+	defer func() {
+		if removeTemp {
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	zw := gzip.NewWriter(tmp)
+	if _, err := io.Copy(zw, in); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(fi.Mode().Perm()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), name+".gz"); err != nil {
+		return err
+	}
+	removeTemp = false
+	if err := syncDir(filepath.Dir(name)); err != nil {
+		return err
+	}
+	return os.Remove(name)
+}
+
+func syncDir(dir string) error {
+	dh, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("dir open: %w", err)
+	}
+	if err := dh.Sync(); err != nil {
+		dh.Close() // best effort..
+		return fmt.Errorf("dir sync: %w", err)
+	}
+	return dh.Close()
+}
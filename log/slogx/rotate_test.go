@@ -0,0 +1,138 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slogx
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Opens a RotatingFile in a temp dir, with a fake clock that advances by a second per call.
+func openTestRotatingFile(t *testing.T, opts RotateOptions) (*RotatingFile, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "app.log")
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	rf, err := OpenRotatingFile(path, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rf.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+	rf.opened = rf.now()
+	t.Cleanup(func() { rf.Close() })
+	return rf, path
+}
+
+func TestRotatingFile_Size(t *testing.T) {
+	rf, path := openTestRotatingFile(t, RotateOptions{MaxSize: 10})
+	for _, line := range []string{"aaaa\n", "bbbb\n", "cccc\n", "a very long line\n"} {
+		if _, err := rf.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	backups, err := rf.backups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var contents []string
+	for _, name := range append(backups, path) {
+		b, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		contents = append(contents, string(b))
+	}
+	want := []string{"aaaa\nbbbb\n", "cccc\n", "a very long line\n"}
+	if strings.Join(contents, "|") != strings.Join(want, "|") {
+		t.Errorf("got %q, want %q", contents, want)
+	}
+}
+
+func TestRotatingFile_AgeAndRetention(t *testing.T) {
+	rf, _ := openTestRotatingFile(t, RotateOptions{MaxAge: time.Second, MaxBackups: 2})
+	for range 5 {
+		if _, err := rf.Write([]byte("line\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	backups, err := rf.backups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 2 {
+		t.Errorf("expected 2 backups, got %v", backups)
+	}
+}
+
+func TestRotatingFile_Compress(t *testing.T) {
+	rf, path := openTestRotatingFile(t, RotateOptions{Compress: true, Perm: 0600})
+	rf.Write([]byte("hello\n"))
+	if err := rf.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	rf.Write([]byte("world\n"))
+	if err := rf.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	backups, _ := rf.backups()
+	if len(backups) != 1 || !strings.HasSuffix(backups[0], ".gz") {
+		t.Fatalf("backups = %v", backups)
+	}
+	f, err := os.Open(backups[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if fi, _ := f.Stat(); fi.Mode().Perm() != 0600 {
+		t.Errorf("compressed file mode = %v", fi.Mode())
+	}
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := io.ReadAll(zr); string(b) != "hello\n" {
+		t.Errorf("compressed content = %q", b)
+	}
+	if b, _ := os.ReadFile(path); string(b) != "world\n" {
+		t.Errorf("current content = %q", b)
+	}
+
+	if _, err := rf.Write([]byte("x")); err == nil {
+		t.Errorf("write after close succeeded")
+	}
+}
+
+func TestRotatingFile_RotateFails(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("root ignores directory permissions")
+	}
+	rf, path := openTestRotatingFile(t, RotateOptions{})
+	dir := filepath.Dir(path)
+	rf.Write([]byte("before\n"))
+
+	// The rename fails in a read-only directory, but logging carries on.
+	if err := os.Chmod(dir, 0500); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chmod(dir, 0700) })
+	if err := rf.Rotate(); err == nil {
+		t.Fatal("rotate succeeded in a read-only directory")
+	}
+	if _, err := rf.Write([]byte("after\n")); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := os.ReadFile(path); string(b) != "before\nafter\n" {
+		t.Errorf("content = %q", b)
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slogx
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Configures NewSamplingHandler.
+type SampleOptions struct {
+	// How many identical records are logged in each Interval, before sampling starts.
+	// If zero, 10 is used.
+	First int
+
+	// Once sampling, every Thereafter'th identical record is logged. If zero, none are.
+	Thereafter int
+
+	// How often the counts are reset. If zero, one second is used.
+	Interval time.Duration
+}
+
+// Returns a slog.Handler which samples identical records (those with the same level and message)
+// before passing them to base, to keep hot loops from flooding output.
+//
+// In each Interval, the First records are logged, then every Thereafter'th. The next record
+// logged after some were dropped carries a "suppressed" attr, with how many similar ones were dropped.
+func NewSamplingHandler(base slog.Handler, opts SampleOptions) slog.Handler {
+	if opts.First == 0 {
+		opts.First = 10
+	}
+	if opts.Interval == 0 {
+		opts.Interval = time.Second
+	}
+	return &samplingHandler{base: base, s: &sampler{opts: opts, now: time.Now, counts: map[sampleKey]*sampleCount{}}}
+}
+
+type samplingHandler struct {
+	base slog.Handler
+	s    *sampler
+}
+
+// Counts records, shared by a samplingHandler, and the handlers derived from it.
+type sampler struct {
+	opts SampleOptions
+	now  func() time.Time
+
+	mu          sync.Mutex
+	windowStart time.Time
+	counts      map[sampleKey]*sampleCount
+}
+
+type sampleKey struct {
+	level slog.Level
+	msg   string
+}
+
+type sampleCount struct {
+	seen       int // in this window
+	suppressed int // since the last record was logged
+}
+
+// Returns whether a record with key should be logged, and if so, how many similar were suppressed before it.
+func (s *sampler) sample(key sampleKey) (bool, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if now.Sub(s.windowStart) >= s.opts.Interval {
+		s.windowStart = now
+		// Only keep what's needed for summaries, so that the map doesn't grow forever.
+		for k, c := range s.counts {
+			if c.suppressed == 0 {
+				delete(s.counts, k)
+			} else {
+				c.seen = 0
+			}
+		}
+	}
+
+	c := s.counts[key]
+	if c == nil {
+		c = &sampleCount{}
+		s.counts[key] = c
+	}
+	c.seen++
+	n := c.seen - s.opts.First
+	if n > 0 && (s.opts.Thereafter == 0 || n%s.opts.Thereafter != 0) {
+		c.suppressed++
+		return false, 0
+	}
+	suppressed := c.suppressed
+	c.suppressed = 0
+	return true, suppressed
+}
+
+func (h *samplingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.base.Enabled(ctx, level)
+}
+
+func (h *samplingHandler) Handle(ctx context.Context, r slog.Record) error {
+	ok, suppressed := h.s.sample(sampleKey{r.Level, r.Message})
+	if !ok {
+		return nil
+	}
+	if suppressed > 0 {
+		r = r.Clone()
+		r.AddAttrs(slog.Int("suppressed", suppressed))
+	}
+	return h.base.Handle(ctx, r)
+}
+
+func (h *samplingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &samplingHandler{base: h.base.WithAttrs(attrs), s: h.s}
+}
+
+func (h *samplingHandler) WithGroup(name string) slog.Handler {
+	return &samplingHandler{base: h.base.WithGroup(name), s: h.s}
+}
@@ -0,0 +1,50 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slogx
+
+import (
+	"github.com/rburchell/gosh/log/slogx/slogxtest"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestSamplingHandler(t *testing.T) {
+	base := slogxtest.NewHandler()
+	h := NewSamplingHandler(base, SampleOptions{First: 2, Thereafter: 3, Interval: time.Minute})
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	h.(*samplingHandler).s.now = func() time.Time { return now }
+	logger := slog.New(h)
+
+	for range 9 {
+		logger.Info("hot")
+	}
+	logger.Info("cold")
+	logger.Warn("hot") // a different level isn't identical
+
+	// Counts reset once the interval passes.
+	now = now.Add(time.Minute)
+	logger.Info("hot")
+
+	type rec struct {
+		msg        string
+		suppressed int64
+	}
+	var got []rec
+	for _, e := range base.Entries() {
+		suppressed, _ := e.Attrs["suppressed"].Any().(int64)
+		got = append(got, rec{e.Message, suppressed})
+	}
+	// hot 1, 2 logged; 3, 4 dropped; 5 logged; 6, 7 dropped; 8 logged; 9 dropped.
+	want := []rec{{"hot", 0}, {"hot", 0}, {"hot", 2}, {"hot", 2}, {"cold", 0}, {"hot", 0}, {"hot", 1}}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("record %d = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
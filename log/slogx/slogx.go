@@ -6,6 +6,7 @@
 //
 // [NewTextHandler] returns a handler which pretty-prints categorised log output.
 // For convenience, there is also a global [TextHandler] instance.
+// [NewJSONHandler] returns a handler which writes the same records as JSON, for machines to read.
 //
 // [NewCategory] returns a category handler, which puts a `category` attribute
 // in each of the [slog.Record] it creates, as well as allowing you to set the minimum
@@ -23,6 +24,10 @@
 //		db.Warn("warn shown 1")         // shown
 //		net.Warn("warn shown 2")        // shown
 //
+// Operators can change category levels without code changes, using the GOSH_LOG
+// environment variable (e.g. GOSH_LOG=db=debug,*=warn). See [Configure].
+// They can also be changed at runtime with [SetLevel] (or over HTTP, with server.Builder.EnableLevels).
+//
 // It is an explicit non-goal to provide the kitchen sink in this package.
 // Just the simple stuff you want to use all the time.
 package slogx
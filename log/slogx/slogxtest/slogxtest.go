@@ -0,0 +1,160 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package slogxtest provides a slog.Handler which captures records, for use in tests.
+//
+// For example:
+//
+//	h := slogxtest.NewHandler()
+//	logger := slogx.NewCategory("db", h, slog.LevelDebug)
+//	doSomething(logger)
+//	if !h.ContainsMessage("Connected") {
+//	    t.Errorf("expected a Connected message, got %v", h.Entries())
+//	}
+package slogxtest
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// A record captured by a Handler.
+type Entry struct {
+	Time    time.Time
+	Level   slog.Level
+	Message string
+	// All attrs, including those from WithAttrs, keyed by name.
+	// Keys in groups are dotted (e.g. "req.path"), and values are resolved.
+	Attrs map[string]slog.Value
+}
+
+// A Handler captures every record it is given, for later inspection.
+// Handlers derived from it by WithAttrs and WithGroup capture into the same place.
+//
+// It is safe for concurrent use.
+type Handler struct {
+	rec *recorder
+	// Attrs from WithAttrs, already flattened and prefixed with their groups.
+	attrs []slog.Attr
+	// The groups from WithGroup, as a key prefix (e.g. "req.headers.").
+	prefix string
+}
+
+type recorder struct {
+	mu      sync.Mutex
+	entries []Entry
+}
+
+// Returns a new, empty, Handler.
+func NewHandler() *Handler {
+	return &Handler{rec: &recorder{}}
+}
+
+// Calls fn for attr, resolving its value, and flattening groups into dotted keys under prefix.
+func flattenAttr(prefix string, attr slog.Attr, fn func(attr slog.Attr)) {
+	attr.Value = attr.Value.Resolve()
+	if attr.Value.Kind() != slog.KindGroup {
+		if !attr.Equal(slog.Attr{}) {
+			attr.Key = prefix + attr.Key
+			fn(attr)
+		}
+		return
+	}
+	if attr.Key != "" {
+		prefix += attr.Key + "."
+	}
+	for _, a := range attr.Value.Group() {
+		flattenAttr(prefix, a, fn)
+	}
+}
+
+func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return true
+}
+
+func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
+	e := Entry{Time: r.Time, Level: r.Level, Message: r.Message, Attrs: map[string]slog.Value{}}
+	for _, attr := range h.attrs {
+		e.Attrs[attr.Key] = attr.Value
+	}
+	r.Attrs(func(attr slog.Attr) bool {
+		flattenAttr(h.prefix, attr, func(attr slog.Attr) {
+			e.Attrs[attr.Key] = attr.Value
+		})
+		return true
+	})
+
+	h.rec.mu.Lock()
+	defer h.rec.mu.Unlock()
+	h.rec.entries = append(h.rec.entries, e)
+	return nil
+}
+
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := *h
+	h2.attrs = append([]slog.Attr(nil), h.attrs...)
+	for _, attr := range attrs {
+		flattenAttr(h.prefix, attr, func(attr slog.Attr) {
+			h2.attrs = append(h2.attrs, attr)
+		})
+	}
+	return &h2
+}
+
+func (h *Handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.prefix += name + "."
+	return &h2
+}
+
+// Returns the entries captured so far, in the order they were logged.
+func (h *Handler) Entries() []Entry {
+	h.rec.mu.Lock()
+	defer h.rec.mu.Unlock()
+	return append([]Entry(nil), h.rec.entries...)
+}
+
+// Forgets the entries captured so far.
+func (h *Handler) Reset() {
+	h.rec.mu.Lock()
+	defer h.rec.mu.Unlock()
+	h.rec.entries = nil
+}
+
+// Returns whether an entry with message msg was captured.
+func (h *Handler) ContainsMessage(msg string) bool {
+	_, ok := h.find(msg)
+	return ok
+}
+
+// Returns the attrs of the first entry with message msg, or nil if there isn't one.
+func (h *Handler) AttrsFor(msg string) map[string]slog.Value {
+	e, _ := h.find(msg)
+	return e.Attrs
+}
+
+// Returns how many entries were captured at exactly level.
+func (h *Handler) CountAtLevel(level slog.Level) int {
+	n := 0
+	for _, e := range h.Entries() {
+		if e.Level == level {
+			n++
+		}
+	}
+	return n
+}
+
+func (h *Handler) find(msg string) (Entry, bool) {
+	for _, e := range h.Entries() {
+		if e.Message == msg {
+			return e, true
+		}
+	}
+	return Entry{}, false
+}
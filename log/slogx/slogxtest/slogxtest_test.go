@@ -0,0 +1,40 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slogxtest
+
+import (
+	"log/slog"
+	"testing"
+)
+
+func TestHandler(t *testing.T) {
+	h := NewHandler()
+	logger := slog.New(h).With("category", "db").WithGroup("q")
+	logger.Info("Query", "sql", "select 1", slog.Group("args", "id", 42))
+	logger.Warn("Slow")
+	logger.Warn("Slow")
+
+	if !h.ContainsMessage("Query") || h.ContainsMessage("Missing") {
+		t.Errorf("ContainsMessage wrong")
+	}
+	attrs := h.AttrsFor("Query")
+	if attrs["category"].String() != "db" || attrs["q.sql"].String() != "select 1" || attrs["q.args.id"].Int64() != 42 {
+		t.Errorf("AttrsFor = %v", attrs)
+	}
+	if h.AttrsFor("Missing") != nil {
+		t.Errorf("AttrsFor missing message should be nil")
+	}
+	if n := h.CountAtLevel(slog.LevelWarn); n != 2 {
+		t.Errorf("CountAtLevel(Warn) = %d", n)
+	}
+	if n := len(h.Entries()); n != 3 {
+		t.Errorf("len(Entries) = %d", n)
+	}
+
+	h.Reset()
+	if n := len(h.Entries()); n != 0 {
+		t.Errorf("len(Entries) after Reset = %d", n)
+	}
+}
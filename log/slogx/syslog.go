@@ -0,0 +1,184 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slogx
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A syslog facility. See SyslogOptions.
+type SyslogFacility int
+
+const (
+	FacilityUser   SyslogFacility = 1
+	FacilityDaemon SyslogFacility = 3
+	FacilityLocal0 SyslogFacility = 16
+	FacilityLocal1 SyslogFacility = 17
+	FacilityLocal2 SyslogFacility = 18
+	FacilityLocal3 SyslogFacility = 19
+	FacilityLocal4 SyslogFacility = 20
+	FacilityLocal5 SyslogFacility = 21
+	FacilityLocal6 SyslogFacility = 22
+	FacilityLocal7 SyslogFacility = 23
+)
+
+// Configures NewSyslogHandler.
+type SyslogOptions struct {
+	// The facility messages are logged with. If zero, FacilityUser is used.
+	Facility SyslogFacility
+
+	// The APP-NAME of messages. If empty, the executable's name is used.
+	AppName string
+
+	// The HOSTNAME of messages. If empty, os.Hostname is used.
+	Hostname string
+
+	// If true, each message is prefixed with its length (RFC 6587 octet counting),
+	// which is needed when writing to a stream (e.g. TCP), rather than a datagram socket.
+	OctetCounting bool
+}
+
+// The SD-ID used for attrs, under the enterprise number reserved for documentation (RFC 5612).
+const syslogSDID = "gosh@32473"
+
+// Returns a slog.Handler which writes each record to w as an RFC 5424 syslog message,
+// for services that must integrate with host logging. Each message is written with a single Write,
+// so w is typically a connection to a syslog daemon, e.g:
+//
+//	conn, err := net.Dial("udp", "localhost:514")
+//	...
+//	h := slogx.NewSyslogHandler(conn, slogx.SyslogOptions{Facility: slogx.FacilityDaemon})
+//
+// The level is mapped to a severity (debug, info, warning, error, and critical for levels above error),
+// the category (see NewCategory) is used as the MSGID, and attrs become structured data.
+func NewSyslogHandler(w io.Writer, opts SyslogOptions) slog.Handler {
+	if opts.Facility == 0 {
+		opts.Facility = FacilityUser
+	}
+	if opts.AppName == "" {
+		opts.AppName = filepath.Base(os.Args[0])
+	}
+	if opts.Hostname == "" {
+		opts.Hostname, _ = os.Hostname()
+	}
+	return &syslogHandler{mu: &sync.Mutex{}, w: w, opts: opts, pid: strconv.Itoa(os.Getpid())}
+}
+
+type syslogHandler struct {
+	mu   *sync.Mutex
+	w    io.Writer
+	opts SyslogOptions
+	pid  string
+	flatAttrs
+}
+
+// Escapes the characters which are special in structured data parameter values (RFC 5424 section 6.3.3).
+var syslogParamEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+
+// Returns the syslog severity for level.
+func syslogSeverity(level slog.Level) int {
+	switch {
+	case level > slog.LevelError:
+		return 2 // critical
+	case level >= slog.LevelError:
+		return 3 // error
+	case level >= slog.LevelWarn:
+		return 4 // warning
+	case level >= slog.LevelInfo:
+		return 6 // informational
+	default:
+		return 7 // debug
+	}
+}
+
+// Returns s restricted to printable US-ASCII without the given characters, and at most max long,
+// or "-" if that leaves nothing.
+func syslogToken(s string, max int, exclude string) string {
+	var b strings.Builder
+	for _, c := range s {
+		if c > ' ' && c < 127 && !strings.ContainsRune(exclude, c) {
+			b.WriteRune(c)
+		}
+	}
+	s = b.String()
+	if len(s) > max {
+		s = s[:max]
+	}
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func (h *syslogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return true
+}
+
+func (h *syslogHandler) Handle(ctx context.Context, r slog.Record) error {
+	category := ""
+	var params []string
+	for _, attr := range h.recordAttrs(r) {
+		if attr.Key == "category" && category == "" {
+			if s, ok := attr.Value.Any().(string); ok && s != "" {
+				category = s
+				continue
+			}
+		}
+		val := syslogParamEscaper.Replace(attr.Value.String())
+		params = append(params, syslogToken(attr.Key, 32, `= ]"`)+`="`+val+`"`)
+	}
+
+	sd := "-"
+	if len(params) > 0 {
+		sd = "[" + syslogSDID + " " + strings.Join(params, " ") + "]"
+	}
+	ts := "-"
+	if !r.Time.IsZero() {
+		ts = r.Time.Format(time.RFC3339Nano)
+	}
+
+	msg := fmt.Sprintf("<%d>1 %s %s %s %s %s %s %s",
+		int(h.opts.Facility)*8+syslogSeverity(r.Level),
+		ts,
+		syslogToken(h.opts.Hostname, 255, ""),
+		syslogToken(h.opts.AppName, 48, ""),
+		h.pid,
+		syslogToken(category, 32, ""),
+		sd,
+		r.Message,
+	)
+	if h.opts.OctetCounting {
+		msg = strconv.Itoa(len(msg)) + " " + msg
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.w, msg)
+	return err
+}
+
+func (h *syslogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := *h
+	h2.flatAttrs = h.withAttrs(attrs)
+	return &h2
+}
+
+func (h *syslogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.flatAttrs = h.withGroup(name)
+	return &h2
+}
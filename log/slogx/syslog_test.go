@@ -0,0 +1,43 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slogx
+
+import (
+	"bytes"
+	"log/slog"
+	"regexp"
+	"testing"
+)
+
+func TestSyslogHandler(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewSyslogHandler(&buf, SyslogOptions{Facility: FacilityLocal0, AppName: "my app", Hostname: "host1", OctetCounting: true})
+	logger := NewCategory("db", h, slog.LevelDebug).WithGroup("q")
+	logger.Warn("Slow query", "sql", `select "x" [1]`, "ms", 1200)
+
+	want := regexp.MustCompile(`^\d+ <132>1 \d{4}-\d\d-\d\dT[^ ]+ host1 myapp \d+ db \[gosh@32473 q\.sql="select \\"x\\" \[1\\]" q\.ms="1200"\] Slow query$`)
+	if got := buf.String(); !want.MatchString(got) {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestSyslogSeverity(t *testing.T) {
+	tests := []struct {
+		level slog.Level
+		want  int
+	}{
+		{slog.LevelDebug, 7},
+		{slog.LevelInfo, 6},
+		{slog.LevelInfo + 1, 6},
+		{slog.LevelWarn, 4},
+		{slog.LevelError, 3},
+		{slog.LevelError + 4, 2},
+	}
+	for _, tt := range tests {
+		if got := syslogSeverity(tt.level); got != tt.want {
+			t.Errorf("syslogSeverity(%v) = %d, want %d", tt.level, got, tt.want)
+		}
+	}
+}
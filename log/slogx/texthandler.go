@@ -9,21 +9,242 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
 )
 
+// Configures NewTextHandler.
+type TextOption func(h *textHandler)
+
+// The colors used by the text handler, as terminal escape codes. See WithTheme.
+type Theme struct {
+	// Attr keys and values.
+	Key   string
+	Value string
+	// The time, if it is in the layout.
+	Time string
+	// The category (and level, if it is in the layout), by level.
+	Debug string
+	Info  string
+	Warn  string
+	Error string
+}
+
+// The colors used by the text handler, unless WithTheme is used.
+var DefaultTheme = Theme{
+	Key:   "\033[03;32m",
+	Value: "\033[01;32m",
+	Time:  "\033[38;5;244m",
+	Debug: "\033[01;38;5;240m",
+	Info:  "\033[01;38;5;245m",
+	Warn:  "\033[01;38;5;208m",
+	Error: "\033[01;38;5;124m",
+}
+
+// Part of a line written by the text handler. See WithLayout.
+type TextField int
+
+const (
+	// The time of the record, as hours, minutes, seconds and milliseconds.
+	TextTime TextField = iota
+	// The level of the record (e.g. INFO).
+	TextLevel
+	// The category of the record (see NewCategory), in a column; see WithCategoryWidth.
+	TextCategory
+	// The message of the record.
+	TextMessage
+	// The file, line and function which logged the record.
+	TextSource
+	// All other attrs, as key=value.
+	TextAttrs
+)
+
+// The layout of lines written by the text handler, unless WithLayout is used.
+var DefaultLayout = []TextField{TextCategory, TextMessage, TextAttrs}
+
+// Sets what is written for each record, and in which order. Fields not in layout aren't written,
+// so for example, file and function information is only written if TextSource is included.
+func WithLayout(layout ...TextField) TextOption {
+	return func(h *textHandler) {
+		h.layout = layout
+	}
+}
+
+// Sets the width of the category column. Longer categories are truncated.
+// If zero, categories are written in full, without padding. The default is 9.
+func WithCategoryWidth(width int) TextOption {
+	return func(h *textHandler) {
+		h.categoryWidth = width
+	}
+}
+
+// Sets the colors used, when color is enabled (see WithColor).
+func WithTheme(theme Theme) TextOption {
+	return func(h *textHandler) {
+		h.theme = theme
+	}
+}
+
+// Forces terminal escape codes (colors) on or off, rather than detecting whether to use them.
+func WithColor(color bool) TextOption {
+	return func(h *textHandler) {
+		h.color = color
+	}
+}
+
 // Returns a new slog.Handler which will pretty-print all records, and write them to w.
 //
-// Log output includes terminal escape codes unconditionally; the expectation is you are writing a command line tool.
-func NewTextHandler(w io.Writer) slog.Handler {
-	return textHandler{
-		Writer: w,
+// Durations are rounded to three significant figures, for readability, and ByteSize values
+// (see Bytes) are written with binary units.
+//
+// Output is colored with terminal escape codes if w is a terminal.
+// Setting the NO_COLOR environment variable disables color, otherwise setting CLICOLOR_FORCE enables it.
+// WithColor overrides all of these.
+func NewTextHandler(w io.Writer, opts ...TextOption) slog.Handler {
+	h := textHandler{
+		Writer:        w,
+		color:         detectColor(w),
+		layout:        DefaultLayout,
+		categoryWidth: 9,
+		theme:         DefaultTheme,
+	}
+	for _, opt := range opts {
+		opt(&h)
+	}
+	return h
+}
+
+// Returns whether output to w should be colored, by default.
+func detectColor(w io.Writer) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	if v := os.Getenv("CLICOLOR_FORCE"); v != "" && v != "0" {
+		return true
+	}
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
 	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
 }
 
 type textHandler struct {
 	// The stream that bytes will be written to.
 	Writer io.Writer
-	attrs  []slog.Attr
+	// Whether to use terminal escape codes.
+	color bool
+	// See WithLayout, WithCategoryWidth, and WithTheme.
+	layout        []TextField
+	categoryWidth int
+	theme         Theme
+	// Attrs from WithAttrs, already flattened, prefixed with their groups, and formatted,
+	// so that only the record's attrs need formatting for each record.
+	attrs []textAttr
+	// The index of each key in attrs.
+	index map[string]int
+	// The category from WithAttrs, if any.
+	category string
+	// The groups from WithGroup, as a key prefix (e.g. "req.headers.").
+	prefix string
+}
+
+// A formatted attr. Multi-line values (e.g. stacks) are unreadable inline,
+// so they are written as blocks on their own lines, after the record.
+type textAttr struct {
+	key    string
+	inline string // key=value
+	block  string
+}
+
+// Calls fn for attr, resolving its value, and flattening groups into dotted keys under prefix.
+func flattenAttr(prefix string, attr slog.Attr, fn func(attr slog.Attr) bool) bool {
+	attr.Value = attr.Value.Resolve()
+	if attr.Value.Kind() != slog.KindGroup {
+		if attr.Equal(slog.Attr{}) {
+			return true
+		}
+		attr.Key = prefix + attr.Key
+		return fn(attr)
+	}
+	if attr.Key != "" {
+		prefix += attr.Key + "."
+	}
+	for _, a := range attr.Value.Group() {
+		if !flattenAttr(prefix, a, fn) {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns attrs (from WithAttrs, already flattened) followed by the attrs of r, flattened under prefix.
+// Where keys are repeated, only the last value is kept (so the record's attrs win),
+// in the position where the key first appeared.
+func recordAttrs(attrs []slog.Attr, prefix string, r slog.Record) []slog.Attr {
+	all := make([]slog.Attr, 0, len(attrs)+r.NumAttrs())
+	all = append(all, attrs...)
+	r.Attrs(func(attr slog.Attr) bool {
+		return flattenAttr(prefix, attr, func(attr slog.Attr) bool {
+			all = append(all, attr)
+			return true
+		})
+	})
+	return dedupAttrs(all)
+}
+
+// flatAttrs holds the attrs and groups for handlers which flatten groups into dotted keys (see flattenAttr).
+// Handlers embed it, and use withAttrs and withGroup in their WithAttrs and WithGroup.
+type flatAttrs struct {
+	// Attrs from WithAttrs, already flattened and prefixed with their groups.
+	attrs []slog.Attr
+	// The groups from WithGroup, as a key prefix (e.g. "req.headers.").
+	prefix string
+}
+
+// Returns a copy of a with attrs flattened and added.
+func (a flatAttrs) withAttrs(attrs []slog.Attr) flatAttrs {
+	a.attrs = append([]slog.Attr(nil), a.attrs...)
+	for _, attr := range attrs {
+		flattenAttr(a.prefix, attr, func(attr slog.Attr) bool {
+			a.attrs = append(a.attrs, attr)
+			return true
+		})
+	}
+	return a
+}
+
+// Returns a copy of a with the group name opened. name must not be empty.
+func (a flatAttrs) withGroup(name string) flatAttrs {
+	a.prefix += name + "."
+	return a
+}
+
+// Returns the attrs followed by those of r, flattened. See recordAttrs.
+func (a flatAttrs) recordAttrs(r slog.Record) []slog.Attr {
+	return recordAttrs(a.attrs, a.prefix, r)
+}
+
+// Removes repeated keys from attrs, keeping the last value, in the position where the key first appeared.
+func dedupAttrs(attrs []slog.Attr) []slog.Attr {
+	index := make(map[string]int, len(attrs))
+	out := attrs[:0]
+	for _, attr := range attrs {
+		if i, ok := index[attr.Key]; ok {
+			out[i].Value = attr.Value
+			continue
+		}
+		index[attr.Key] = len(out)
+		out = append(out, attr)
+	}
+	return out
 }
 
 func leftJustified(str string, width int) string {
@@ -37,71 +258,251 @@ func leftJustified(str string, width int) string {
 }
 
 func (h textHandler) Handle(ctx context.Context, r slog.Record) error {
-	const (
-		keyColor   = "\033[03;32m"
-		valueColor = "\033[01;32m"
-		resetColor = "\033[0m"
-	)
-
-	catStr := "<unknown>"
-	forAllAttrs := func(callback func(attr slog.Attr) bool) {
-		for _, attr := range h.attrs {
-			if !callback(attr) {
-				return
-			}
-		}
-		r.Attrs(callback)
+	keyColor := h.escape(h.theme.Key)
+	valueColor := h.escape(h.theme.Value)
+	resetColor := h.escape("\033[0m")
+
+	catStr := h.category
+	if catStr == "" {
+		catStr = "<unknown>"
 	}
 
-	// Format attributes, and find category name
-	// FIXME: If my understanding is correct, we should/could do this on the handler attrs once, rather than once per record.
-	var kvstr string
-	forAllAttrs(func(attr slog.Attr) bool {
-		if attr.Key == "category" {
-			if s, ok := attr.Value.Any().(string); ok && s != "" {
+	// Format the record's attrs, and find category name.
+	// Where keys are repeated, the last value wins, in the position where the key first appeared.
+	attrs := h.attrs
+	copied := false
+	var extra []textAttr
+	r.Attrs(func(attr slog.Attr) bool {
+		return flattenAttr(h.prefix, attr, func(attr slog.Attr) bool {
+			if s, ok := attr.Value.Any().(string); ok && s != "" && attr.Key == "category" {
 				catStr = s
 				return true
 			}
-		}
-		kvstr += fmt.Sprintf("%s%s%s=%s%s%s ", keyColor, attr.Key, resetColor, valueColor, attr.Value, resetColor)
-		return true
+			ta := h.formatAttr(attr)
+			if i, ok := h.index[ta.key]; ok {
+				if !copied {
+					attrs = append([]textAttr(nil), h.attrs...)
+					copied = true
+				}
+				attrs[i] = ta
+				return true
+			}
+			for i := range extra {
+				if extra[i].key == ta.key {
+					extra[i] = ta
+					return true
+				}
+			}
+			extra = append(extra, ta)
+			return true
+		})
 	})
 
-	// Trim trailing space
-	if len(kvstr) > 0 {
-		kvstr = kvstr[:len(kvstr)-1]
+	var kvs, blocks strings.Builder
+	for _, list := range [][]textAttr{attrs, extra} {
+		for _, ta := range list {
+			if ta.block != "" {
+				blocks.WriteString(ta.block)
+				continue
+			}
+			if kvs.Len() > 0 {
+				kvs.WriteByte(' ')
+			}
+			kvs.WriteString(ta.inline)
+		}
 	}
+	kvstr := kvs.String()
 
 	// Determine message color by level
 	var color string
-	switch r.Level {
-	case slog.LevelDebug:
-		color = "\033[01;38;5;240m"
-	case slog.LevelInfo:
-		color = "\033[01;38;5;245m"
-	case slog.LevelWarn:
-		color = "\033[01;38;5;208m"
-	case slog.LevelError:
-		color = "\033[01;38;5;124m"
+	switch {
+	case r.Level >= slog.LevelError:
+		color = h.escape(h.theme.Error)
+	case r.Level >= slog.LevelWarn:
+		color = h.escape(h.theme.Warn)
+	case r.Level >= slog.LevelInfo:
+		color = h.escape(h.theme.Info)
 	default:
-		color = resetColor
+		color = h.escape(h.theme.Debug)
 	}
 
-	// Build and write the final line
-	line := fmt.Sprintf("%s%s%s%s %s", color, leftJustified(catStr, 10), resetColor, r.Message, kvstr)
-	fmt.Fprintln(h.Writer, line)
-	return nil
+	// Build and write the final line, in the order of the layout
+	var line strings.Builder
+	sep := ""
+	add := func(part string) {
+		line.WriteString(sep)
+		line.WriteString(part)
+		sep = " "
+	}
+	for _, field := range h.layout {
+		switch field {
+		case TextTime:
+			if !r.Time.IsZero() {
+				add(h.escape(h.theme.Time) + r.Time.Format("15:04:05.000") + resetColor)
+			}
+		case TextLevel:
+			add(color + leftJustified(LevelString(r.Level), 5) + resetColor)
+		case TextCategory:
+			if h.categoryWidth > 0 {
+				// The column's padding (and separator) is colored too.
+				add(color + leftJustified(catStr, h.categoryWidth) + " " + resetColor)
+				sep = ""
+			} else {
+				add(color + catStr + resetColor)
+			}
+		case TextMessage:
+			add(r.Message)
+		case TextSource:
+			if r.PC != 0 {
+				frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+				add(fmt.Sprintf("%sfile%s=%s%s:%d%s %sfunc%s=%s%s%s",
+					keyColor, resetColor, valueColor, trimSourcePath(frame.File, frame.Function), frame.Line, resetColor,
+					keyColor, resetColor, valueColor, frame.Function, resetColor))
+			}
+		case TextAttrs:
+			if kvstr != "" {
+				add(kvstr)
+			}
+		}
+	}
+	line.WriteString(blocks.String())
+	line.WriteByte('\n')
+	_, err := io.WriteString(h.Writer, line.String())
+	return err
+}
+
+// The modules the binary was built from, by module path, with their versions.
+// The main module's version is empty.
+var buildModules = sync.OnceValues(func() (map[string]string, string) {
+	mods := map[string]string{}
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return mods, ""
+	}
+	if bi.Main.Path != "" {
+		mods[bi.Main.Path] = ""
+	}
+	for _, dep := range bi.Deps {
+		if dep.Replace != nil {
+			dep = dep.Replace
+		}
+		mods[dep.Path] = dep.Version
+	}
+	return mods, bi.Path
+})
+
+// Returns file, from a frame of function, shortened to be relative to the root of its module
+// (e.g. "net/http/server/server.go"), to the module cache for dependencies
+// (e.g. "example.com/m@v1.2.3/client.go"), or to GOROOT for the standard library,
+// in the same way as -trimpath.
+//
+// The package's module comes from the build info, so the filesystem isn't needed.
+func trimSourcePath(file, function string) string {
+	dir, base := path.Split(file)
+	dir = strings.TrimSuffix(dir, "/")
+	if !path.IsAbs(dir) && !filepath.IsAbs(dir) {
+		// Already relative, e.g. built with -trimpath.
+		return file
+	}
+
+	// The package path is everything up to the first '.' after the last '/'.
+	pkg := function
+	if i := strings.IndexByte(pkg[strings.LastIndexByte(pkg, '/')+1:], '.'); i != -1 {
+		pkg = pkg[:strings.LastIndexByte(pkg, '/')+1+i]
+	}
+	pkg = strings.TrimSuffix(pkg, "_test")
+	mods, mainPkg := buildModules()
+	if pkg == "main" {
+		pkg = mainPkg
+	}
+
+	// Find the innermost module containing pkg.
+	for mod := pkg; mod != "." && mod != ""; mod = path.Dir(mod) {
+		version, ok := mods[mod]
+		if !ok {
+			continue
+		}
+		rel := strings.TrimPrefix(pkg, mod)
+		if !strings.HasSuffix(dir, rel) {
+			break
+		}
+		if version != "" {
+			return mod + "@" + version + rel + "/" + base
+		}
+		return strings.TrimPrefix(rel+"/", "/") + base
+	}
+
+	// The standard library's import paths have no dot in their first element.
+	if first, _, _ := strings.Cut(pkg, "/"); pkg != "" && !strings.Contains(first, ".") && strings.HasSuffix(dir, "/"+pkg) {
+		return pkg + "/" + base
+	}
+	return file
+}
+
+// Returns code, or nothing if color is disabled.
+func (h textHandler) escape(code string) string {
+	if !h.color {
+		return ""
+	}
+	return code
 }
 
 func (h textHandler) Enabled(ctx context.Context, level slog.Level) bool {
 	return true
 }
 
+// Formats attr, whose key is already prefixed with its groups.
+func (h textHandler) formatAttr(attr slog.Attr) textAttr {
+	keyColor := h.escape(h.theme.Key)
+	resetColor := h.escape("\033[0m")
+	val := attr.Value.String()
+	if attr.Value.Kind() == slog.KindDuration {
+		val = formatDuration(attr.Value.Duration())
+	}
+	if strings.Contains(val, "\n") {
+		var b strings.Builder
+		fmt.Fprintf(&b, "\n    %s%s%s:", keyColor, attr.Key, resetColor)
+		for _, l := range strings.Split(strings.TrimRight(val, "\n"), "\n") {
+			b.WriteString("\n        ")
+			b.WriteString(l)
+		}
+		return textAttr{key: attr.Key, block: b.String()}
+	}
+	return textAttr{key: attr.Key, inline: keyColor + attr.Key + resetColor + "=" + h.escape(h.theme.Value) + val + resetColor}
+}
+
 func (h textHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
-	return textHandler{Writer: h.Writer, attrs: attrs}
+	merged := append([]textAttr(nil), h.attrs...)
+	index := maps.Clone(h.index)
+	if index == nil {
+		index = map[string]int{}
+	}
+	for _, attr := range attrs {
+		flattenAttr(h.prefix, attr, func(attr slog.Attr) bool {
+			if s, ok := attr.Value.Any().(string); ok && s != "" && attr.Key == "category" {
+				h.category = s
+				return true
+			}
+			ta := h.formatAttr(attr)
+			if i, ok := index[ta.key]; ok {
+				merged[i] = ta
+				return true
+			}
+			index[ta.key] = len(merged)
+			merged = append(merged, ta)
+			return true
+		})
+	}
+	h.attrs = merged
+	h.index = index
+	return h
 }
 
+// Nests subsequent attrs under name, which is rendered as a dotted key prefix (e.g. "req.method=GET").
 func (h textHandler) WithGroup(name string) slog.Handler {
-	// FIXME: Handle group somehow
-	return textHandler{Writer: h.Writer, attrs: h.attrs}
+	if name == "" {
+		return h
+	}
+	h.prefix += name + "."
+	return h
 }
@@ -6,14 +6,17 @@ package slogx
 
 import (
 	"bytes"
+	"context"
+	"io"
 	"log/slog"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestTextHandler(t *testing.T) {
 	var buf bytes.Buffer
-	handler := NewTextHandler(&buf)
+	handler := NewTextHandler(&buf, WithColor(true))
 	logger := slog.New(handler)
 
 	logger.Debug("debuglog", "category", "tst", "key", "value")
@@ -38,3 +41,200 @@ func TestTextHandler(t *testing.T) {
 		}
 	}
 }
+
+func TestTextHandler_Groups(t *testing.T) {
+	tests := []struct {
+		name string
+		log  func(l *slog.Logger)
+		want string
+	}{
+		{
+			name: "record group",
+			log:  func(l *slog.Logger) { l.Info("msg", slog.Group("req", "method", "GET", "path", "/")) },
+			want: "req.method=GET req.path=/",
+		},
+		{
+			name: "WithGroup",
+			log:  func(l *slog.Logger) { l.WithGroup("req").WithGroup("hdr").Info("msg", "accept", "*/*") },
+			want: "req.hdr.accept=*/*",
+		},
+		{
+			name: "WithAttrs keeps earlier attrs and groups",
+			log:  func(l *slog.Logger) { l.With("a", 1).WithGroup("g").With("b", 2).Info("msg", "c", 3) },
+			want: "a=1 g.b=2 g.c=3",
+		},
+		{
+			name: "inline and empty groups",
+			log:  func(l *slog.Logger) { l.WithGroup("").Info("msg", slog.Group("", "x", 1), slog.Group("empty")) },
+			want: "x=1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			tt.log(slog.New(NewTextHandler(&buf)).With("category", "tst"))
+			_, got, _ := strings.Cut(strings.TrimSuffix(buf.String(), "\n"), "msg ")
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTextHandler_Color(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		opts    []TextOption
+		wantESC bool
+	}{
+		{"not a terminal", nil, nil, false},
+		{"CLICOLOR_FORCE", map[string]string{"CLICOLOR_FORCE": "1"}, nil, true},
+		{"CLICOLOR_FORCE=0", map[string]string{"CLICOLOR_FORCE": "0"}, nil, false},
+		{"NO_COLOR wins", map[string]string{"NO_COLOR": "1", "CLICOLOR_FORCE": "1"}, nil, false},
+		{"WithColor wins", map[string]string{"NO_COLOR": "1"}, []TextOption{WithColor(true)}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("NO_COLOR", "")
+			t.Setenv("CLICOLOR_FORCE", "")
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			var buf bytes.Buffer
+			slog.New(NewTextHandler(&buf, tt.opts...)).Info("msg", "category", "tst", "k", "v")
+			if got := strings.Contains(buf.String(), "\033["); got != tt.wantESC {
+				t.Errorf("escape codes = %v, want %v: %q", got, tt.wantESC, buf.String())
+			}
+		})
+	}
+}
+
+func TestTextHandler_Layout(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []TextOption
+		want string
+	}{
+		{
+			name: "default",
+			want: "tst       msg key=value",
+		},
+		{
+			name: "long category is truncated",
+			opts: []TextOption{WithCategoryWidth(2)},
+			want: "ts msg key=value",
+		},
+		{
+			name: "unpadded category",
+			opts: []TextOption{WithCategoryWidth(0)},
+			want: "tst msg key=value",
+		},
+		{
+			name: "reordered",
+			opts: []TextOption{WithLayout(TextLevel, TextMessage, TextAttrs, TextCategory)},
+			want: "WARN  msg key=value tst       ",
+		},
+		{
+			name: "time",
+			opts: []TextOption{WithLayout(TextTime, TextMessage)},
+			want: "12:34:56.789 msg",
+		},
+		{
+			name: "theme",
+			opts: []TextOption{WithColor(true), WithTheme(Theme{Key: "<k>", Value: "<v>", Warn: "<w>"}), WithCategoryWidth(0)},
+			want: "<w>tst\033[0m msg <k>key\033[0m=<v>value\033[0m",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			r := slog.NewRecord(time.Date(2025, 1, 2, 12, 34, 56, 789e6, time.UTC), slog.LevelWarn, "msg", 0)
+			r.AddAttrs(slog.String("category", "tst"), slog.String("key", "value"))
+			NewTextHandler(&buf, append([]TextOption{WithColor(false)}, tt.opts...)...).Handle(context.Background(), r)
+			if got := strings.TrimSuffix(buf.String(), "\n"); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTextHandler_Source(t *testing.T) {
+	var buf bytes.Buffer
+	slog.New(NewTextHandler(&buf, WithColor(false), WithLayout(TextMessage, TextSource))).Info("msg")
+	if got := buf.String(); !strings.HasPrefix(got, "msg file=") || !strings.Contains(got, "file=log/slogx/texthandler_test.go:") ||
+		!strings.Contains(got, "func=github.com/rburchell/gosh/log/slogx.TestTextHandler_Source") {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestTrimSourcePath(t *testing.T) {
+	tests := []struct {
+		file     string
+		function string
+		want     string
+	}{
+		{"/src/gosh/net/http/server/server.go", "github.com/rburchell/gosh/net/http/server.(*Builder).Build", "net/http/server/server.go"},
+		{"/src/gosh/log/slogx/slogx_test.go", "github.com/rburchell/gosh/log/slogx_test.TestX.func1", "log/slogx/slogx_test.go"},
+		{"/usr/lib/go/src/log/slog/logger.go", "log/slog.(*Logger).log", "log/slog/logger.go"},
+		{"/usr/lib/go/src/runtime/proc.go", "runtime.main", "runtime/proc.go"},
+		{"/elsewhere/x.go", "example.com/unknown.F", "/elsewhere/x.go"},
+		{"/src/other/net/http/server/server.go", "github.com/rburchell/gosh/log/slogx.F", "/src/other/net/http/server/server.go"},
+		{"example.com/m/main.go", "main.main", "example.com/m/main.go"},
+	}
+	for _, tt := range tests {
+		if got := trimSourcePath(tt.file, tt.function); got != tt.want {
+			t.Errorf("trimSourcePath(%q, %q) = %q, want %q", tt.file, tt.function, got, tt.want)
+		}
+	}
+}
+
+func TestTextHandler_Dedup(t *testing.T) {
+	tests := []struct {
+		name string
+		log  func(l *slog.Logger)
+		want string
+	}{
+		{
+			name: "record wins",
+			log:  func(l *slog.Logger) { l.With("a", 1, "b", 2).Info("msg", "a", 3) },
+			want: "tst       msg a=3 b=2",
+		},
+		{
+			name: "category",
+			log:  func(l *slog.Logger) { l.Info("msg", "category", "mine") },
+			want: "mine      msg",
+		},
+		{
+			name: "groups",
+			log:  func(l *slog.Logger) { l.WithGroup("g").With("a", 1).Info("msg", "a", 2, slog.Group("h", "a", 3)) },
+			want: "tst       msg g.a=2 g.h.a=3",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			tt.log(NewCategory("tst", NewTextHandler(&buf, WithColor(false)), slog.LevelDebug))
+			if got := strings.TrimSuffix(buf.String(), "\n"); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func BenchmarkTextHandler(b *testing.B) {
+	logger := NewCategory("bench", NewTextHandler(io.Discard, WithColor(true)), slog.LevelInfo).
+		With("service", "api", "version", "1.2.3", "region", "eu-west-1", "pid", 1234, "host", "web-1")
+	b.ReportAllocs()
+	for b.Loop() {
+		logger.Info("request", "path", "/users", "status", 200)
+	}
+}
+
+func BenchmarkTextHandler_NoHandlerAttrs(b *testing.B) {
+	logger := NewCategory("bench", NewTextHandler(io.Discard, WithColor(true)), slog.LevelInfo)
+	b.ReportAllocs()
+	for b.Loop() {
+		logger.Info("request", "path", "/users", "status", 200)
+	}
+}
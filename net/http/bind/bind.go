@@ -4,14 +4,34 @@
 
 // Package bind provides an easy way to map a HTTP request parameters to a structs.
 //
-// Data sources are query parameters, form values, and JSON bodies.
+// Data sources are query parameters, form values, headers, and JSON bodies.
+// Other body formats (e.g. msgpack) can be bound by providing a [Codec].
+// [BindValues] can also be used to bind key/value data that did not come from a request.
 //
 // Supported struct tags are:
 //   - `form`: The name of the formfield to decode.
 //   - `binding:"required"`: Marks the field as required.
 //
+// The name in a tag may be followed by comma-separated options, e.g. `form:"tags,brackets"`.
+// Supported options for query and form values are:
+//   - `brackets`: Binds a slice from repeated "name[]" keys (tags[]=a&tags[]=b).
+//   - `indexed`: Binds a slice from indexed keys (tags[0]=a&tags[1]=b).
+//     For a slice of structs, fields of each element are bound from items[0].name=x.
+//
+// Slice fields without either option are bound from repeated keys (tags=a&tags=b).
+//
+// For all sources, string values can be normalized before conversion with:
+//   - `trim`: Removes leading and trailing whitespace.
+//   - `lower`: Converts to lower case.
+//   - `upper`: Converts to upper case.
+//
+// For example, `form:"email,trim,lower"`.
+//
 // If a required parameter is missing, an error is returned.
 //
+// Once all fields are bound, cross-field checks can be made by implementing [Validator]
+// on the destination type, or by using [RegisterValidator].
+//
 // Example usage:
 //
 //	type Input struct {
@@ -30,6 +50,8 @@ import (
 	"fmt"
 	"net/http"
 	"reflect"
+	"slices"
+	"strings"
 )
 
 // Validate that all fields on obj with a required binding were placed in writtenFields.
@@ -50,20 +72,47 @@ func validateRequired[T any](writtenFields map[string]struct{}, obj T) error {
 	return nil
 }
 
+// The options following the name in a struct tag, e.g. `form:"name,opt1,opt2"`.
+type tagOptions []string
+
+// Returns true if the option 'name' is set.
+func (o tagOptions) Has(name string) bool {
+	return slices.Contains(o, name)
+}
+
+// Applies the normalization options (trim, lower, upper) in o to s.
+func (o tagOptions) normalize(s string) string {
+	if o.Has("trim") {
+		s = strings.TrimSpace(s)
+	}
+	if o.Has("lower") {
+		s = strings.ToLower(s)
+	}
+	if o.Has("upper") {
+		s = strings.ToUpper(s)
+	}
+	return s
+}
+
 // Look up each field and value on a given obj, and call the callback.
 //
 // The given tagKey is used to name the field by tag instead of using the field name, if it's set.
-func forEachField(obj any, tagKey string, fn func(field reflect.StructField, fv reflect.Value, tag string) error) error {
+// Any options following the name in the tag are passed as opts.
+func forEachField(obj any, tagKey string, fn func(field reflect.StructField, fv reflect.Value, tag string, opts tagOptions) error) error {
 	v := reflect.ValueOf(obj).Elem()
 	t := v.Type()
 
 	for i := range t.NumField() {
 		f := t.Field(i)
-		tag := f.Tag.Get(tagKey)
+		tag, rest, _ := strings.Cut(f.Tag.Get(tagKey), ",")
 		if tag == "" {
 			tag = f.Name
 		}
-		if err := fn(f, v.Field(i), tag); err != nil {
+		var opts tagOptions
+		if rest != "" {
+			opts = strings.Split(rest, ",")
+		}
+		if err := fn(f, v.Field(i), tag, opts); err != nil {
 			return err
 		}
 	}
@@ -88,28 +137,7 @@ func BindForm[T any](r *http.Request, obj *T) error {
 		return err
 	}
 
-	writtenFields := make(map[string]struct{})
-	err := forEachField(obj, "form", func(field reflect.StructField, fv reflect.Value, tag string) error {
-		values, present := r.Form[tag]
-		if !present {
-			return nil
-		}
-		if len(values) == 0 {
-			panic("how is this present?")
-		}
-		value := values[0]
-		if err := setFieldValue(field.Name, fv, value); err != nil {
-			return err
-		}
-		writtenFields[field.Name] = struct{}{}
-		return nil
-	})
-
-	if err != nil {
-		return err
-	}
-
-	return validateRequired(writtenFields, obj)
+	return bindValues(r.Form, "form", obj)
 }
 
 // Reads query values from r and writes them to obj.
@@ -126,26 +154,7 @@ func BindForm[T any](r *http.Request, obj *T) error {
 // If the struct tag `binding:"required" is set,
 // then if the field is not present, an error will be returned.`
 func BindQuery[T any](r *http.Request, obj *T) error {
-	q := r.URL.Query()
-
-	writtenFields := make(map[string]struct{})
-	err := forEachField(obj, "query", func(field reflect.StructField, fv reflect.Value, tag string) error {
-		value, present := q.Get(tag), q.Has(tag)
-		if !present {
-			return nil
-		}
-		if err := setFieldValue(field.Name, fv, value); err != nil {
-			return err
-		}
-		writtenFields[field.Name] = struct{}{}
-		return nil
-	})
-
-	if err != nil {
-		return err
-	}
-
-	return validateRequired(writtenFields, obj)
+	return bindValues(r.URL.Query(), "query", obj)
 }
 
 // Reads json values from r and writes them to obj.
@@ -169,12 +178,21 @@ func BindJSON[T any](r *http.Request, obj *T) error {
 		return err
 	}
 
+	return bindMap(data, "json", obj)
+}
+
+// Writes the decoded body data to obj, naming fields by the tagKey struct tag.
+func bindMap(data map[string]any, tagKey string, obj any) error {
 	writtenFields := make(map[string]struct{})
-	err := forEachField(obj, "json", func(field reflect.StructField, fv reflect.Value, tag string) error {
+	err := forEachField(obj, tagKey, func(field reflect.StructField, fv reflect.Value, tag string, opts tagOptions) error {
 		value, ok := data[tag]
-		if !ok {
+		if !ok || value == nil {
+			// An explicit null is treated the same as a missing field.
 			return nil
 		}
+		if s, ok := value.(string); ok {
+			value = opts.normalize(s)
+		}
 		if err := setFieldValue(field.Name, fv, value); err != nil {
 			return err
 		}
@@ -186,5 +204,8 @@ func BindJSON[T any](r *http.Request, obj *T) error {
 		return err
 	}
 
-	return validateRequired(writtenFields, obj)
+	if err := validateRequired(writtenFields, obj); err != nil {
+		return err
+	}
+	return validateStruct(obj)
 }
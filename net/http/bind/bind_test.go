@@ -534,3 +534,18 @@ func TestBindJSON(t *testing.T) {
 		})
 	}
 }
+
+func TestBindJSONNormalize(t *testing.T) {
+	type NormInput struct {
+		Email string `json:"email,trim,lower"`
+		Num   int    `json:"num,trim"`
+	}
+	r := &http.Request{Body: io.NopCloser(strings.NewReader(`{"email": " Bob@Example.com ", "num": 3}`))}
+	var got NormInput
+	if err := BindJSON(r, &got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := (NormInput{Email: "bob@example.com", Num: 3}); got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bind
+
+import (
+	"errors"
+	"io"
+	"net/http"
+)
+
+// A Codec decodes a request body.
+//
+// Codecs allow binding other body formats without this package depending on them.
+type Codec interface {
+	Unmarshal(data []byte, v any) error
+}
+
+// CodecFunc adapts an ordinary function (e.g. msgpack.Unmarshal) to a Codec.
+type CodecFunc func(data []byte, v any) error
+
+func (f CodecFunc) Unmarshal(data []byte, v any) error {
+	return f(data, v)
+}
+
+// The Codec used by BindMsgpack.
+//
+// This is nil by default, so that there is no dependency on a msgpack implementation.
+// To enable BindMsgpack, set it at startup, e.g:
+//
+//	bind.MsgpackCodec = bind.CodecFunc(msgpack.Unmarshal)
+var MsgpackCodec Codec
+
+// Reads the body of r with codec, and writes the values to obj.
+//
+// The codec must be able to decode into a map[string]any.
+// Field names are determined from the struct field names,
+// but can be overridden by setting a struct tag named by tagKey.
+//
+// If the struct tag `binding:"required" is set,
+// then if the field is not present, an error will be returned.`
+func BindBody[T any](r *http.Request, codec Codec, tagKey string, obj *T) error {
+	defer r.Body.Close()
+
+	b, err := io.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+
+	var data map[string]any
+	if err := codec.Unmarshal(b, &data); err != nil {
+		return err
+	}
+
+	return bindMap(data, tagKey, obj)
+}
+
+// Reads msgpack values from r and writes them to obj, using MsgpackCodec.
+//
+// The msgpack field names are determined from the struct field names,
+// but can be overridden by setting a "msgpack" struct tag.
+//
+// For example:
+//
+//	struct Person {
+//	    Age int `msgpack:"age"`
+//	}
+//
+// If the struct tag `binding:"required" is set,
+// then if the field is not present, an error will be returned.`
+func BindMsgpack[T any](r *http.Request, obj *T) error {
+	if MsgpackCodec == nil {
+		return errors.New("bind: no MsgpackCodec set")
+	}
+	return BindBody(r, MsgpackCodec, "msgpack", obj)
+}
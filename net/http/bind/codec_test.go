@@ -0,0 +1,71 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bind
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Stands in for a real msgpack implementation, which we don't want to depend on.
+var fakeMsgpack = CodecFunc(json.Unmarshal)
+
+func TestBindMsgpack(t *testing.T) {
+	type MsgpackInput struct {
+		Title string `msgpack:"title" binding:"required"`
+		Num   int8   `msgpack:"num"`
+	}
+
+	MsgpackCodec = nil
+	var got MsgpackInput
+	r := httptest.NewRequest("POST", "/", strings.NewReader(`{"title":"x"}`))
+	if err := BindMsgpack(r, &got); err == nil {
+		t.Fatalf("expected error without a codec")
+	}
+
+	MsgpackCodec = fakeMsgpack
+	defer func() { MsgpackCodec = nil }()
+
+	tests := []struct {
+		name    string
+		body    string
+		want    MsgpackInput
+		wantErr bool
+	}{
+		{"all fields", `{"title":"x","num":3}`, MsgpackInput{Title: "x", Num: 3}, false},
+		{"missing required", `{"num":3}`, MsgpackInput{}, true},
+		{"null required", `{"title":null}`, MsgpackInput{}, true},
+		{"overflow", `{"title":"x","num":300}`, MsgpackInput{}, true},
+		{"bad body", `{`, MsgpackInput{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/", strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", "application/msgpack")
+			var got MsgpackInput
+			err := Bind(r, &got)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBindBody(t *testing.T) {
+	type Input struct {
+		Name string `custom:"name" binding:"required"`
+	}
+	r := &http.Request{Body: http.NoBody}
+	var got Input
+	if err := BindBody(r, fakeMsgpack, "custom", &got); err == nil {
+		t.Errorf("expected error decoding empty body")
+	}
+}
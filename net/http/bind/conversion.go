@@ -6,6 +6,7 @@ package bind
 
 import (
 	"fmt"
+	"math"
 	"reflect"
 	"strconv"
 )
@@ -55,21 +56,21 @@ func setFieldValue(fieldName string, fv reflect.Value, value any) error {
 			}
 			fv.SetBool(b)
 		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-			i, err := strconv.ParseInt(str, 10, 64)
+			i, err := strconv.ParseInt(str, 10, fv.Type().Bits())
 			if err != nil {
-				return fmt.Errorf("cannot convert %q to int: %w", str, err)
+				return fmt.Errorf("cannot convert %q to %s: %w", str, fv.Type(), err)
 			}
 			fv.SetInt(i)
 		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-			u, err := strconv.ParseUint(str, 10, 64)
+			u, err := strconv.ParseUint(str, 10, fv.Type().Bits())
 			if err != nil {
-				return fmt.Errorf("cannot convert %q to uint: %w", str, err)
+				return fmt.Errorf("cannot convert %q to %s: %w", str, fv.Type(), err)
 			}
 			fv.SetUint(u)
 		case reflect.Float32, reflect.Float64:
-			f, err := strconv.ParseFloat(str, 64)
+			f, err := strconv.ParseFloat(str, fv.Type().Bits())
 			if err != nil {
-				return fmt.Errorf("cannot convert %q to float: %w", str, err)
+				return fmt.Errorf("cannot convert %q to %s: %w", str, fv.Type(), err)
 			}
 			fv.SetFloat(f)
 
@@ -88,47 +89,54 @@ func setFieldValue(fieldName string, fv reflect.Value, value any) error {
 		i := reflect.ValueOf(v).Int()
 		switch kind {
 		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-			fv.SetInt(i)
+			return setInt(fieldName, fv, i)
 		case reflect.Float32, reflect.Float64:
-			fv.SetFloat(float64(i))
+			return setFloat(fieldName, fv, float64(i))
 		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
 			if i < 0 {
 				return fmt.Errorf("cannot assign negative int to uint")
 			}
-			fv.SetUint(uint64(i))
+			return setUint(fieldName, fv, uint64(i))
 		default:
 			return fmt.Errorf("cannot assign int to %s", kind)
 		}
-		return nil
 	case uint, uint8, uint16, uint32, uint64:
 		u := reflect.ValueOf(v).Uint()
 		switch kind {
 		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-			fv.SetUint(u)
+			return setUint(fieldName, fv, u)
 		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-			fv.SetInt(int64(u))
+			if u > math.MaxInt64 {
+				return fmt.Errorf("field %s: %d overflows %s", fieldName, u, fv.Type())
+			}
+			return setInt(fieldName, fv, int64(u))
 		case reflect.Float32, reflect.Float64:
-			fv.SetFloat(float64(u))
+			return setFloat(fieldName, fv, float64(u))
 		default:
 			return fmt.Errorf("cannot assign uint to %s", kind)
 		}
-		return nil
 	case float32, float64:
 		f := reflect.ValueOf(v).Float()
 		switch kind {
 		case reflect.Float32, reflect.Float64:
-			fv.SetFloat(f)
+			return setFloat(fieldName, fv, f)
 		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-			fv.SetInt(int64(f))
+			// Note the asymmetric bounds: -2^63 is representable, 2^63 is not.
+			if math.IsNaN(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+				return fmt.Errorf("field %s: %v overflows %s", fieldName, f, fv.Type())
+			}
+			return setInt(fieldName, fv, int64(f))
 		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
 			if f < 0 {
 				return fmt.Errorf("cannot assign negative float to uint")
 			}
-			fv.SetUint(uint64(f))
+			if math.IsNaN(f) || f >= math.MaxUint64 {
+				return fmt.Errorf("field %s: %v overflows %s", fieldName, f, fv.Type())
+			}
+			return setUint(fieldName, fv, uint64(f))
 		default:
 			return fmt.Errorf("cannot assign float to %s", kind)
 		}
-		return nil
 	}
 
 	// Handle slices
@@ -176,3 +184,30 @@ func setFieldValue(fieldName string, fv reflect.Value, value any) error {
 	// give up and go home
 	return fmt.Errorf("cannot assign %T to %s", value, fv.Type())
 }
+
+// Writes i to the int field fv, unless it would overflow.
+func setInt(fieldName string, fv reflect.Value, i int64) error {
+	if fv.OverflowInt(i) {
+		return fmt.Errorf("field %s: %d overflows %s", fieldName, i, fv.Type())
+	}
+	fv.SetInt(i)
+	return nil
+}
+
+// Writes u to the uint field fv, unless it would overflow.
+func setUint(fieldName string, fv reflect.Value, u uint64) error {
+	if fv.OverflowUint(u) {
+		return fmt.Errorf("field %s: %d overflows %s", fieldName, u, fv.Type())
+	}
+	fv.SetUint(u)
+	return nil
+}
+
+// Writes f to the float field fv, unless it would overflow.
+func setFloat(fieldName string, fv reflect.Value, f float64) error {
+	if fv.OverflowFloat(f) {
+		return fmt.Errorf("field %s: %v overflows %s", fieldName, f, fv.Type())
+	}
+	fv.SetFloat(f)
+	return nil
+}
@@ -0,0 +1,107 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bind
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+type numericStruct struct {
+	I8  int8
+	I16 int16
+	I32 int32
+	I64 int64
+	U8  uint8
+	U16 uint16
+	U32 uint32
+	U64 uint64
+	F32 float32
+	F64 float64
+}
+
+func TestSetFieldValueOverflow(t *testing.T) {
+	tests := []struct {
+		field   string
+		value   any
+		wantErr bool
+	}{
+		{"I8", "127", false},
+		{"I8", "128", true},
+		{"I8", "-128", false},
+		{"I8", "-129", true},
+		{"I8", 300, true},
+		{"I8", uint(200), true},
+		{"I8", 200.0, true},
+		{"I16", 40000, true},
+		{"I32", int64(math.MaxInt32) + 1, true},
+		{"I64", uint64(math.MaxUint64), true},
+		{"I64", 1e19, true},
+		{"I64", math.NaN(), true},
+		{"U8", "255", false},
+		{"U8", "256", true},
+		{"U8", 256, true},
+		{"U8", uint16(256), true},
+		{"U8", 256.0, true},
+		{"U16", uint32(70000), true},
+		{"U32", "4294967296", true},
+		{"U64", 1e20, true},
+		{"F32", "1e39", true},
+		{"F32", 1e39, true},
+		{"F32", 1.5, false},
+		{"F64", "1e39", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s/%v", tt.field, tt.value), func(t *testing.T) {
+			var s numericStruct
+			fv := reflect.ValueOf(&s).Elem().FieldByName(tt.field)
+			err := setFieldValue(tt.field, fv, tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("setFieldValue(%s, %v) error = %v, wantErr %v", tt.field, tt.value, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// Checks that converting arbitrary strings to numeric fields never panics,
+// and that anything accepted round-trips without truncation.
+func FuzzSetFieldValue(f *testing.F) {
+	for _, seed := range []string{"0", "-1", "127", "128", "255", "256", "65536", "4294967296", "1e39", "NaN", "-0", "18446744073709551616"} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, in string) {
+		var s numericStruct
+		v := reflect.ValueOf(&s).Elem()
+		for i := range v.NumField() {
+			fv := v.Field(i)
+			name := v.Type().Field(i).Name
+			if err := setFieldValue(name, fv, in); err != nil {
+				continue
+			}
+
+			switch fv.Kind() {
+			case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+				want, err := strconv.ParseInt(in, 10, 64)
+				if err != nil || fv.Int() != want {
+					t.Errorf("%s: %q became %d", name, in, fv.Int())
+				}
+			case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+				want, err := strconv.ParseUint(in, 10, 64)
+				if err != nil || fv.Uint() != want {
+					t.Errorf("%s: %q became %d", name, in, fv.Uint())
+				}
+			case reflect.Float32, reflect.Float64:
+				if f, _ := strconv.ParseFloat(in, 64); math.IsInf(fv.Float(), 0) && !math.IsInf(f, 0) {
+					t.Errorf("%s: %q became %v", name, in, fv.Float())
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,103 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bind
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/rburchell/gosh/log/slogx"
+	"log/slog"
+	"mime"
+	"net/http"
+)
+
+var log *slog.Logger = slogx.NewCategory("http", slogx.TextHandler, slog.LevelDebug)
+
+// Binds r to obj, picking the right Bind* variant for the request.
+//
+// Requests with a JSON content type use BindJSON.
+// Requests with a msgpack content type use BindMsgpack.
+// GET, HEAD, and DELETE requests use BindQuery.
+// Everything else uses BindForm (which also includes query values).
+func Bind[T any](r *http.Request, obj *T) error {
+	ct, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch {
+	case ct == "application/json":
+		return BindJSON(r, obj)
+	case ct == "application/msgpack" || ct == "application/x-msgpack":
+		return BindMsgpack(r, obj)
+	case r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodDelete:
+		return BindQuery(r, obj)
+	default:
+		return BindForm(r, obj)
+	}
+}
+
+// Handler adapts a typed function into a http.HandlerFunc.
+//
+// The request is bound to a new InputT (see Bind), and fn is called with it.
+// The returned OutputT is written as JSON, with a 200 status.
+//
+// If binding fails, a 400 is written. If fn fails, a 500 is written,
+// unless the error has a `StatusCode() int` method, in which case that status is used.
+// Errors are written as JSON, in the form {"error": "message"}. For 5xx statuses, the error is
+// logged, and the message is just the status text, so that internal details aren't leaked.
+//
+// For example:
+//
+//	type PingIn struct {
+//	    Name string `query:"name" binding:"required"`
+//	}
+//	type PingOut struct {
+//	    Greeting string `json:"greeting"`
+//	}
+//
+//	mux.Handle("/ping", bind.Handler(func(ctx context.Context, in PingIn) (PingOut, error) {
+//	    return PingOut{Greeting: "hello " + in.Name}, nil
+//	}))
+func Handler[InputT any, OutputT any](fn func(ctx context.Context, in InputT) (OutputT, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var in InputT
+		if err := Bind(r, &in); err != nil {
+			writeJSON(w, http.StatusBadRequest, errorBody{Error: err.Error()})
+			return
+		}
+
+		out, err := fn(r.Context(), in)
+		if err != nil {
+			status := ErrorStatus(err)
+			msg := err.Error()
+			if status >= 500 {
+				log.ErrorContext(r.Context(), "Handler failed", "path", r.URL.Path, "err", err)
+				msg = http.StatusText(status)
+			}
+			writeJSON(w, status, errorBody{Error: msg})
+			return
+		}
+
+		writeJSON(w, http.StatusOK, out)
+	}
+}
+
+type errorBody struct {
+	Error string `json:"error"`
+}
+
+// ErrorStatus returns the status code to use for err: that from its `StatusCode() int` method,
+// if it has one, or 500.
+func ErrorStatus(err error) int {
+	var sc interface{ StatusCode() int }
+	if errors.As(err, &sc) {
+		return sc.StatusCode()
+	}
+	return http.StatusInternalServerError
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
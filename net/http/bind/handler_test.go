@@ -0,0 +1,84 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bind
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type statusErr struct{}
+
+func (statusErr) Error() string   { return "nope" }
+func (statusErr) StatusCode() int { return http.StatusTeapot }
+
+type unavailableErr struct{}
+
+func (unavailableErr) Error() string   { return "db is down" }
+func (unavailableErr) StatusCode() int { return http.StatusServiceUnavailable }
+
+func TestHandler(t *testing.T) {
+	type In struct {
+		Name string `query:"name" form:"name" json:"name" binding:"required"`
+	}
+	type Out struct {
+		Greeting string `json:"greeting"`
+	}
+
+	h := Handler(func(ctx context.Context, in In) (Out, error) {
+		switch in.Name {
+		case "fail":
+			return Out{}, errors.New("failed")
+		case "teapot":
+			return Out{}, statusErr{}
+		case "unavailable":
+			return Out{}, unavailableErr{}
+		}
+		return Out{Greeting: "hello " + in.Name}, nil
+	})
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		ctype      string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{"query", "GET", "/?name=bob", "", "", 200, `{"greeting":"hello bob"}`},
+		{"form", "POST", "/", "application/x-www-form-urlencoded", "name=alice", 200, `{"greeting":"hello alice"}`},
+		{"json", "POST", "/", "application/json; charset=utf-8", `{"name":"eve"}`, 200, `{"greeting":"hello eve"}`},
+		{"missing required", "GET", "/", "", "", 400, `{"error":"Name is required"}`},
+		{"handler error", "GET", "/?name=fail", "", "", 500, `{"error":"Internal Server Error"}`},
+		{"handler status error", "GET", "/?name=teapot", "", "", 418, `{"error":"nope"}`},
+		{"handler 5xx status error", "GET", "/?name=unavailable", "", "", 503, `{"error":"Service Unavailable"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			if tt.ctype != "" {
+				req.Header.Set("Content-Type", tt.ctype)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got := strings.TrimSpace(w.Body.String()); got != tt.wantBody {
+				t.Errorf("body = %s, want %s", got, tt.wantBody)
+			}
+			if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+				t.Errorf("content type = %q", ct)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bind
+
+import (
+	"reflect"
+	"sync"
+)
+
+// A Validator checks a struct after all of its fields have been bound.
+//
+// If the destination of a Bind* call implements Validator, Validate is called
+// once binding succeeds, so that cross-field checks (start < end, password == confirm)
+// can live with the input type.
+type Validator interface {
+	Validate() error
+}
+
+var (
+	validatorsMu sync.RWMutex
+	validators   = map[reflect.Type]func(any) error{}
+)
+
+// Registers fn to validate T after binding, for types you can't add a Validate method to.
+//
+// A registered validator runs before the type's own Validate method (if any).
+// Registering a second validator for the same T replaces the first.
+func RegisterValidator[T any](fn func(obj *T) error) {
+	validatorsMu.Lock()
+	defer validatorsMu.Unlock()
+	validators[reflect.TypeFor[T]()] = func(obj any) error {
+		return fn(obj.(*T))
+	}
+}
+
+// Runs the struct-level validation for obj, which must be a pointer to a struct.
+func validateStruct(obj any) error {
+	validatorsMu.RLock()
+	fn := validators[reflect.TypeOf(obj).Elem()]
+	validatorsMu.RUnlock()
+
+	if fn != nil {
+		if err := fn(obj); err != nil {
+			return err
+		}
+	}
+	if v, ok := obj.(Validator); ok {
+		return v.Validate()
+	}
+	return nil
+}
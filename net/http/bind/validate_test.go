@@ -0,0 +1,77 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bind
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+type rangeInput struct {
+	Start int `query:"start" binding:"required"`
+	End   int `query:"end" binding:"required"`
+}
+
+func (r *rangeInput) Validate() error {
+	if r.Start >= r.End {
+		return errors.New("start must be before end")
+	}
+	return nil
+}
+
+type passwordInput struct {
+	Password string `query:"password"`
+	Confirm  string `query:"confirm"`
+}
+
+func TestValidator(t *testing.T) {
+	tests := []struct {
+		rawQS   string
+		wantErr bool
+	}{
+		{"start=1&end=2", false},
+		{"start=2&end=1", true},
+		{"start=2", true}, // required check still comes first
+	}
+	for _, tt := range tests {
+		t.Run(tt.rawQS, func(t *testing.T) {
+			r := &http.Request{URL: &url.URL{RawQuery: tt.rawQS}}
+			var got rangeInput
+			err := BindQuery(r, &got)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRegisterValidator(t *testing.T) {
+	RegisterValidator(func(in *passwordInput) error {
+		if in.Password != in.Confirm {
+			return errors.New("passwords do not match")
+		}
+		return nil
+	})
+
+	tests := []struct {
+		rawQS   string
+		wantErr bool
+	}{
+		{"password=a&confirm=a", false},
+		{"password=a&confirm=b", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rawQS, func(t *testing.T) {
+			r := &http.Request{URL: &url.URL{RawQuery: tt.rawQS}}
+			var got passwordInput
+			err := BindQuery(r, &got)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
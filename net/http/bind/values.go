@@ -0,0 +1,183 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bind
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// The largest index accepted in an indexed key (e.g. items[999]).
+// This stops a hostile client from making us allocate a huge slice.
+const maxIndex = 1000
+
+// Reads values and writes them to obj, without needing a request.
+//
+// The names to look up in values are determined from the struct field names,
+// but can be overridden by setting a struct tag named by tagKey.
+// This allows reusing the binder for other key/value inputs, e.g:
+//
+//	type Options struct {
+//	    Verbose bool `opt:"verbose"`
+//	}
+//
+//	var opts Options
+//	err := bind.BindValues(url.Values{"verbose": {"true"}}, "opt", &opts)
+//
+// If the struct tag `binding:"required"` is set,
+// then if the field is not present, an error will be returned.
+func BindValues[T any](values url.Values, tagKey string, obj *T) error {
+	return bindValues(values, tagKey, obj)
+}
+
+// Reads header values from r and writes them to obj.
+//
+// The header names are determined from the struct field names,
+// but can be overridden by setting a "header" struct tag.
+// Header names are not case sensitive.
+//
+// For example:
+//
+//	struct Request {
+//	    Token string `header:"x-api-token"`
+//	}
+//
+// If the struct tag `binding:"required" is set,
+// then if the field is not present, an error will be returned.`
+func BindHeader[T any](r *http.Request, obj *T) error {
+	return BindHeaderValues(r.Header, obj)
+}
+
+// The same as BindHeader, but reads from h directly, without needing a request.
+func BindHeaderValues[T any](h http.Header, obj *T) error {
+	return bindValues(url.Values(h), "header", obj)
+}
+
+// Writes values to obj, naming fields by the tagKey struct tag.
+func bindValues(values url.Values, tagKey string, obj any) error {
+	writtenFields := make(map[string]struct{})
+	err := forEachField(obj, tagKey, func(field reflect.StructField, fv reflect.Value, tag string, opts tagOptions) error {
+		present, err := bindField(values, tagKey, field.Name, fv, tag, opts)
+		if err != nil {
+			return err
+		}
+		if present {
+			writtenFields[field.Name] = struct{}{}
+		}
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if err := validateRequired(writtenFields, obj); err != nil {
+		return err
+	}
+	return validateStruct(obj)
+}
+
+// Writes the value(s) for 'tag' in values to fv.
+// Returns true if the field was present in values.
+func bindField(values url.Values, tagKey string, fieldName string, fv reflect.Value, tag string, opts tagOptions) (bool, error) {
+	if tagKey == "header" {
+		tag = http.CanonicalHeaderKey(tag)
+	}
+
+	if fv.Kind() == reflect.Slice {
+		switch {
+		case opts.Has("indexed"):
+			return bindIndexed(values, tagKey, fieldName, fv, tag, opts)
+		case opts.Has("brackets"):
+			tag += "[]"
+		}
+		vals, present := values[tag]
+		if !present {
+			return false, nil
+		}
+		normalized := make([]string, len(vals))
+		for i, v := range vals {
+			normalized[i] = opts.normalize(v)
+		}
+		return true, setFieldValue(fieldName, fv, normalized)
+	}
+
+	vals, present := values[tag]
+	if !present || len(vals) == 0 {
+		return false, nil
+	}
+	return true, setFieldValue(fieldName, fv, opts.normalize(vals[0]))
+}
+
+// Writes indexed keys (tag[0]=a, tag[1].name=b) to the slice fv.
+//
+// Missing indexes are left as the zero value.
+// Returns true if any indexed key was present in values.
+func bindIndexed(values url.Values, tagKey string, fieldName string, fv reflect.Value, tag string, opts tagOptions) (bool, error) {
+	prefix := tag + "["
+	elems := map[int]url.Values{}
+	n := 0
+
+	for key, vals := range values {
+		rest, ok := strings.CutPrefix(key, prefix)
+		if !ok {
+			continue
+		}
+		idxStr, sub, ok := strings.Cut(rest, "]")
+		if !ok {
+			return false, fmt.Errorf("%s: malformed key %q", fieldName, key)
+		}
+		idx, err := strconv.Atoi(idxStr)
+		if err != nil || idx < 0 {
+			return false, fmt.Errorf("%s: invalid index in %q", fieldName, key)
+		}
+		if idx >= maxIndex {
+			return false, fmt.Errorf("%s: index %d too large", fieldName, idx)
+		}
+		if sub != "" && sub[0] != '.' {
+			return false, fmt.Errorf("%s: malformed key %q", fieldName, key)
+		}
+		if elems[idx] == nil {
+			elems[idx] = url.Values{}
+		}
+		elems[idx][strings.TrimPrefix(sub, ".")] = vals
+		n = max(n, idx+1)
+	}
+
+	if len(elems) == 0 {
+		return false, nil
+	}
+
+	slice := reflect.MakeSlice(fv.Type(), n, n)
+	for idx := range n {
+		ev, ok := elems[idx]
+		if !ok {
+			continue
+		}
+		elemName := fmt.Sprintf("%s[%d]", fieldName, idx)
+		elem := slice.Index(idx)
+
+		if elem.Kind() == reflect.Struct {
+			if err := bindValues(ev, tagKey, elem.Addr().Interface()); err != nil {
+				return false, fmt.Errorf("%s: %w", elemName, err)
+			}
+			continue
+		}
+
+		vals := ev[""]
+		if len(vals) == 0 {
+			return false, fmt.Errorf("%s: expected a value, not fields", elemName)
+		}
+		if err := setFieldValue(elemName, elem, opts.normalize(vals[0])); err != nil {
+			return false, err
+		}
+	}
+	fv.Set(slice)
+	return true, nil
+}
@@ -0,0 +1,176 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bind
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestBindQueryArrays(t *testing.T) {
+	type Item struct {
+		Name  string `query:"name" binding:"required"`
+		Count int    `query:"count"`
+	}
+	type ArrayInput struct {
+		Repeated []string `query:"r"`
+		Brackets []int    `query:"tags,brackets"`
+		Indexed  []string `query:"idx,indexed"`
+		Items    []Item   `query:"items,indexed"`
+	}
+
+	tests := []struct {
+		name    string
+		rawQS   string
+		want    ArrayInput
+		wantErr bool
+	}{
+		{
+			name:  "repeated keys",
+			rawQS: "r=a&r=b",
+			want:  ArrayInput{Repeated: []string{"a", "b"}},
+		},
+		{
+			name:  "brackets",
+			rawQS: "tags[]=1&tags[]=2&tags=3",
+			want:  ArrayInput{Brackets: []int{1, 2}},
+		},
+		{
+			name:    "brackets bad conversion",
+			rawQS:   "tags[]=x",
+			wantErr: true,
+		},
+		{
+			name:  "indexed scalars",
+			rawQS: "idx[1]=b&idx[0]=a",
+			want:  ArrayInput{Indexed: []string{"a", "b"}},
+		},
+		{
+			name:  "indexed with gap",
+			rawQS: "idx[2]=c",
+			want:  ArrayInput{Indexed: []string{"", "", "c"}},
+		},
+		{
+			name:  "indexed structs",
+			rawQS: "items[0].name=x&items[0].count=2&items[1].name=y",
+			want:  ArrayInput{Items: []Item{{Name: "x", Count: 2}, {Name: "y"}}},
+		},
+		{
+			name:    "indexed struct missing required",
+			rawQS:   "items[0].count=2",
+			wantErr: true,
+		},
+		{
+			name:    "indexed too large",
+			rawQS:   "idx[100000000]=a",
+			wantErr: true,
+		},
+		{
+			name:    "indexed negative",
+			rawQS:   "idx[-1]=a",
+			wantErr: true,
+		},
+		{
+			name:    "indexed malformed",
+			rawQS:   "idx[0=a",
+			wantErr: true,
+		},
+		{
+			name:    "indexed scalar with fields",
+			rawQS:   "idx[0].name=a",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &http.Request{URL: &url.URL{RawQuery: tt.rawQS}}
+			var got ArrayInput
+			err := BindQuery(r, &got)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBindFormNormalize(t *testing.T) {
+	type NormInput struct {
+		Email string   `form:"email,trim,lower"`
+		Code  *string  `form:"code,upper"`
+		Age   int      `form:"age,trim"`
+		Tags  []string `form:"tags,trim"`
+		Raw   string   `form:"raw"`
+	}
+
+	code := "ABC"
+	r := &http.Request{Form: url.Values{
+		"email": {"  Alice@Example.COM \t"},
+		"code":  {"abc"},
+		"age":   {" 42 "},
+		"tags":  {" a", "b "},
+		"raw":   {" as is "},
+	}}
+	var got NormInput
+	if err := BindForm(r, &got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := NormInput{Email: "alice@example.com", Code: &code, Age: 42, Tags: []string{"a", "b"}, Raw: " as is "}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestBindValues(t *testing.T) {
+	type Options struct {
+		Verbose bool   `opt:"verbose"`
+		Name    string `opt:"name" binding:"required"`
+	}
+
+	var got Options
+	if err := BindValues(url.Values{"verbose": {"true"}, "name": {"x"}}, "opt", &got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := (Options{Verbose: true, Name: "x"}); got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	if err := BindValues(url.Values{"verbose": {"true"}}, "opt", &got); err == nil {
+		t.Errorf("expected error for missing required field")
+	}
+}
+
+func TestBindHeader(t *testing.T) {
+	type HeaderInput struct {
+		Token   string `header:"x-api-token" binding:"required"`
+		Retries int    `header:"X-Retries"`
+		Accept  string // tagless
+	}
+
+	r := &http.Request{Header: http.Header{}}
+	r.Header.Set("X-Api-Token", "secret")
+	r.Header.Set("x-retries", "3")
+	r.Header.Set("Accept", "text/plain")
+
+	var got HeaderInput
+	if err := BindHeader(r, &got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := (HeaderInput{Token: "secret", Retries: 3, Accept: "text/plain"}); got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	if err := BindHeaderValues(http.Header{}, &got); err == nil {
+		t.Errorf("expected error for missing required header")
+	}
+}
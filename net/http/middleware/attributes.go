@@ -0,0 +1,114 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+)
+
+// A Key identifies a value of type T in the request attribute bag. See Attributes.
+//
+// Keys are compared by identity, so create them once, as package level vars.
+type Key[T any] struct {
+	name string
+}
+
+// Creates a new Key. The name is only used for debugging.
+func NewKey[T any](name string) *Key[T] {
+	return &Key[T]{name: name}
+}
+
+func (k *Key[T]) String() string {
+	return k.name
+}
+
+type attrBag struct {
+	mu     sync.Mutex
+	values map[any]any
+}
+
+// Attributes installs a per-request attribute bag, which lets handlers and middlewares share typed values
+// (e.g. the authenticated user, or a tenant), without each of them needing their own context key.
+//
+// For example:
+//
+//	var TenantKey = middleware.NewKey[*Tenant]("tenant")
+//
+//	// in a middleware, after Attributes
+//	middleware.Set(r, TenantKey, tenant)
+//
+//	// in a handler
+//	tenant, ok := middleware.Get(r, TenantKey)
+//
+// Because the bag is shared, values set by a middleware after calling the next handler are visible
+// to middlewares before it, once the next handler returns.
+func Attributes(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := attrsFrom(r.Context()); err == nil {
+			// Already installed further out; keep sharing that one.
+			next.ServeHTTP(w, r)
+			return
+		}
+		ctx := context.WithValue(r.Context(), attrsKey, &attrBag{values: map[any]any{}})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func attrsFrom(ctx context.Context) (*attrBag, error) {
+	if b, ok := ctx.Value(attrsKey).(*attrBag); ok {
+		return b, nil
+	}
+
+	// if this is hit, you are accessing attributes either too early (before the Attributes handler),
+	// or the Attributes handler isn't installed.
+	return nil, errors.New("attributes not found in request")
+}
+
+// Fetch the value for key from the request's attributes.
+// See Attributes.
+func Get[T any](r *http.Request, key *Key[T]) (T, bool) {
+	var zero T
+	b, err := attrsFrom(r.Context())
+	if err != nil {
+		return zero, false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	v, ok := b.values[key]
+	if !ok {
+		return zero, false
+	}
+	t, _ := v.(T) // a nil interface value (e.g. Set[error](r, key, nil)) doesn't assert
+	return t, true
+}
+
+// Stores value for key in the request's attributes, or error.
+// See Attributes.
+func Set[T any](r *http.Request, key *Key[T], value T) error {
+	b, err := attrsFrom(r.Context())
+	if err != nil {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.values[key] = value
+	return nil
+}
+
+// Removes key from the request's attributes, or error.
+// See Attributes.
+func Unset[T any](r *http.Request, key *Key[T]) error {
+	b, err := attrsFrom(r.Context())
+	if err != nil {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.values, key)
+	return nil
+}
@@ -0,0 +1,71 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAttributes(t *testing.T) {
+	userKey := NewKey[string]("user")
+	otherUserKey := NewKey[string]("user")
+	countKey := NewKey[int]("count")
+
+	var gotUser string
+	var gotOther, gotCount bool
+	setter := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := Set(r, userKey, "alice"); err != nil {
+				t.Fatalf("Set: %v", err)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+	handler := Attributes(setter(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotUser, _ = Get(r, userKey)
+		_, gotOther = Get(r, otherUserKey)
+		Set(r, countKey, 1)
+		Unset(r, countKey)
+		_, gotCount = Get(r, countKey)
+	})))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	if gotUser != "alice" {
+		t.Errorf("user = %q, want alice", gotUser)
+	}
+	if gotOther {
+		t.Errorf("distinct key with the same name found a value")
+	}
+	if gotCount {
+		t.Errorf("Unset value still present")
+	}
+}
+
+func TestAttributes_NotInstalled(t *testing.T) {
+	key := NewKey[int]("x")
+	r := httptest.NewRequest("GET", "/", nil)
+	if err := Set(r, key, 1); err == nil {
+		t.Errorf("expected error without Attributes")
+	}
+	if _, ok := Get(r, key); ok {
+		t.Errorf("expected no value without Attributes")
+	}
+}
+
+func TestAttributes_NilInterface(t *testing.T) {
+	key := NewKey[error]("err")
+	var got error
+	var ok bool
+	Attributes(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Set(r, key, nil)
+		got, ok = Get(r, key)
+	})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	if got != nil || !ok {
+		t.Errorf("Get = %v, %v, want nil, true", got, ok)
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// BasicAuth requires requests to carry HTTP basic auth credentials accepted by verify.
+//
+// verify should compare secrets in constant time (see crypto/subtle).
+// Unauthenticated requests get a 401 with a WWW-Authenticate challenge.
+// The user name of an authenticated request is available from Principal, and is logged by LogRequests.
+func BasicAuth(verify func(user, pass string) bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, pass, ok := r.BasicAuth()
+			if !ok || !verify(user, pass) {
+				w.Header().Set("WWW-Authenticate", `Basic realm="restricted", charset="UTF-8"`)
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+			setLogUser(w, user)
+			ctx := context.WithValue(r.Context(), principalKey, any(user))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// BearerAuth requires requests to carry an "Authorization: Bearer <token>" header accepted by verify.
+//
+// verify returns the principal (e.g. a user) the token belongs to, and whether the token is valid.
+// Unauthenticated requests get a 401 with a WWW-Authenticate challenge.
+// The principal of an authenticated request is available from Principal.
+// If it is a string or a fmt.Stringer, it is logged by LogRequests as the user.
+func BearerAuth(verify func(token string) (principal any, ok bool)) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			scheme, token, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+			var principal any
+			ok := strings.EqualFold(scheme, "Bearer") && token != ""
+			if ok {
+				principal, ok = verify(strings.TrimSpace(token))
+			}
+			if !ok {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+			setLogUser(w, principal)
+			ctx := context.WithValue(r.Context(), principalKey, principal)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// Fetch the authenticated principal associated with the request, or error.
+// See BasicAuth and BearerAuth.
+func Principal(r *http.Request) (any, error) {
+	if v := r.Context().Value(principalKey); v != nil {
+		return v, nil
+	}
+
+	// if this is hit, you are accessing the principal either too early (before the auth handler),
+	// or the auth handler isn't installed.
+	return nil, errors.New("principal not found in request")
+}
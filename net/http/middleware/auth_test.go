@@ -0,0 +1,111 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBasicAuth(t *testing.T) {
+	var principal any
+	handler := BasicAuth(func(user, pass string) bool {
+		return user == "alice" && pass == "secret"
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, err := Principal(r)
+		if err != nil {
+			t.Errorf("unexpected error fetching principal: %v", err)
+		}
+		principal = p
+	}))
+
+	tests := []struct {
+		name       string
+		user, pass string
+		setAuth    bool
+		wantStatus int
+	}{
+		{"no credentials", "", "", false, http.StatusUnauthorized},
+		{"wrong password", "alice", "nope", true, http.StatusUnauthorized},
+		{"correct", "alice", "secret", true, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal = nil
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.setAuth {
+				req.SetBasicAuth(tt.user, tt.pass)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusUnauthorized {
+				if w.Header().Get("WWW-Authenticate") == "" {
+					t.Errorf("missing WWW-Authenticate header")
+				}
+				return
+			}
+			if principal != tt.user {
+				t.Errorf("principal = %v, want %v", principal, tt.user)
+			}
+		})
+	}
+}
+
+func TestBearerAuth(t *testing.T) {
+	type user struct{ name string }
+
+	var principal any
+	handler := BearerAuth(func(token string) (any, bool) {
+		if token == "t0ken" {
+			return user{"bob"}, true
+		}
+		return nil, false
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, _ = Principal(r)
+	}))
+
+	tests := []struct {
+		name       string
+		header     string
+		wantStatus int
+	}{
+		{"no header", "", http.StatusUnauthorized},
+		{"wrong scheme", "Basic t0ken", http.StatusUnauthorized},
+		{"empty token", "Bearer ", http.StatusUnauthorized},
+		{"wrong token", "Bearer nope", http.StatusUnauthorized},
+		{"correct", "Bearer t0ken", http.StatusOK},
+		{"case insensitive scheme", "bearer t0ken", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal = nil
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusOK && principal != (user{"bob"}) {
+				t.Errorf("principal = %v", principal)
+			}
+		})
+	}
+}
+
+func TestPrincipal_Missing(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	if _, err := Principal(req); err == nil {
+		t.Errorf("expected error without auth middleware")
+	}
+}
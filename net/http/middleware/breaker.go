@@ -0,0 +1,200 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Returned by CircuitBreaker.Transport when the circuit is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// The state of a CircuitBreaker.
+type BreakerState int
+
+const (
+	// Requests are passed through, and failures counted.
+	BreakerClosed BreakerState = iota
+	// Requests are rejected immediately.
+	BreakerOpen
+	// A single probe request is passed through, to find out if the upstream has recovered.
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+	return "BreakerState(" + strconv.Itoa(int(s)) + ")"
+}
+
+// Configures NewCircuitBreaker.
+type BreakerOptions struct {
+	// The number of consecutive failures which opens the circuit. If zero, 5 is used.
+	Threshold int
+
+	// How long the circuit stays open before a probe is let through. If zero, 30 seconds is used.
+	Cooldown time.Duration
+
+	// Reports whether a response status counts as a failure.
+	// If nil, 5xx statuses are failures.
+	IsFailure func(status int) bool
+}
+
+// A CircuitBreaker stops sending requests to a failing upstream (a handler, or a proxy target),
+// so that clients get a fast 503 rather than waiting on something that won't work.
+//
+// After Threshold consecutive failures, the circuit opens, and everything is rejected.
+// After Cooldown, one probe request is let through: if it succeeds, the circuit closes again,
+// otherwise it stays open for another Cooldown.
+//
+// A CircuitBreaker can wrap a handler (see Handler), or a http.RoundTripper (see Transport).
+type CircuitBreaker struct {
+	opts BreakerOptions
+	now  func() time.Time
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// Creates a closed CircuitBreaker.
+func NewCircuitBreaker(opts BreakerOptions) *CircuitBreaker {
+	if opts.Threshold <= 0 {
+		opts.Threshold = 5
+	}
+	if opts.Cooldown <= 0 {
+		opts.Cooldown = 30 * time.Second
+	}
+	if opts.IsFailure == nil {
+		opts.IsFailure = func(status int) bool { return status >= 500 }
+	}
+	return &CircuitBreaker{opts: opts, now: time.Now}
+}
+
+// Returns the current state.
+func (cb *CircuitBreaker) State() BreakerState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	if cb.state == BreakerOpen && cb.now().Sub(cb.openedAt) >= cb.opts.Cooldown {
+		return BreakerHalfOpen
+	}
+	return cb.state
+}
+
+// Reports whether a request may go ahead, and whether it is the probe of a half-open circuit.
+// If allowed, done must be called with probe and the outcome.
+func (cb *CircuitBreaker) allow() (allowed, probe bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	switch cb.state {
+	case BreakerClosed:
+		return true, false
+	case BreakerOpen:
+		if cb.now().Sub(cb.openedAt) < cb.opts.Cooldown {
+			return false, false
+		}
+		cb.state = BreakerHalfOpen
+	}
+	if cb.probing {
+		return false, false
+	}
+	cb.probing = true
+	return true, true
+}
+
+// Records the outcome of a request allowed by allow.
+//
+// Only the probe decides what happens to a half-open circuit: requests let in earlier,
+// while it was closed, may finish at any time, and are ignored once it isn't closed.
+func (cb *CircuitBreaker) done(probe, ok bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	if probe {
+		cb.probing = false
+		if ok {
+			cb.state = BreakerClosed
+			cb.failures = 0
+		} else {
+			cb.state = BreakerOpen
+			cb.openedAt = cb.now()
+		}
+		return
+	}
+	if cb.state != BreakerClosed {
+		return
+	}
+
+	if ok {
+		cb.failures = 0
+		return
+	}
+	cb.failures++
+	if cb.failures >= cb.opts.Threshold {
+		log.Warn("Circuit breaker opened", "failures", cb.failures)
+		cb.state = BreakerOpen
+		cb.openedAt = cb.now()
+	}
+}
+
+// Returns the seconds to put in Retry-After.
+func (cb *CircuitBreaker) retryAfter() string {
+	return strconv.Itoa(int(math.Ceil(cb.opts.Cooldown.Seconds())))
+}
+
+// Handler returns a middleware which passes requests to next while the circuit is closed,
+// and returns a 503 while it is open. Failures are decided by the response status.
+func (cb *CircuitBreaker) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		allowed, probe := cb.allow()
+		if !allowed {
+			w.Header().Set("Retry-After", cb.retryAfter())
+			http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+			return
+		}
+
+		w, recw := withRecorder(w)
+		defer func() {
+			if p := recover(); p != nil {
+				cb.done(probe, false)
+				panic(p)
+			}
+		}()
+		next.ServeHTTP(w, r)
+		cb.done(probe, !cb.opts.IsFailure(recw.status))
+	})
+}
+
+// Transport returns a http.RoundTripper which sends requests with base (or http.DefaultTransport, if nil)
+// while the circuit is closed, and fails with ErrCircuitOpen while it is open.
+// Transport errors and failing statuses both count as failures.
+//
+// This is useful with httputil.ReverseProxy, whose ErrorHandler turns the error into a 502.
+func (cb *CircuitBreaker) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		allowed, probe := cb.allow()
+		if !allowed {
+			return nil, ErrCircuitOpen
+		}
+		resp, err := base.RoundTrip(req)
+		cb.done(probe, err == nil && !cb.opts.IsFailure(resp.StatusCode))
+		return resp, err
+	})
+}
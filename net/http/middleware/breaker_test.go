@@ -0,0 +1,137 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Unix(0, 0)
+	cb := NewCircuitBreaker(BreakerOptions{Threshold: 2, Cooldown: time.Minute})
+	cb.now = func() time.Time { return now }
+
+	status := http.StatusInternalServerError
+	calls := 0
+	handler := cb.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(status)
+	}))
+	do := func() int {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+		return w.Code
+	}
+
+	do()
+	if cb.State() != BreakerClosed {
+		t.Fatalf("opened after one failure")
+	}
+	do()
+	if cb.State() != BreakerOpen {
+		t.Fatalf("state = %v, want open", cb.State())
+	}
+
+	// Open: rejected without calling the handler
+	if code := do(); code != http.StatusServiceUnavailable || calls != 2 {
+		t.Fatalf("code = %d, calls = %d", code, calls)
+	}
+
+	// After cooldown, a failed probe reopens
+	now = now.Add(time.Minute)
+	if cb.State() != BreakerHalfOpen {
+		t.Fatalf("state = %v, want half-open", cb.State())
+	}
+	do()
+	if calls != 3 || cb.State() != BreakerOpen {
+		t.Fatalf("failed probe: calls = %d, state = %v", calls, cb.State())
+	}
+
+	// A successful probe closes
+	now = now.Add(time.Minute)
+	status = http.StatusOK
+	if code := do(); code != http.StatusOK || cb.State() != BreakerClosed {
+		t.Fatalf("successful probe: code = %d, state = %v", code, cb.State())
+	}
+}
+
+func TestCircuitBreaker_SlowRequestDuringProbe(t *testing.T) {
+	now := time.Unix(0, 0)
+	cb := NewCircuitBreaker(BreakerOptions{Threshold: 1, Cooldown: time.Minute})
+	cb.now = func() time.Time { return now }
+
+	// A slow request is let in while closed, then another fails and opens the circuit.
+	if allowed, probe := cb.allow(); !allowed || probe {
+		t.Fatalf("slow: allowed = %v, probe = %v", allowed, probe)
+	}
+	if allowed, probe := cb.allow(); !allowed || probe {
+		t.Fatalf("failing: allowed = %v, probe = %v", allowed, probe)
+	}
+	cb.done(false, false)
+	if cb.State() != BreakerOpen {
+		t.Fatalf("state = %v, want open", cb.State())
+	}
+
+	// After the cooldown, the probe goes out, and the slow request succeeds before it.
+	now = now.Add(time.Minute)
+	if allowed, probe := cb.allow(); !allowed || !probe {
+		t.Fatalf("probe: allowed = %v, probe = %v", allowed, probe)
+	}
+	cb.done(false, true)
+	if cb.State() != BreakerHalfOpen {
+		t.Fatalf("slow request decided the probe: state = %v", cb.State())
+	}
+	if allowed, _ := cb.allow(); allowed {
+		t.Fatalf("a second probe was allowed")
+	}
+
+	// The probe's own outcome is what counts.
+	cb.done(true, false)
+	if cb.State() != BreakerOpen {
+		t.Fatalf("failed probe: state = %v, want open", cb.State())
+	}
+}
+
+func TestCircuitBreakerTransport(t *testing.T) {
+	cb := NewCircuitBreaker(BreakerOptions{Threshold: 1})
+	failing := roundTripperFunc(func(*http.Request) (*http.Response, error) {
+		return nil, errors.New("connection refused")
+	})
+	client := &http.Client{Transport: cb.Transport(failing)}
+
+	if _, err := client.Get("http://upstream.invalid/"); err == nil {
+		t.Fatalf("expected error")
+	}
+	if _, err := client.Get("http://upstream.invalid/"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("err = %v, want ErrCircuitOpen", err)
+	}
+}
+
+func TestCircuitBreaker_InsideLogRequests(t *testing.T) {
+	var buf bytes.Buffer
+	cb := NewCircuitBreaker(BreakerOptions{Threshold: 1, Cooldown: time.Minute})
+	handler := LogRequestsWith(LogOptions{Logger: slog.New(slog.NewTextHandler(&buf, nil))})(
+		cb.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if status, _, ok := ResponseStatus(w); !ok || status != http.StatusOK {
+				t.Errorf("ResponseStatus = %d, %v", status, ok)
+			}
+			SkipLogging(w)
+			w.WriteHeader(http.StatusInternalServerError)
+		})))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	if got := buf.String(); got != "" {
+		t.Errorf("SkipLogging was ignored, got:\n%s", got)
+	}
+	if got := cb.State(); got != BreakerOpen {
+		t.Errorf("State = %v, want open", got)
+	}
+}
@@ -0,0 +1,247 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"bytes"
+	"container/list"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Configures NewCache.
+type CacheOptions struct {
+	// How long responses are cached for. If zero, one minute is used.
+	TTL time.Duration
+
+	// The most responses kept at once; the least recently used are evicted first.
+	// If zero, 1000 is used.
+	MaxEntries int
+
+	// The largest response body that is cached. If zero, 1MB is used.
+	MaxBodySize int
+
+	// Request headers which change the response (e.g. Accept-Language),
+	// and so are included in the cache key.
+	Vary []string
+}
+
+// A Cache is an in-memory response cache, for expensive read-mostly endpoints.
+//
+// Only successful (200) GET and HEAD responses are cached. Requests with an Authorization header,
+// and responses that set cookies, or have Cache-Control of private or no-store, are never cached.
+//
+// For example:
+//
+//	cache := middleware.NewCache(middleware.CacheOptions{TTL: 5 * time.Minute})
+//	mux.Handle("/reports/", cache.Handler(reportsHandler))
+//
+//	// later, when the data changes:
+//	cache.InvalidatePrefix("/reports/")
+type Cache struct {
+	opts CacheOptions
+
+	mu      sync.Mutex
+	entries map[string]*list.Element // of *cacheEntry
+	lru     *list.List               // most recently used at the front
+}
+
+type cacheEntry struct {
+	key     string
+	path    string
+	status  int
+	header  http.Header
+	body    []byte
+	created time.Time
+}
+
+// Creates an empty Cache.
+func NewCache(opts CacheOptions) *Cache {
+	if opts.TTL <= 0 {
+		opts.TTL = time.Minute
+	}
+	if opts.MaxEntries <= 0 {
+		opts.MaxEntries = 1000
+	}
+	if opts.MaxBodySize <= 0 {
+		opts.MaxBodySize = 1 << 20
+	}
+	return &Cache{opts: opts, entries: map[string]*list.Element{}, lru: list.New()}
+}
+
+// Returns the cache key for r.
+func (c *Cache) key(r *http.Request) string {
+	var b strings.Builder
+	b.WriteString(r.Method)
+	b.WriteByte(' ')
+	b.WriteString(r.URL.RequestURI())
+	for _, h := range c.opts.Vary {
+		b.WriteByte('\n')
+		b.WriteString(strings.Join(r.Header.Values(h), ","))
+	}
+	return b.String()
+}
+
+func (c *Cache) get(key string) *cacheEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+	e := el.Value.(*cacheEntry)
+	if time.Since(e.created) > c.opts.TTL {
+		c.lru.Remove(el)
+		delete(c.entries, key)
+		return nil
+	}
+	c.lru.MoveToFront(el)
+	return e
+}
+
+func (c *Cache) put(e *cacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[e.key]; ok {
+		c.lru.Remove(el)
+	}
+	c.entries[e.key] = c.lru.PushFront(e)
+	for c.lru.Len() > c.opts.MaxEntries {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// Removes cached responses for which match returns true.
+func (c *Cache) invalidate(match func(path string) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, el := range c.entries {
+		if match(el.Value.(*cacheEntry).path) {
+			c.lru.Remove(el)
+			delete(c.entries, key)
+		}
+	}
+}
+
+// Removes all cached responses for path (with any query string).
+func (c *Cache) Invalidate(path string) {
+	c.invalidate(func(p string) bool { return p == path })
+}
+
+// Removes all cached responses for paths starting with prefix.
+func (c *Cache) InvalidatePrefix(prefix string) {
+	c.invalidate(func(p string) bool { return strings.HasPrefix(p, prefix) })
+}
+
+// Removes all cached responses.
+func (c *Cache) Purge() {
+	c.invalidate(func(string) bool { return true })
+}
+
+// Returns the number of cached responses.
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// Handler returns a middleware serving responses for next from the cache.
+func (c *Cache) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if (r.Method != http.MethodGet && r.Method != http.MethodHead) || r.Header.Get("Authorization") != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key := c.key(r)
+		if e := c.get(key); e != nil {
+			h := w.Header()
+			for k, v := range e.header {
+				h[k] = slices.Clone(v)
+			}
+			h.Set("Age", strconv.Itoa(int(time.Since(e.created).Seconds())))
+			w.WriteHeader(e.status)
+			w.Write(e.body)
+			return
+		}
+
+		// Headers set by outer middleware (e.g. X-Request-ID) belong to this request, not the response,
+		// so only those that next sets are cached.
+		before := w.Header().Clone()
+		w, recw := withRecorder(w)
+		cw := &cacheWriter{ResponseWriter: w, limit: c.opts.MaxBodySize}
+		next.ServeHTTP(cw, r)
+
+		header := addedHeaders(before, w.Header())
+		if recw.status != http.StatusOK || cw.tooBig || !cacheable(header) {
+			return
+		}
+		c.put(&cacheEntry{
+			key:     key,
+			path:    r.URL.Path,
+			status:  recw.status,
+			header:  header,
+			body:    cw.buf.Bytes(),
+			created: time.Now(),
+		})
+	})
+}
+
+// Headers which are never cached: those which are per-request, or per-connection (hop-by-hop).
+var uncachedHeaders = []string{
+	RequestIDHeader, "Age", "Connection", "Keep-Alive", "Proxy-Authenticate", "Proxy-Authorization",
+	"Te", "Trailer", "Transfer-Encoding", "Upgrade",
+}
+
+// Returns a copy of the headers in after which were added or changed since before.
+func addedHeaders(before, after http.Header) http.Header {
+	h := http.Header{}
+	for k, v := range after {
+		if slices.Equal(before[k], v) || slices.Contains(uncachedHeaders, k) {
+			continue
+		}
+		h[k] = slices.Clone(v)
+	}
+	return h
+}
+
+// Returns true if a response with header h may be cached.
+func cacheable(h http.Header) bool {
+	if len(h.Values("Set-Cookie")) > 0 {
+		return false
+	}
+	cc := strings.ToLower(h.Get("Cache-Control"))
+	return !strings.Contains(cc, "no-store") && !strings.Contains(cc, "private")
+}
+
+// cacheWriter keeps a copy of the response as it is written, so that it can be cached.
+type cacheWriter struct {
+	http.ResponseWriter
+	buf    bytes.Buffer
+	limit  int
+	tooBig bool
+}
+
+func (w *cacheWriter) Write(b []byte) (int, error) {
+	if !w.tooBig {
+		if w.buf.Len()+len(b) > w.limit {
+			w.tooBig = true
+			w.buf = bytes.Buffer{}
+		} else {
+			w.buf.Write(b)
+		}
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *cacheWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,148 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCache(t *testing.T) {
+	calls := 0
+	cache := NewCache(CacheOptions{TTL: time.Hour, MaxEntries: 2, Vary: []string{"Accept-Language"}})
+	handler := cache.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		switch r.URL.Path {
+		case "/private":
+			w.Header().Set("Cache-Control", "private")
+		case "/cookie":
+			http.SetCookie(w, &http.Cookie{Name: "a", Value: "b"})
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		}
+		w.Header().Set("X-Lang", r.Header.Get("Accept-Language"))
+		fmt.Fprintf(w, "call %d", calls)
+	}))
+
+	do := func(method, target, lang string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		if lang != "" {
+			req.Header.Set("Accept-Language", lang)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	first := do("GET", "/a", "")
+	second := do("GET", "/a", "")
+	if calls != 1 || second.Body.String() != first.Body.String() {
+		t.Fatalf("expected cached response, calls = %d, body = %q", calls, second.Body.String())
+	}
+	if second.Header().Get("Age") == "" {
+		t.Errorf("expected Age header on cached response")
+	}
+
+	// Vary header makes a different entry
+	if w := do("GET", "/a", "fr"); w.Header().Get("X-Lang") != "fr" || calls != 2 {
+		t.Errorf("Vary not respected: calls = %d, lang = %q", calls, w.Header().Get("X-Lang"))
+	}
+
+	// Uncacheable responses and requests
+	for _, tt := range []struct{ method, target string }{
+		{"GET", "/private"},
+		{"GET", "/cookie"},
+		{"GET", "/missing"},
+		{"POST", "/a"},
+	} {
+		before := calls
+		do(tt.method, tt.target, "")
+		do(tt.method, tt.target, "")
+		if calls != before+2 {
+			t.Errorf("%s %s was cached", tt.method, tt.target)
+		}
+	}
+
+	// Invalidation
+	cache.Invalidate("/a")
+	before := calls
+	do("GET", "/a", "")
+	if calls != before+1 {
+		t.Errorf("Invalidate did not remove entry")
+	}
+
+	// Eviction: MaxEntries is 2
+	do("GET", "/b", "")
+	do("GET", "/c", "")
+	if cache.Len() != 2 {
+		t.Errorf("Len = %d, want 2", cache.Len())
+	}
+
+	cache.Purge()
+	if cache.Len() != 0 {
+		t.Errorf("Len = %d after Purge", cache.Len())
+	}
+}
+
+func TestCache_TTL(t *testing.T) {
+	calls := 0
+	cache := NewCache(CacheOptions{TTL: time.Millisecond})
+	handler := cache.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	time.Sleep(5 * time.Millisecond)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	if calls != 2 {
+		t.Errorf("calls = %d, want 2 after TTL expiry", calls)
+	}
+}
+
+func TestCache_OuterHeaders(t *testing.T) {
+	calls := 0
+	cache := NewCache(CacheOptions{TTL: time.Hour})
+	handler := TagWithRequestID(cache.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Add("X-Tags", "a")
+		w.Header().Set("Connection", "close")
+		fmt.Fprint(w, "body")
+	})))
+
+	get := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.AddCookie(&http.Cookie{Name: "cid", Value: "37f279"})
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	var ids []string
+	for range 2 {
+		w := get()
+		ids = append(ids, w.Header().Get(RequestIDHeader))
+		if got := w.Header().Values("X-Tags"); len(got) != 1 || got[0] != "a" {
+			t.Errorf("X-Tags = %q", got)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("expected the second response to be cached, calls = %d", calls)
+	}
+	if ids[0] == "" || ids[0] == ids[1] {
+		t.Errorf("request IDs = %q, want distinct", ids)
+	}
+
+	// The cached header isn't aliased by responses.
+	get().Header()["X-Tags"][0] = "changed"
+	w := get()
+	if got := w.Header().Get("X-Tags"); got != "a" {
+		t.Errorf("X-Tags after modifying a response = %q", got)
+	}
+	if got := w.Header().Get("Connection"); got != "" {
+		t.Errorf("hop-by-hop header replayed: %q", got)
+	}
+}
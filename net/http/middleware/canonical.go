@@ -0,0 +1,104 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Whether hosts should start with "www.". See CanonicalOptions.
+type WWWPolicy int
+
+const (
+	// Leave hosts alone.
+	WWWIgnore WWWPolicy = iota
+	// Redirect www.example.com to example.com.
+	WWWStrip
+	// Redirect example.com to www.example.com.
+	WWWAdd
+)
+
+// Whether paths should end with "/". See CanonicalOptions.
+type SlashPolicy int
+
+const (
+	// Leave paths alone.
+	SlashIgnore SlashPolicy = iota
+	// Redirect /foo/ to /foo.
+	SlashStrip
+	// Redirect /foo to /foo/.
+	SlashAdd
+)
+
+// Configures Canonical.
+type CanonicalOptions struct {
+	// Redirects http requests to https.
+	HTTPS bool
+
+	// Whether to add or remove "www." from hosts.
+	WWW WWWPolicy
+
+	// Whether to add or remove trailing slashes from paths. The root path is never changed.
+	TrailingSlash SlashPolicy
+}
+
+// Canonical returns a middleware which redirects requests to a canonical URL, as configured by opts,
+// so that each page is only reachable at one address.
+//
+// The scheme and host are those the client used (see ClientScheme and ClientHost),
+// so it works behind a trusted proxy terminating TLS.
+//
+// GET and HEAD requests are redirected with a 301; other methods with a 308, so that the method
+// and body are kept.
+func Canonical(opts CanonicalOptions) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			scheme := ClientScheme(r)
+			host := ClientHost(r)
+			path := r.URL.Path
+
+			if opts.HTTPS {
+				scheme = "https"
+			}
+
+			hasWWW := len(host) > 4 && strings.EqualFold(host[:4], "www.")
+			switch {
+			case opts.WWW == WWWStrip && hasWWW:
+				host = host[4:]
+			case opts.WWW == WWWAdd && !hasWWW && host != "":
+				host = "www." + host
+			}
+
+			if path != "/" && path != "" {
+				hasSlash := strings.HasSuffix(path, "/")
+				switch {
+				case opts.TrailingSlash == SlashStrip && hasSlash:
+					path = strings.TrimRight(path, "/")
+					if path == "" {
+						path = "/"
+					}
+				case opts.TrailingSlash == SlashAdd && !hasSlash:
+					path += "/"
+				}
+			}
+
+			if scheme == ClientScheme(r) && host == ClientHost(r) && path == r.URL.Path {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			target := scheme + "://" + host + path
+			if r.URL.RawQuery != "" {
+				target += "?" + r.URL.RawQuery
+			}
+			code := http.StatusPermanentRedirect
+			if r.Method == http.MethodGet || r.Method == http.MethodHead {
+				code = http.StatusMovedPermanently
+			}
+			http.Redirect(w, r, target, code)
+		})
+	}
+}
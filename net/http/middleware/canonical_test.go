@@ -0,0 +1,66 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCanonical(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	tests := []struct {
+		name     string
+		opts     CanonicalOptions
+		method   string
+		target   string
+		header   map[string]string
+		wantCode int
+		wantLoc  string
+	}{
+		{"no options", CanonicalOptions{}, "GET", "http://www.example.com/a/", nil, 200, ""},
+		{"https", CanonicalOptions{HTTPS: true}, "GET", "http://example.com/a?x=1", nil, 301, "https://example.com/a?x=1"},
+		{"https already", CanonicalOptions{HTTPS: true}, "GET", "https://example.com/a", nil, 200, ""},
+		{"https behind proxy", CanonicalOptions{HTTPS: true}, "GET", "http://example.com/a",
+			map[string]string{"X-Forwarded-Proto": "https"}, 200, ""},
+		{"strip www", CanonicalOptions{WWW: WWWStrip}, "GET", "http://www.example.com/", nil, 301, "http://example.com/"},
+		{"add www", CanonicalOptions{WWW: WWWAdd}, "GET", "http://example.com/", nil, 301, "http://www.example.com/"},
+		{"add www already", CanonicalOptions{WWW: WWWAdd}, "GET", "http://WWW.example.com/", nil, 200, ""},
+		{"strip slash", CanonicalOptions{TrailingSlash: SlashStrip}, "GET", "http://example.com/a//", nil, 301, "http://example.com/a"},
+		{"strip slash root", CanonicalOptions{TrailingSlash: SlashStrip}, "GET", "http://example.com/", nil, 200, ""},
+		{"add slash", CanonicalOptions{TrailingSlash: SlashAdd}, "GET", "http://example.com/a", nil, 301, "http://example.com/a/"},
+		{"post keeps method", CanonicalOptions{HTTPS: true}, "POST", "http://example.com/a", nil, 308, "https://example.com/a"},
+		{"combined", CanonicalOptions{HTTPS: true, WWW: WWWStrip, TrailingSlash: SlashStrip}, "GET", "http://www.example.com/a/", nil, 301, "https://example.com/a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			req.RemoteAddr = "127.0.0.1:1234"
+			req.URL.Scheme, req.URL.Host = "", "" // as a server would see it
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			Canonical(tt.opts)(ok).ServeHTTP(w, req)
+			if w.Code != tt.wantCode {
+				t.Fatalf("code = %d, want %d", w.Code, tt.wantCode)
+			}
+			if loc := w.Header().Get("Location"); loc != tt.wantLoc {
+				t.Errorf("Location = %q, want %q", loc, tt.wantLoc)
+			}
+		})
+	}
+	// An absolute-form request line doesn't make a plain http request look like https.
+	req := httptest.NewRequest("GET", "/a", nil)
+	req.URL.Scheme, req.URL.Host = "https", "example.com"
+	w := httptest.NewRecorder()
+	Canonical(CanonicalOptions{HTTPS: true})(ok).ServeHTTP(w, req)
+	if w.Code != http.StatusMovedPermanently {
+		t.Errorf("absolute-form https: code = %d, want 301", w.Code)
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import "net/http"
+
+// A Chain is an ordered stack of middleware, which can be built once and reused.
+//
+// The first middleware in the chain is the outermost, i.e. it sees the request first.
+// For example:
+//
+//	common := middleware.Chain{middleware.TagWithRequestID, middleware.LogRequests}
+//	authed := common.Append(middleware.BasicAuth(verify))
+//
+//	mux.Handle("/", common.Then(publicHandler))
+//	mux.Handle("/admin/", authed.Then(adminHandler))
+type Chain []func(http.Handler) http.Handler
+
+// Returns a new Chain with mw added to the end (i.e. inside) of c. c is not modified.
+func (c Chain) Append(mw ...func(http.Handler) http.Handler) Chain {
+	out := make(Chain, 0, len(c)+len(mw))
+	out = append(out, c...)
+	return append(out, mw...)
+}
+
+// Returns a new Chain with all of other added to the end (i.e. inside) of c. c is not modified.
+func (c Chain) Extend(other Chain) Chain {
+	return c.Append(other...)
+}
+
+// Wraps h in the chain's middleware, and returns the result.
+// If h is nil, http.DefaultServeMux is used.
+func (c Chain) Then(h http.Handler) http.Handler {
+	if h == nil {
+		h = http.DefaultServeMux
+	}
+	for i := len(c) - 1; i >= 0; i-- {
+		h = c[i](h)
+	}
+	return h
+}
+
+// The same as Then, for a http.HandlerFunc.
+func (c Chain) ThenFunc(fn http.HandlerFunc) http.Handler {
+	if fn == nil {
+		return c.Then(nil)
+	}
+	return c.Then(fn)
+}
@@ -0,0 +1,81 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestCSRF(t *testing.T) {
+	var field string
+	handler := CSRF(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		field = string(CSRFField(r))
+	}))
+
+	// A safe request issues a token.
+	req := httptest.NewRequest("GET", "/", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET status = %d", w.Code)
+	}
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != "csrf" {
+		t.Fatalf("expected csrf cookie, got %v", cookies)
+	}
+	token := cookies[0].Value
+	if !strings.Contains(field, token) {
+		t.Errorf("field %q does not contain token", field)
+	}
+
+	tests := []struct {
+		name       string
+		cookie     string
+		header     string
+		form       string
+		wantStatus int
+	}{
+		{"no token", token, "", "", http.StatusForbidden},
+		{"no cookie", "", token, "", http.StatusForbidden},
+		{"wrong header", token, strings.Repeat("0", 64), "", http.StatusForbidden},
+		{"header", token, token, "", http.StatusOK},
+		{"form", token, "", token, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := url.Values{}
+			if tt.form != "" {
+				body.Set("csrf_token", tt.form)
+			}
+			req := httptest.NewRequest("POST", "/", strings.NewReader(body.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: "csrf", Value: tt.cookie})
+			}
+			if tt.header != "" {
+				req.Header.Set("X-CSRF-Token", tt.header)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestCSRFToken_Missing(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	if _, err := CSRFToken(req); err == nil {
+		t.Errorf("expected error without CSRF middleware")
+	}
+	if CSRFField(req) != "" {
+		t.Errorf("expected empty field without CSRF middleware")
+	}
+}
@@ -0,0 +1,155 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// Configures DebugBodies.
+type DebugOptions struct {
+	// The logger to write to. Bodies are only captured when it has slog.LevelDebug enabled,
+	// so a slogx category can be used to switch capture on and off.
+	// If nil, the "http" category is used.
+	Logger *slog.Logger
+
+	// The most bytes of each body that are logged. If zero, 4096 is used.
+	MaxBodySize int
+
+	// If set, called on each captured body before it is logged, to remove secrets.
+	// See RedactFields.
+	Redact func(body []byte) []byte
+}
+
+// DebugBodies returns a middleware which logs (truncated) request and response bodies, at debug level,
+// to help track down malformed payloads.
+//
+// Only the part of the request body that the handler actually reads is logged.
+//
+// For example:
+//
+//	var bodies = slogx.NewCategory("http.bodies", slogx.TextHandler, slog.LevelDebug)
+//	handler = middleware.DebugBodies(middleware.DebugOptions{
+//	    Logger: bodies,
+//	    Redact: middleware.RedactFields("password", "token"),
+//	})(handler)
+func DebugBodies(opts DebugOptions) func(http.Handler) http.Handler {
+	logger := opts.Logger
+	if logger == nil {
+		logger = log
+	}
+	limit := opts.MaxBodySize
+	if limit <= 0 {
+		limit = 4096
+	}
+	redact := opts.Redact
+	if redact == nil {
+		redact = func(b []byte) []byte { return b }
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !logger.Enabled(r.Context(), slog.LevelDebug) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			reqBody := &limitedBuffer{limit: limit}
+			if r.Body != nil && r.Body != http.NoBody {
+				// Don't touch the caller's request.
+				r = r.Clone(r.Context())
+				r.Body = &teeBody{ReadCloser: r.Body, w: reqBody}
+			}
+			w, recw := withRecorder(w)
+			dw := &debugWriter{ResponseWriter: w, body: limitedBuffer{limit: limit}}
+			next.ServeHTTP(dw, r)
+
+			logger.LogAttrs(r.Context(), slog.LevelDebug, "Bodies",
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.Int("status", recw.status),
+				slog.String("request", reqBody.String(redact)),
+				slog.String("response", dw.body.String(redact)),
+			)
+		})
+	}
+}
+
+// RedactFields returns a redaction function for DebugOptions.Redact, which replaces the values of
+// the named fields in JSON ("name": "value") and form-encoded (name=value) bodies with [REDACTED].
+//
+// It works on truncated bodies too, but only on string values.
+func RedactFields(names ...string) func(body []byte) []byte {
+	quoted := make([]string, len(names))
+	for i, n := range names {
+		quoted[i] = regexp.QuoteMeta(n)
+	}
+	alt := strings.Join(quoted, "|")
+	jsonRe := regexp.MustCompile(`("(?:` + alt + `)"\s*:\s*)"(?:[^"\\]|\\.)*"?`)
+	formRe := regexp.MustCompile(`((?:^|&)(?:` + alt + `)=)[^&]*`)
+
+	return func(body []byte) []byte {
+		body = jsonRe.ReplaceAll(body, []byte(`$1"[REDACTED]"`))
+		return formRe.ReplaceAll(body, []byte(`${1}[REDACTED]`))
+	}
+}
+
+// limitedBuffer keeps the first limit bytes written to it, and remembers if there were more.
+type limitedBuffer struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.buf.Len(); len(p) > room {
+		b.buf.Write(p[:room])
+		b.truncated = true
+	} else {
+		b.buf.Write(p)
+	}
+	return len(p), nil
+}
+
+// Returns the redacted contents, marking truncation.
+func (b *limitedBuffer) String(redact func([]byte) []byte) string {
+	s := string(redact(b.buf.Bytes()))
+	if b.truncated {
+		s += "...(truncated)"
+	}
+	return s
+}
+
+// teeBody copies what is read from a request body to w.
+type teeBody struct {
+	io.ReadCloser
+	w io.Writer
+}
+
+func (t *teeBody) Read(p []byte) (int, error) {
+	n, err := t.ReadCloser.Read(p)
+	t.w.Write(p[:n])
+	return n, err
+}
+
+// debugWriter keeps the start of a response body.
+type debugWriter struct {
+	http.ResponseWriter
+	body limitedBuffer
+}
+
+func (w *debugWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *debugWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
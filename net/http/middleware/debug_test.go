@@ -0,0 +1,76 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDebugBodies(t *testing.T) {
+	echo := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusCreated)
+		w.Write(b)
+	})
+
+	var out bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&out, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	handler := DebugBodies(DebugOptions{Logger: logger, MaxBodySize: 40, Redact: RedactFields("password")})(echo)
+
+	body := `{"user":"bob","password":"hunter2","padding":"xxxxxxxxxxxxxxxx"}`
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/login", strings.NewReader(body))
+	reqBody := req.Body
+	handler.ServeHTTP(w, req)
+
+	if req.Body != reqBody {
+		t.Errorf("caller's request body was replaced")
+	}
+
+	if w.Body.String() != body {
+		t.Errorf("body not passed through: %q", w.Body.String())
+	}
+	got := out.String()
+	if strings.Contains(got, "hunter2") {
+		t.Errorf("secret was logged: %s", got)
+	}
+	for _, want := range []string{"[REDACTED]", "(truncated)", "status=201", "path=/login"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("log missing %q: %s", want, got)
+		}
+	}
+
+	// Disabled at debug level, nothing is captured
+	out.Reset()
+	quiet := slog.New(slog.NewTextHandler(&out, &slog.HandlerOptions{Level: slog.LevelInfo}))
+	DebugBodies(DebugOptions{Logger: quiet})(echo).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/", strings.NewReader("x")))
+	if out.Len() != 0 {
+		t.Errorf("logged while disabled: %s", out.String())
+	}
+}
+
+func TestRedactFields(t *testing.T) {
+	redact := RedactFields("password", "api.key")
+	tests := []struct {
+		in, want string
+	}{
+		{`{"password": "a\"b", "x": "y"}`, `{"password": "[REDACTED]", "x": "y"}`},
+		{`{"password":"trunc`, `{"password":"[REDACTED]"`},
+		{`user=bob&password=secret&x=1`, `user=bob&password=[REDACTED]&x=1`},
+		{`api.key=abc`, `api.key=[REDACTED]`},
+		{`apixkey=abc`, `apixkey=abc`},
+	}
+	for _, tt := range tests {
+		if got := string(redact([]byte(tt.in))); got != tt.want {
+			t.Errorf("redact(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
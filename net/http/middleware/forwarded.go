@@ -0,0 +1,150 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// One element of a Forwarded header, mapping (lowercased) parameter names to values.
+type forwardedElement map[string]string
+
+// Parses the values of Forwarded headers (RFC 7239), e.g:
+//
+//	for=192.0.2.60;proto=http;by=203.0.113.43, for="[2001:db8:cafe::17]:4711"
+//
+// Elements are returned in order, i.e. the first element was added by the proxy nearest the client.
+// Malformed elements are skipped.
+func parseForwarded(values []string) []forwardedElement {
+	var out []forwardedElement
+	for _, v := range values {
+		for len(v) > 0 {
+			elem := forwardedElement{}
+			ok := true
+			// Parse pairs until the end of this element.
+			for {
+				v = strings.TrimLeft(v, " \t")
+				eq := strings.IndexByte(v, '=')
+				if eq <= 0 || strings.ContainsAny(v[:eq], ",; \t\"") {
+					ok = false
+					break
+				}
+				key := strings.ToLower(v[:eq])
+				v = v[eq+1:]
+
+				var val string
+				if strings.HasPrefix(v, `"`) {
+					var quoted bool
+					val, v, quoted = parseQuoted(v)
+					if !quoted {
+						ok = false
+						break
+					}
+				} else {
+					end := strings.IndexAny(v, ";,")
+					if end == -1 {
+						end = len(v)
+					}
+					val, v = strings.TrimSpace(v[:end]), v[end:]
+				}
+				elem[key] = val
+
+				v = strings.TrimLeft(v, " \t")
+				if !strings.HasPrefix(v, ";") {
+					break
+				}
+				v = v[1:]
+			}
+
+			// Skip to the next element.
+			if comma := strings.IndexByte(v, ','); comma != -1 {
+				v = v[comma+1:]
+			} else {
+				v = ""
+			}
+			if ok && len(elem) > 0 {
+				out = append(out, elem)
+			}
+		}
+	}
+	return out
+}
+
+// Parses a quoted-string at the start of v, returning the unescaped value, and the remainder of v.
+func parseQuoted(v string) (string, string, bool) {
+	var b strings.Builder
+	for i := 1; i < len(v); i++ {
+		switch v[i] {
+		case '"':
+			return b.String(), v[i+1:], true
+		case '\\':
+			i++
+			if i == len(v) {
+				return "", "", false
+			}
+		}
+		b.WriteByte(v[i])
+	}
+	return "", "", false
+}
+
+// Returns the IP address of a Forwarded node ("192.0.2.1", "[2001:db8::1]:80"), or nil.
+//
+// Obfuscated identifiers and "unknown" return nil.
+func forwardedNodeIP(node string) net.IP {
+	if host, _, err := net.SplitHostPort(node); err == nil {
+		node = host
+	}
+	node = strings.TrimSuffix(strings.TrimPrefix(node, "["), "]")
+	return net.ParseIP(node)
+}
+
+// Returns the scheme ("http" or "https") the end client used to make the request.
+//
+// If the request came from a trusted proxy (see SetTrustedProxies),
+// the scheme reported by the proxy in the Forwarded or X-Forwarded-Proto headers is used.
+//
+// r.URL.Scheme isn't used, since the client sets it with an absolute-form request line
+// (GET https://host/ HTTP/1.1).
+func ClientScheme(r *http.Request) string {
+	if info, ok := r.Context().Value(realIPKey).(realIPInfo); ok {
+		// Already resolved by RealIP, which replaced the RemoteAddr the headers are checked against.
+		return info.scheme
+	}
+	if fromTrustedProxy(r) {
+		if fwd := parseForwarded(r.Header.Values("Forwarded")); len(fwd) > 0 {
+			if proto := strings.ToLower(fwd[0]["proto"]); proto == "http" || proto == "https" {
+				return proto
+			}
+		}
+		if proto := strings.ToLower(r.Header.Get("X-Forwarded-Proto")); proto == "http" || proto == "https" {
+			return proto
+		}
+	}
+	if r.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
+
+// Returns the host the end client made the request to.
+//
+// If the request came from a trusted proxy (see SetTrustedProxies),
+// the host reported by the proxy in the Forwarded or X-Forwarded-Host headers is used.
+func ClientHost(r *http.Request) string {
+	if fromTrustedProxy(r) {
+		if fwd := parseForwarded(r.Header.Values("Forwarded")); len(fwd) > 0 {
+			if host := fwd[0]["host"]; host != "" {
+				return host
+			}
+		}
+		if host := r.Header.Get("X-Forwarded-Host"); host != "" {
+			return strings.TrimSpace(strings.Split(host, ",")[0])
+		}
+	}
+	return r.Host
+}
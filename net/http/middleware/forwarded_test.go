@@ -0,0 +1,137 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestParseForwarded(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []string
+		want   []forwardedElement
+	}{
+		{"empty", nil, nil},
+		{"simple", []string{"for=192.0.2.60"}, []forwardedElement{{"for": "192.0.2.60"}}},
+		{
+			"multiple params and elements",
+			[]string{`for=192.0.2.60;proto=http;by=203.0.113.43, For="[2001:db8:cafe::17]:4711"`},
+			[]forwardedElement{
+				{"for": "192.0.2.60", "proto": "http", "by": "203.0.113.43"},
+				{"for": "[2001:db8:cafe::17]:4711"},
+			},
+		},
+		{
+			"multiple headers",
+			[]string{"for=1.1.1.1", "for=2.2.2.2"},
+			[]forwardedElement{{"for": "1.1.1.1"}, {"for": "2.2.2.2"}},
+		},
+		{"quoted escapes", []string{`host="a\"b"`}, []forwardedElement{{"host": `a"b`}}},
+		{"unterminated quote", []string{`for="1.2.3.4`}, nil},
+		{"garbage then valid", []string{"garbage, for=1.2.3.4"}, []forwardedElement{{"for": "1.2.3.4"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseForwarded(tt.values)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestForwarded(t *testing.T) {
+	tests := []struct {
+		name       string
+		remoteAddr string
+		headers    map[string]string
+		tls        bool
+		wantIP     string
+		wantScheme string
+		wantHost   string
+	}{
+		{
+			name:       "no headers",
+			remoteAddr: "8.8.8.8:1",
+			wantIP:     "8.8.8.8", wantScheme: "http", wantHost: "example.com",
+		},
+		{
+			name:       "direct TLS",
+			remoteAddr: "8.8.8.8:1",
+			tls:        true,
+			wantIP:     "8.8.8.8", wantScheme: "https", wantHost: "example.com",
+		},
+		{
+			name:       "untrusted Forwarded ignored",
+			remoteAddr: "8.8.8.8:1",
+			headers:    map[string]string{"Forwarded": "for=1.2.3.4;proto=https;host=evil.com"},
+			wantIP:     "8.8.8.8", wantScheme: "http", wantHost: "example.com",
+		},
+		{
+			name:       "trusted Forwarded",
+			remoteAddr: "127.0.0.1:1",
+			headers:    map[string]string{"Forwarded": `for="[2001:db8::1]:4711";proto=https;host=public.com`},
+			wantIP:     "2001:db8::1", wantScheme: "https", wantHost: "public.com",
+		},
+		{
+			name:       "Forwarded preferred over X-Forwarded-*",
+			remoteAddr: "127.0.0.1:1",
+			headers: map[string]string{
+				"Forwarded":         "for=1.1.1.1;proto=https;host=a.com",
+				"X-Forwarded-For":   "2.2.2.2",
+				"X-Forwarded-Proto": "http",
+				"X-Forwarded-Host":  "b.com",
+			},
+			wantIP: "1.1.1.1", wantScheme: "https", wantHost: "a.com",
+		},
+		{
+			name:       "obfuscated Forwarded falls back",
+			remoteAddr: "127.0.0.1:1",
+			headers: map[string]string{
+				"Forwarded":         "for=_hidden",
+				"X-Forwarded-For":   "2.2.2.2",
+				"X-Forwarded-Proto": "https",
+				"X-Forwarded-Host":  "b.com",
+			},
+			wantIP: "2.2.2.2", wantScheme: "https", wantHost: "b.com",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "http://example.com/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.tls {
+				req.TLS = &tls.ConnectionState{}
+			}
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			// httptest fills in the URL scheme; real server requests don't have one.
+			req.URL.Scheme = ""
+
+			if got := ClientIP(req); got != tt.wantIP {
+				t.Errorf("ClientIP = %q, want %q", got, tt.wantIP)
+			}
+			if got := ClientScheme(req); got != tt.wantScheme {
+				t.Errorf("ClientScheme = %q, want %q", got, tt.wantScheme)
+			}
+			if got := ClientHost(req); got != tt.wantHost {
+				t.Errorf("ClientHost = %q, want %q", got, tt.wantHost)
+			}
+
+			// RealIP should produce a request which gives the same answers.
+			var r2 *http.Request
+			RealIP(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { r2 = r })).ServeHTTP(httptest.NewRecorder(), req)
+			if ClientIP(r2) != tt.wantIP || ClientScheme(r2) != tt.wantScheme || ClientHost(r2) != tt.wantHost {
+				t.Errorf("after RealIP: got (%q, %q, %q)", ClientIP(r2), ClientScheme(r2), ClientHost(r2))
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// IPFilter returns a middleware which only lets through requests from allowed client IPs.
+//
+// allow and deny are lists of CIDRs (10.0.0.0/8) or single IP addresses (10.1.2.3).
+// A client IP matching deny is always blocked. If allow is not empty, a client IP must also match allow.
+// Blocked requests get a 403.
+//
+// Headers are only believed from trusted proxies (see SetTrustedProxies). Unlike ClientIP, the client IP is
+// the address nearest to the server which isn't a trusted proxy, so that a client can't choose it by
+// sending its own X-Forwarded-For for a trusted proxy to append to.
+//
+// For example, to restrict admin routes to a VPN range:
+//
+//	vpnOnly, err := middleware.IPFilter([]string{"10.8.0.0/16"}, nil)
+//	if err != nil { ... }
+//	mux.Handle("/admin/", vpnOnly(adminHandler))
+func IPFilter(allow, deny []string) (func(http.Handler) http.Handler, error) {
+	allowNets, err := parseCIDRs(allow)
+	if err != nil {
+		return nil, fmt.Errorf("allow: %w", err)
+	}
+	denyNets, err := parseCIDRs(deny)
+	if err != nil {
+		return nil, fmt.Errorf("deny: %w", err)
+	}
+
+	allowed := func(r *http.Request) bool {
+		ip := net.ParseIP(trustedClientIP(r))
+		if ip == nil {
+			return false
+		}
+		if containsIP(denyNets, ip) {
+			return false
+		}
+		return len(allowNets) == 0 || containsIP(allowNets, ip)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !allowed(r) {
+				log.WarnContext(r.Context(), "Blocked by IP filter", "path", r.URL.Path, "ip", trustedClientIP(r))
+				http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}, nil
+}
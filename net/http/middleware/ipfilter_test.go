@@ -0,0 +1,81 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIPFilter(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	tests := []struct {
+		name       string
+		allow      []string
+		deny       []string
+		remoteAddr string
+		xff        string
+		wantStatus int
+	}{
+		{"no lists", nil, nil, "8.8.8.8:1", "", 200},
+		{"allowed", []string{"10.8.0.0/16"}, nil, "10.8.1.2:1", "", 200},
+		{"not allowed", []string{"10.8.0.0/16"}, nil, "10.9.1.2:1", "", 403},
+		{"denied", nil, []string{"8.8.8.8"}, "8.8.8.8:1", "", 403},
+		{"deny wins over allow", []string{"10.0.0.0/8"}, []string{"10.0.0.5"}, "10.0.0.5:1", "", 403},
+		{"via trusted proxy", []string{"10.8.0.0/16"}, nil, "127.0.0.1:1", "10.8.0.1", 200},
+		{"via trusted proxy, blocked", []string{"10.8.0.0/16"}, nil, "127.0.0.1:1", "1.2.3.4", 403},
+		{"untrusted proxy ignored", []string{"10.8.0.0/16"}, nil, "8.8.8.8:1", "10.8.0.1", 403},
+		{"spoofed leftmost entry", []string{"10.8.0.0/16"}, nil, "127.0.0.1:1", "10.8.0.1, 1.2.3.4", 403},
+		{"spoofed entry denied", nil, []string{"1.2.3.4"}, "127.0.0.1:1", "10.8.0.1, 1.2.3.4", 403},
+		{"chain of trusted proxies", []string{"10.8.0.0/16"}, nil, "127.0.0.1:1", "1.2.3.4, 10.8.0.1, 127.0.0.2", 200},
+		{"unparseable hop", nil, nil, "127.0.0.1:1", "1.2.3.4, unknown", 403},
+		{"ipv6", []string{"2001:db8::/32"}, nil, "[2001:db8::1]:1", "", 200},
+		{"unparseable remote", []string{"10.8.0.0/16"}, nil, "garbage", "", 403},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mw, err := IPFilter(tt.allow, tt.deny)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.xff != "" {
+				req.Header.Set("X-Forwarded-For", tt.xff)
+			}
+			w := httptest.NewRecorder()
+			mw(ok).ServeHTTP(w, req)
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestIPFilter_AfterRealIP(t *testing.T) {
+	mw, err := IPFilter([]string{"10.8.0.0/16"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "127.0.0.1:1"
+	req.Header.Set("X-Forwarded-For", "10.8.0.1, 1.2.3.4")
+	w := httptest.NewRecorder()
+	RealIP(mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))).ServeHTTP(w, req)
+	if w.Code != 403 {
+		t.Errorf("status = %d, want 403", w.Code)
+	}
+}
+
+func TestIPFilter_Invalid(t *testing.T) {
+	if _, err := IPFilter([]string{"nope"}, nil); err == nil {
+		t.Errorf("expected error for invalid allow list")
+	}
+	if _, err := IPFilter(nil, []string{"10.0.0.0/99"}); err == nil {
+		t.Errorf("expected error for invalid deny list")
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// Configures LimitConcurrency.
+type LimitOptions struct {
+	// The maximum number of requests handled at once. Must be at least 1.
+	MaxInFlight int
+
+	// The maximum number of requests waiting for a slot once MaxInFlight is reached.
+	// If zero, requests are rejected as soon as MaxInFlight is reached.
+	MaxQueued int
+
+	// How long a queued request waits for a slot before being rejected.
+	// If zero, queued requests wait until a slot is free, or the client goes away.
+	QueueTimeout time.Duration
+
+	// The value sent in the Retry-After header of rejected requests.
+	// If zero, one second is used.
+	RetryAfter time.Duration
+}
+
+// LimitConcurrency returns a middleware which caps the number of requests in flight, to shed load.
+//
+// Requests over the limit wait in a bounded queue (see LimitOptions).
+// Requests which don't fit in the queue, or wait too long, get a 503 with a Retry-After header.
+//
+// The limit belongs to the returned middleware, and is shared by every handler it wraps.
+// For a global limit, wrap the whole handler (or wrap several with the same middleware);
+// for a per-route limit, call LimitConcurrency once for each route.
+func LimitConcurrency(opts LimitOptions) func(http.Handler) http.Handler {
+	if opts.MaxInFlight < 1 {
+		panic("LimitConcurrency: MaxInFlight must be at least 1")
+	}
+	retryAfter := opts.RetryAfter
+	if retryAfter <= 0 {
+		retryAfter = time.Second
+	}
+	retryAfterStr := strconv.Itoa(int(math.Ceil(retryAfter.Seconds())))
+
+	slots := make(chan struct{}, opts.MaxInFlight)
+	var queued atomic.Int64
+
+	acquire := func(r *http.Request) bool {
+		select {
+		case slots <- struct{}{}:
+			return true
+		default:
+		}
+
+		if queued.Add(1) > int64(opts.MaxQueued) {
+			queued.Add(-1)
+			return false
+		}
+		defer queued.Add(-1)
+
+		var timeout <-chan time.Time
+		if opts.QueueTimeout > 0 {
+			timer := time.NewTimer(opts.QueueTimeout)
+			defer timer.Stop()
+			timeout = timer.C
+		}
+
+		select {
+		case slots <- struct{}{}:
+			return true
+		case <-timeout:
+			return false
+		case <-r.Context().Done():
+			return false
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !acquire(r) {
+				log.WarnContext(r.Context(), "Shedding load", "path", r.URL.Path, "ip", getClientIP(r))
+				w.Header().Set("Retry-After", retryAfterStr)
+				http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+				return
+			}
+			defer func() { <-slots }()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLimitConcurrency(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{}, 10)
+	handler := LimitConcurrency(LimitOptions{MaxInFlight: 2, MaxQueued: 1, RetryAfter: 1500 * time.Millisecond})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			started <- struct{}{}
+			<-release
+		}))
+
+	var wg sync.WaitGroup
+	codes := make(chan int, 10)
+	serve := func() {
+		defer wg.Done()
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+		codes <- w.Code
+	}
+
+	// Fill both slots.
+	wg.Add(2)
+	go serve()
+	go serve()
+	<-started
+	<-started
+
+	// One more can queue...
+	wg.Add(1)
+	go serve()
+	time.Sleep(20 * time.Millisecond)
+
+	// ...but then we're full.
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503", w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "2" {
+		t.Errorf("Retry-After = %q, want %q", got, "2")
+	}
+
+	close(release)
+	wg.Wait()
+	close(codes)
+	for code := range codes {
+		if code != http.StatusOK {
+			t.Errorf("status = %d, want 200", code)
+		}
+	}
+}
+
+func TestLimitConcurrency_QueueTimeout(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	handler := LimitConcurrency(LimitOptions{MaxInFlight: 1, MaxQueued: 1, QueueTimeout: 10 * time.Millisecond})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			<-release
+		}))
+
+	go handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	<-started
+	defer close(release)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want 503", w.Code)
+	}
+}
@@ -5,126 +5,237 @@
 package middleware
 
 import (
+	"fmt"
 	"github.com/rburchell/gosh/log/slogx"
+	"io"
 	"log/slog"
-	"net"
 	"net/http"
+	"os"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
 var log *slog.Logger = slogx.NewCategory("http", slogx.TextHandler, slog.LevelDebug)
 
-// list of locations we will trust for reporting headers
-var trustedNets []*net.IPNet
+// A field that can be included in structured access logs. See LogOptions.
+type LogField string
+
+const (
+	LogStatus    LogField = "status"
+	LogMethod    LogField = "method"
+	LogPath      LogField = "path"
+	LogDuration  LogField = "duration"
+	LogCID       LogField = "cid"
+	LogRID       LogField = "rid"
+	LogIP        LogField = "ip"
+	LogUserAgent LogField = "user_agent"
+	LogReferer   LogField = "referer"
+	LogBytes     LogField = "bytes"
+	LogTraceID   LogField = "trace_id"
+	LogSpanID    LogField = "span_id"
+)
 
-func init() {
-	// FIXME: don't hardcode this.
-	var trustedCIDRs = []string{
-		"127.0.0.1/8",
-		"100.0.0.0/8",
-	}
-	for _, cidr := range trustedCIDRs {
-		_, network, err := net.ParseCIDR(cidr)
-		if err == nil {
-			trustedNets = append(trustedNets, network)
-		}
-	}
+// The fields logged by LogRequests.
+var DefaultLogFields = []LogField{LogStatus, LogMethod, LogPath, LogDuration, LogBytes, LogCID, LogRID, LogIP}
+
+// The format of access logs. See LogOptions.
+type LogFormat int
+
+const (
+	// Structured output, written to a slog.Logger.
+	LogFormatStructured LogFormat = iota
+	// The Apache "common" log format, written to a io.Writer.
+	// The user is the one accepted by BasicAuth or BearerAuth, or "-".
+	LogFormatCommon
+	// The Apache "combined" log format (common, plus referer and user agent), written to a io.Writer.
+	LogFormatCombined
+)
+
+// Configures LogRequestsWith.
+type LogOptions struct {
+	// The format to log in. Defaults to LogFormatStructured.
+	Format LogFormat
+
+	// For LogFormatStructured, the logger to write to.
+	// If nil, the "http" category is used.
+	Logger *slog.Logger
 
+	// For LogFormatStructured, the fields to include in each record.
+	// If nil, DefaultLogFields is used.
+	Fields []LogField
+
+	// For LogFormatCommon and LogFormatCombined, where to write lines to.
+	// If nil, os.Stdout is used.
+	Output io.Writer
+
+	// If set, requests for which it returns true aren't logged (e.g. health checks).
+	// See also SkipLogging.
+	Skip func(r *http.Request) bool
 }
 
-// getClientIP gets the correct IP for the end client
-// it also uses HTTP headers, if the request is from a trusted origin (see trustedNets).
-func getClientIP(r *http.Request) string {
-	remoteIPStr, _, err := net.SplitHostPort(r.RemoteAddr)
-	if err != nil {
-		remoteIPStr = r.RemoteAddr
+// LogRequests ... logs requests.
+//
+// It is the same as LogRequestsWith(LogOptions{}).
+func LogRequests(next http.Handler) http.Handler {
+	return LogRequestsWith(LogOptions{})(next)
+}
+
+// LogRequestsWith returns a middleware which logs requests, configured by opts.
+func LogRequestsWith(opts LogOptions) func(http.Handler) http.Handler {
+	logger := opts.Logger
+	if logger == nil {
+		logger = log
 	}
-	remoteIP := net.ParseIP(remoteIPStr)
-	if remoteIP == nil {
-		return remoteIPStr
+	fields := opts.Fields
+	if fields == nil {
+		fields = DefaultLogFields
 	}
-
-	trusted := false
-	for _, net := range trustedNets {
-		if net.Contains(remoteIP) {
-			trusted = true
-			break
-		}
+	out := opts.Output
+	if out == nil {
+		out = os.Stdout
 	}
+	var outMu sync.Mutex
 
-	if trusted {
-		for _, h := range []string{"X-Forwarded-For", "X-Real-IP"} {
-			if ip := r.Header.Get(h); ip != "" {
-				// if multiple IPs, take the first
-				if idx := strings.Index(ip, ","); idx != -1 {
-					ip = ip[:idx]
-				}
-				ip = strings.TrimSpace(ip)
-
-				// ensure it is valid...
-				remoteIP := net.ParseIP(ip)
-				if remoteIP != nil {
-					return ip
-				}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			recw := &statusRecorder{ResponseWriter: w, status: 200}
+			start := time.Now()
+			next.ServeHTTP(recw, r)
+			duration := time.Since(start)
+
+			if recw.skip || (opts.Skip != nil && opts.Skip(r)) {
+				return
 			}
-		}
-	}
 
-	return remoteIP.String()
-}
+			if opts.Format != LogFormatStructured {
+				line := apacheLine(r, recw, start, opts.Format == LogFormatCombined)
+				outMu.Lock()
+				io.WriteString(out, line)
+				outMu.Unlock()
+				return
+			}
 
-type statusRecorder struct {
-	http.ResponseWriter
-	status int
+			level := slog.LevelInfo
+			if recw.status >= 500 {
+				level = slog.LevelError
+			} else if recw.status >= 400 {
+				level = slog.LevelWarn
+			}
+
+			attrs := make([]slog.Attr, 0, len(fields))
+			for _, f := range fields {
+				attrs = append(attrs, logAttr(f, r, recw, duration))
+			}
+			logger.LogAttrs(r.Context(), level, "Finished", attrs...)
+		})
+	}
 }
 
-func (r *statusRecorder) WriteHeader(code int) {
-	r.status = code
-	r.ResponseWriter.WriteHeader(code)
+// Stops the current request from being logged by LogRequests (or LogRequestsWith),
+// e.g. for noisy endpoints like health checks. It does nothing if LogRequests isn't installed.
+func SkipLogging(w http.ResponseWriter) {
+	if recw := findRecorder(w); recw != nil {
+		recw.skip = true
+	}
 }
 
-// This allows use in a http.ResponseController, which means that our wrapping is a little less of a pain.
-// We still hide interfaces (i.e. http.Flusher), but the ResponseController allows hitting the underlying
-// implementations anyway.
-//
-// This is pretty disgusting, but since I don't want to deal with the combinatorial explosion of interfaces,
-// this feels like the path of least resistance.
-func (r *statusRecorder) Unwrap() http.ResponseWriter {
-	return r.ResponseWriter
+// Records principal as the user of the request w is for, for LogFormatCommon,
+// if it is a string or a fmt.Stringer. It does nothing if LogRequests isn't installed.
+func setLogUser(w http.ResponseWriter, principal any) {
+	recw := findRecorder(w)
+	if recw == nil {
+		return
+	}
+	switch p := principal.(type) {
+	case string:
+		recw.user = p
+	case fmt.Stringer:
+		recw.user = p.String()
+	}
 }
 
-// LogRequests ... logs requests.
-func LogRequests(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		recw := &statusRecorder{ResponseWriter: w, status: 200}
-		start := time.Now()
-		next.ServeHTTP(recw, r)
-		duration := time.Since(start)
+// Escapes user for an (unquoted) field of an access log line, as \xhh for spaces,
+// quotes, backslashes and unprintable bytes, so it can't be mistaken for other fields or lines.
+func escapeLogUser(user string) string {
+	var b strings.Builder
+	for i := 0; i < len(user); i++ {
+		c := user[i]
+		if c <= ' ' || c >= 0x7f || c == '"' || c == '\\' {
+			fmt.Fprintf(&b, "\\x%02x", c)
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
 
+// Returns the attr for field f.
+func logAttr(f LogField, r *http.Request, recw *statusRecorder, duration time.Duration) slog.Attr {
+	switch f {
+	case LogStatus:
+		return slog.Int(string(f), recw.status)
+	case LogMethod:
+		return slog.String(string(f), r.Method)
+	case LogPath:
+		return slog.String(string(f), r.URL.Path)
+	case LogDuration:
+		return slog.Duration(string(f), duration)
+	case LogCID, LogRID:
 		cid, rid, err := IDs(r)
-		cids := "??"
-		rids := "??"
-		if err == nil {
-			cids = string(cid)
-			rids = string(rid)
+		if err != nil {
+			return slog.String(string(f), "??")
+		}
+		if f == LogCID {
+			return slog.String(string(f), string(cid))
 		}
+		return slog.String(string(f), string(rid))
+	case LogIP:
+		return slog.String(string(f), getClientIP(r))
+	case LogUserAgent:
+		return slog.String(string(f), r.UserAgent())
+	case LogReferer:
+		return slog.String(string(f), r.Referer())
+	case LogBytes:
+		return slogx.Bytes(string(f), recw.size)
+	case LogTraceID, LogSpanID:
+		span, ok := SpanFromContext(r.Context())
+		if !ok {
+			return slog.String(string(f), "??")
+		}
+		if f == LogTraceID {
+			return slog.String(string(f), span.TraceID.String())
+		}
+		return slog.String(string(f), span.SpanID.String())
+	}
+	return slog.String(string(f), "??")
+}
 
-		level := slog.LevelInfo
-		if recw.status >= 500 {
-			level = slog.LevelError
-		} else if recw.status >= 400 {
-			level = slog.LevelWarn
+// Returns a line in Apache common (or combined) log format.
+func apacheLine(r *http.Request, recw *statusRecorder, start time.Time, combined bool) string {
+	dash := func(s string) string {
+		if s == "" {
+			return "-"
 		}
+		return s
+	}
 
-		log.Log(r.Context(), level, "Finished",
-			slog.Int("status", recw.status),
-			slog.String("method", r.Method),
-			slog.String("path", r.URL.Path),
-			slog.Duration("duration", duration),
-			slog.String("cid", cids),
-			slog.String("rid", rids),
-			slog.String("ip", getClientIP(r)),
-		)
-	})
+	size := "-"
+	if recw.size > 0 {
+		size = strconv.FormatInt(recw.size, 10)
+	}
+
+	line := fmt.Sprintf("%s - %s [%s] %q %d %s",
+		getClientIP(r),
+		dash(escapeLogUser(recw.user)),
+		start.Format("02/Jan/2006:15:04:05 -0700"),
+		r.Method+" "+r.RequestURI+" "+r.Proto,
+		recw.status,
+		size,
+	)
+	if combined {
+		line += fmt.Sprintf(" %q %q", dash(r.Referer()), dash(r.UserAgent()))
+	}
+	return line + "\n"
 }
@@ -5,112 +5,122 @@
 package middleware
 
 import (
+	"bytes"
+	"log/slog"
 	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
 	"testing"
 )
 
-func TestGetClientIP(t *testing.T) {
-	tests := []struct {
-		name       string
-		remoteAddr string
-		headers    map[string]string
-		want       string
-	}{
-		{
-			name:       "untrusted IP, ignore headers",
-			remoteAddr: "8.8.8.8:12345",
-			headers: map[string]string{
-				"X-Forwarded-For": "1.2.3.4",
-			},
-			want: "8.8.8.8",
-		},
-		{
-			name:       "trusted localhost, use X-Forwarded-For",
-			remoteAddr: "127.0.0.1:54321",
-			headers: map[string]string{
-				"X-Forwarded-For": "1.2.3.4",
-			},
-			want: "1.2.3.4",
-		},
-		{
-			name:       "trusted 100.x.x.x, use X-Real-IP",
-			remoteAddr: "100.1.2.3:45678",
-			headers: map[string]string{
-				"X-Real-IP": "5.6.7.8",
-			},
-			want: "5.6.7.8",
-		},
-		{
-			name:       "header with multiple IPs",
-			remoteAddr: "127.0.0.1:54321",
-			headers: map[string]string{
-				"X-Forwarded-For": "9.9.9.9, 10.10.10.10",
-			},
-			want: "9.9.9.9",
-		},
-		{
-			name:       "fallback to remote addr",
-			remoteAddr: "192.168.1.1:1111",
-			headers:    nil,
-			want:       "192.168.1.1",
+func TestLogRequestsWith_Structured(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
 		},
+	}))
 
+	handler := LogRequestsWith(LogOptions{
+		Logger: logger,
+		Fields: []LogField{LogStatus, LogMethod, LogPath, LogUserAgent, LogReferer},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+
+	req := httptest.NewRequest("GET", "/missing", nil)
+	req.Header.Set("User-Agent", "test-agent")
+	req.Header.Set("Referer", "http://example.com/")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	want := `level=WARN msg=Finished status=404 method=GET path=/missing user_agent=test-agent referer=http://example.com/` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestLogRequestsWith_Apache(t *testing.T) {
+	tests := []struct {
+		name   string
+		format LogFormat
+		auth   bool
+		user   string
+		want   string
+	}{
 		{
-			name:       "untrusted localhost IPv6",
-			remoteAddr: "[::1]:54321",
-			headers: map[string]string{
-				"X-Forwarded-For": "2001:db8::1",
-			},
-			want: "::1",
-		},
-		{
-			name:       "trusted 100.x.x.x IPv4-mapped IPv6, use X-Real-IP",
-			remoteAddr: "[::ffff:100.1.2.3]:12345",
-			headers: map[string]string{
-				"X-Real-IP": "5.6.7.8",
-			},
-			want: "5.6.7.8",
+			name:   "common",
+			format: LogFormatCommon,
+			user:   "alice",
+			want:   `^192\.0\.2\.1 - - \[\d\d/\w{3}/\d{4}:\d\d:\d\d:\d\d [+-]\d{4}\] "POST /submit\?x=1 HTTP/1\.1" 201 2$`,
 		},
 		{
-			name:       "untrusted IPv6, ignore headers",
-			remoteAddr: "[2001:db8::1234]:12345",
-			headers: map[string]string{
-				"X-Forwarded-For": "1.2.3.4",
-			},
-			want: "2001:db8::1234",
+			name:   "combined",
+			format: LogFormatCombined,
+			want:   `^192\.0\.2\.1 - - \[.*\] "POST /submit\?x=1 HTTP/1\.1" 201 2 "-" "test-agent"$`,
 		},
 		{
-			name:       "invalid RemoteAddr, use raw string",
-			remoteAddr: "invalid-address",
-			headers: map[string]string{
-				"X-Forwarded-For": "9.9.9.9",
-			},
-			want: "invalid-address",
+			name:   "authenticated",
+			format: LogFormatCommon,
+			auth:   true,
+			user:   "alice",
+			want:   `^192\.0\.2\.1 - alice \[.*\] "POST /submit\?x=1 HTTP/1\.1" 201 2$`,
 		},
 		{
-			name:       "invalid IP in header, fallback to RemoteAddr",
-			remoteAddr: "127.0.0.1:1234",
-			headers: map[string]string{
-				"X-Forwarded-For": "not-an-ip",
-			},
-			want: "127.0.0.1",
+			name:   "escaped user",
+			format: LogFormatCommon,
+			auth:   true,
+			user:   "al ice\"\n",
+			want:   `^192\.0\.2\.1 - al\\x20ice\\x22\\x0a \[.*\] "POST /submit\?x=1 HTTP/1\.1" 201 2$`,
 		},
 	}
-
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			req := &http.Request{
-				RemoteAddr: tt.remoteAddr,
-				Header:     make(http.Header),
+			var buf bytes.Buffer
+			var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusCreated)
+				w.Write([]byte("ok"))
+			})
+			if tt.auth {
+				handler = BasicAuth(func(user, pass string) bool { return true })(handler)
 			}
-			for k, v := range tt.headers {
-				req.Header.Set(k, v)
+			handler = LogRequestsWith(LogOptions{Format: tt.format, Output: &buf})(handler)
+
+			req := httptest.NewRequest("POST", "/submit?x=1", nil)
+			if tt.user != "" {
+				// The user is only logged once auth middleware has accepted it.
+				req.SetBasicAuth(tt.user, "secret")
 			}
+			req.Header.Set("User-Agent", "test-agent")
+			handler.ServeHTTP(httptest.NewRecorder(), req)
 
-			got := getClientIP(req)
-			if got != tt.want {
-				t.Errorf("got %q, want %q", got, tt.want)
+			got := strings.TrimSuffix(buf.String(), "\n")
+			if !regexp.MustCompile(tt.want).MatchString(got) {
+				t.Errorf("got:\n%s\nwant match:\n%s", got, tt.want)
 			}
 		})
 	}
 }
+
+func TestLogRequestsWith_Skip(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	handler := LogRequestsWith(LogOptions{
+		Logger: logger,
+		Skip:   func(r *http.Request) bool { return r.URL.Path == "/healthz" },
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/metrics" {
+			SkipLogging(w)
+		}
+	}))
+
+	for _, path := range []string{"/healthz", "/metrics", "/logged"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+	if got := buf.String(); strings.Count(got, "msg=Finished") != 1 || !strings.Contains(got, "path=/logged") {
+		t.Errorf("got:\n%s", got)
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"encoding/json"
+	"html/template"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Configures Maintenance.
+type MaintenanceOptions struct {
+	// Reports whether maintenance mode is on. It is called on every request, so must be cheap.
+	// An atomic.Bool's Load method works well. Required.
+	Enabled func() bool
+
+	// Paths which are still served during maintenance (e.g. health checks).
+	// A path ending in "/" allows everything beneath it.
+	Allow []string
+
+	// The message shown to clients. If empty, a generic message is used.
+	Message string
+
+	// The page shown to browsers, executed with a MaintenanceData.
+	// If nil, a plain default page is used.
+	Page *template.Template
+
+	// If set, sent in the Retry-After header.
+	RetryAfter time.Duration
+}
+
+// The data passed to MaintenanceOptions.Page.
+type MaintenanceData struct {
+	Message string
+}
+
+var defaultMaintenancePage = template.Must(template.New("maintenance").Parse(`<!DOCTYPE html>
+<html><head><title>Down for maintenance</title></head>
+<body><h1>Down for maintenance</h1><p>{{.Message}}</p></body></html>
+`))
+
+// Maintenance returns a middleware which can be switched at runtime to turn away requests with a 503,
+// for controlled deploy windows.
+//
+// Clients accepting JSON get {"error": "message"}, everyone else gets an HTML page.
+//
+// For example:
+//
+//	var down atomic.Bool
+//	handler = middleware.Maintenance(middleware.MaintenanceOptions{
+//	    Enabled: down.Load,
+//	    Allow:   []string{"/healthz"},
+//	})(handler)
+//
+//	down.Store(true) // later, when starting maintenance
+func Maintenance(opts MaintenanceOptions) func(http.Handler) http.Handler {
+	if opts.Enabled == nil {
+		panic("Maintenance: Enabled is required")
+	}
+	if opts.Message == "" {
+		opts.Message = "This service is down for maintenance. Please try again later."
+	}
+	if opts.Page == nil {
+		opts.Page = defaultMaintenancePage
+	}
+
+	allowed := func(path string) bool {
+		for _, a := range opts.Allow {
+			if path == a || (strings.HasSuffix(a, "/") && strings.HasPrefix(path, a)) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !opts.Enabled() || allowed(r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if opts.RetryAfter > 0 {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(opts.RetryAfter.Seconds()))))
+			}
+			if strings.Contains(r.Header.Get("Accept"), "application/json") {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusServiceUnavailable)
+				json.NewEncoder(w).Encode(map[string]string{"error": opts.Message})
+				return
+			}
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			if err := opts.Page.Execute(w, MaintenanceData{Message: opts.Message}); err != nil {
+				log.ErrorContext(r.Context(), "Rendering maintenance page failed", "err", err)
+			}
+		})
+	}
+}
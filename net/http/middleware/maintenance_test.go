@@ -0,0 +1,72 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMaintenance(t *testing.T) {
+	var down atomic.Bool
+	handler := Maintenance(MaintenanceOptions{
+		Enabled:    down.Load,
+		Allow:      []string{"/healthz", "/admin/"},
+		Message:    "back soon",
+		RetryAfter: time.Minute,
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+
+	do := func(path, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := do("/", ""); w.Code != http.StatusOK {
+		t.Fatalf("status = %d while not in maintenance", w.Code)
+	}
+
+	down.Store(true)
+
+	w := do("/", "text/html")
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), "back soon") || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/html") {
+		t.Errorf("unexpected HTML response: %q", w.Body.String())
+	}
+	if w.Header().Get("Retry-After") != "60" {
+		t.Errorf("Retry-After = %q", w.Header().Get("Retry-After"))
+	}
+
+	w = do("/api", "application/json")
+	if w.Code != http.StatusServiceUnavailable || strings.TrimSpace(w.Body.String()) != `{"error":"back soon"}` {
+		t.Errorf("unexpected JSON response: %d %q", w.Code, w.Body.String())
+	}
+
+	for _, path := range []string{"/healthz", "/admin/", "/admin/users"} {
+		if w := do(path, ""); w.Code != http.StatusOK {
+			t.Errorf("%s: status = %d, want 200", path, w.Code)
+		}
+	}
+	if w := do("/healthz/x", ""); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("/healthz/x: status = %d, want 503", w.Code)
+	}
+
+	down.Store(false)
+	if w := do("/", ""); w.Code != http.StatusOK {
+		t.Errorf("status = %d after leaving maintenance", w.Code)
+	}
+}
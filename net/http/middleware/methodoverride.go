@@ -0,0 +1,36 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"net/http"
+	"strings"
+)
+
+// MethodOverride lets clients which can only send POST (old browsers, some webhooks) use other methods.
+//
+// A POST request with an X-HTTP-Method-Override header, or a "_method" form value,
+// is handled as if it had been sent with that method.
+// Only PUT, PATCH, and DELETE can be requested; anything else is ignored.
+//
+// MethodOverride must wrap the router for the new method to be used in routing.
+func MethodOverride(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			method := r.Header.Get("X-HTTP-Method-Override")
+			if method == "" {
+				// Only form bodies are parsed here, so e.g. JSON bodies are left for the handler.
+				method = r.PostFormValue("_method")
+			}
+			switch method = strings.ToUpper(method); method {
+			case http.MethodPut, http.MethodPatch, http.MethodDelete:
+				r2 := r.Clone(r.Context())
+				r2.Method = method
+				r = r2
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
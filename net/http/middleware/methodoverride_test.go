@@ -0,0 +1,57 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMethodOverride(t *testing.T) {
+	var gotMethod, gotBody string
+	handler := MethodOverride(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod = r.Method
+		b, _ := io.ReadAll(r.Body)
+		gotBody = string(b)
+	}))
+
+	tests := []struct {
+		name       string
+		method     string
+		header     string
+		ctype      string
+		body       string
+		wantMethod string
+		wantBody   string
+	}{
+		{"plain POST", "POST", "", "", "", "POST", ""},
+		{"header", "POST", "delete", "", "", "DELETE", ""},
+		{"form value", "POST", "", "application/x-www-form-urlencoded", "_method=PUT&a=b", "PUT", ""},
+		{"disallowed method", "POST", "CONNECT", "", "", "POST", ""},
+		{"not a POST", "GET", "DELETE", "", "", "GET", ""},
+		{"JSON body untouched", "POST", "", "application/json", `{"_method":"PUT"}`, "POST", `{"_method":"PUT"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/", strings.NewReader(tt.body))
+			if tt.header != "" {
+				req.Header.Set("X-HTTP-Method-Override", tt.header)
+			}
+			if tt.ctype != "" {
+				req.Header.Set("Content-Type", tt.ctype)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+			if gotMethod != tt.wantMethod {
+				t.Errorf("method = %q, want %q", gotMethod, tt.wantMethod)
+			}
+			if gotBody != tt.wantBody {
+				t.Errorf("body = %q, want %q", gotBody, tt.wantBody)
+			}
+		})
+	}
+}
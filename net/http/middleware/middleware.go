@@ -4,3 +4,16 @@
 
 // Package middleware contains some HTTP middleware for use in creating simple web applications.
 package middleware
+
+// Context keys
+type ctxKey int
+
+const (
+	idsKey ctxKey = iota
+	principalKey
+	csrfKey
+	sessionKey
+	spanKey
+	attrsKey
+	realIPKey
+)
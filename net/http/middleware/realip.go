@@ -0,0 +1,221 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// The proxies that are trusted when no others are configured.
+var defaultTrustedProxies = []string{
+	"127.0.0.1/8",
+	"100.0.0.0/8",
+}
+
+// list of locations we will trust for reporting headers
+var (
+	trustedMu   sync.RWMutex
+	trustedNets []*net.IPNet
+)
+
+func init() {
+	if err := SetTrustedProxies(defaultTrustedProxies); err != nil {
+		panic(err)
+	}
+}
+
+// Sets the proxies which are trusted to report the client IP in headers (X-Forwarded-For, X-Real-IP).
+//
+// Each entry is either a CIDR (10.0.0.0/8) or a single IP address (10.1.2.3).
+// Passing an empty list means no proxy is trusted, and the connection's address is always used.
+//
+// If any entry is invalid, an error is returned and the trusted proxies are left unchanged.
+// The default is to trust 127.0.0.1/8 and 100.0.0.0/8.
+func SetTrustedProxies(cidrs []string) error {
+	nets, err := parseCIDRs(cidrs)
+	if err != nil {
+		return fmt.Errorf("invalid trusted proxy: %w", err)
+	}
+
+	trustedMu.Lock()
+	trustedNets = nets
+	trustedMu.Unlock()
+	return nil
+}
+
+// Parses a list of CIDRs (10.0.0.0/8) or single IP addresses (10.1.2.3).
+func parseCIDRs(cidrs []string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+	for _, cidr := range cidrs {
+		if !strings.Contains(cidr, "/") {
+			ip := net.ParseIP(cidr)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid IP address: %q", cidr)
+			}
+			bits := 8 * len(ip)
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 32
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, err
+		}
+		nets = append(nets, network)
+	}
+	return nets, nil
+}
+
+// Returns true if ip is in any of nets.
+func containsIP(nets []*net.IPNet, ip net.IP) bool {
+	for _, net := range nets {
+		if net.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns true if ip is a trusted proxy.
+func isTrustedProxy(ip net.IP) bool {
+	trustedMu.RLock()
+	defer trustedMu.RUnlock()
+	return containsIP(trustedNets, ip)
+}
+
+// Returns true if r came directly from a trusted proxy.
+func fromTrustedProxy(r *http.Request) bool {
+	remoteIPStr, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		remoteIPStr = r.RemoteAddr
+	}
+	remoteIP := net.ParseIP(remoteIPStr)
+	return remoteIP != nil && isTrustedProxy(remoteIP)
+}
+
+// getClientIP gets the correct IP for the end client
+// it also uses HTTP headers, if the request is from a trusted origin (see SetTrustedProxies).
+//
+// The headers consulted are Forwarded (RFC 7239), X-Forwarded-For, and X-Real-IP, in that order.
+func getClientIP(r *http.Request) string {
+	remoteIPStr, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		remoteIPStr = r.RemoteAddr
+	}
+	remoteIP := net.ParseIP(remoteIPStr)
+	if remoteIP == nil {
+		return remoteIPStr
+	}
+
+	if isTrustedProxy(remoteIP) {
+		if fwd := parseForwarded(r.Header.Values("Forwarded")); len(fwd) > 0 {
+			if ip := forwardedNodeIP(fwd[0]["for"]); ip != nil {
+				return ip.String()
+			}
+		}
+		for _, h := range []string{"X-Forwarded-For", "X-Real-IP"} {
+			if ip := r.Header.Get(h); ip != "" {
+				// if multiple IPs, take the first
+				if idx := strings.Index(ip, ","); idx != -1 {
+					ip = ip[:idx]
+				}
+				ip = strings.TrimSpace(ip)
+
+				// ensure it is valid...
+				remoteIP := net.ParseIP(ip)
+				if remoteIP != nil {
+					return ip
+				}
+			}
+		}
+	}
+
+	return remoteIP.String()
+}
+
+// Returns the IP of the end client for access control (see IPFilter).
+//
+// Unlike getClientIP, the forwarding chain (Forwarded, X-Forwarded-For, or X-Real-IP) is walked from
+// the nearest hop outwards, skipping trusted proxies, and the first untrusted address is used.
+// Entries a client added itself, in front of those added by trusted proxies, are never used.
+// If a hop can't be parsed (e.g. "unknown"), "" is returned.
+func trustedClientIP(r *http.Request) string {
+	remote := r.RemoteAddr
+	if info, ok := r.Context().Value(realIPKey).(realIPInfo); ok {
+		remote = info.remoteAddr // RealIP replaced it
+	}
+	host, _, err := net.SplitHostPort(remote)
+	if err != nil {
+		host = remote
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !isTrustedProxy(ip) {
+		return host
+	}
+
+	var chain []net.IP
+	if fwd := parseForwarded(r.Header.Values("Forwarded")); len(fwd) > 0 {
+		for _, elem := range fwd {
+			chain = append(chain, forwardedNodeIP(elem["for"]))
+		}
+	} else if xff := r.Header.Values("X-Forwarded-For"); len(xff) > 0 {
+		for _, v := range xff {
+			for _, hop := range strings.Split(v, ",") {
+				chain = append(chain, net.ParseIP(strings.TrimSpace(hop)))
+			}
+		}
+	} else if xri := r.Header.Get("X-Real-IP"); xri != "" {
+		chain = append(chain, net.ParseIP(strings.TrimSpace(xri)))
+	}
+
+	for i := len(chain) - 1; i >= 0; i-- {
+		if chain[i] == nil {
+			return ""
+		}
+		ip = chain[i]
+		if !isTrustedProxy(ip) {
+			break
+		}
+	}
+	return ip.String()
+}
+
+// Returns the IP of the end client making the request.
+//
+// If the request came from a trusted proxy (see SetTrustedProxies),
+// the IP reported by the proxy in headers is used.
+func ClientIP(r *http.Request) string {
+	return getClientIP(r)
+}
+
+// RealIP replaces the request's RemoteAddr with the IP of the end client (see ClientIP).
+// It also sets the request's Host and URL.Scheme to those the client used (see ClientHost and ClientScheme).
+//
+// This is useful for handlers that use RemoteAddr directly, but note that the port is lost.
+// Middleware using the client IP (e.g. LogRequests) works with or without RealIP.
+func RealIP(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scheme := ClientScheme(r)
+		ctx := context.WithValue(r.Context(), realIPKey, realIPInfo{remoteAddr: r.RemoteAddr, scheme: scheme})
+		r2 := r.Clone(ctx)
+		r2.RemoteAddr = getClientIP(r)
+		r2.Host = ClientHost(r)
+		r2.URL.Scheme = scheme
+		next.ServeHTTP(w, r2)
+	})
+}
+
+// What RealIP knew about the original request, before replacing parts of it.
+type realIPInfo struct {
+	remoteAddr string // the connection's address
+	scheme     string // see ClientScheme
+}
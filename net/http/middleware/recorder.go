@@ -0,0 +1,133 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+)
+
+// statusRecorder records the status and size of a response, as it is written.
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	size        int64
+	wroteHeader bool
+	skip        bool   // see SkipLogging
+	user        string // the authenticated user, for LogFormatCommon; see BasicAuth and BearerAuth
+	// Called just before the response starts, e.g. to set cookies; see Sessions.
+	onStart []func()
+}
+
+var (
+	_ http.Flusher  = &statusRecorder{}
+	_ http.Hijacker = &statusRecorder{}
+	_ io.ReaderFrom = &statusRecorder{}
+)
+
+func (r *statusRecorder) WriteHeader(code int) {
+	// Informational responses (other than switching protocols) are followed by the real status.
+	if !r.wroteHeader && (code >= 200 || code == http.StatusSwitchingProtocols) {
+		r.start()
+		r.status = code
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	r.start()
+	r.wroteHeader = true
+	n, err := r.ResponseWriter.Write(b)
+	r.size += int64(n)
+	return n, err
+}
+
+// Runs the onStart functions, the first time the response is about to start.
+func (r *statusRecorder) start() {
+	if r.wroteHeader {
+		return
+	}
+	onStart := r.onStart
+	r.onStart = nil
+	for _, f := range onStart {
+		f()
+	}
+}
+
+// Passes through to the underlying http.Flusher, if there is one, so that streaming (e.g. SSE) works.
+func (r *statusRecorder) Flush() {
+	r.start()
+	r.wroteHeader = true
+	http.NewResponseController(r.ResponseWriter).Flush()
+}
+
+// Passes through to the underlying http.Hijacker, if there is one, so that e.g. websockets work.
+func (r *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	r.start()
+	return http.NewResponseController(r.ResponseWriter).Hijack()
+}
+
+// Passes through to the underlying io.ReaderFrom, if there is one, so that e.g. sendfile can be used.
+func (r *statusRecorder) ReadFrom(src io.Reader) (int64, error) {
+	r.start()
+	r.wroteHeader = true
+	var n int64
+	var err error
+	if rf, ok := r.ResponseWriter.(io.ReaderFrom); ok {
+		n, err = rf.ReadFrom(src)
+	} else {
+		// Hide our own ReadFrom, otherwise io.Copy would call it again.
+		n, err = io.Copy(struct{ io.Writer }{r.ResponseWriter}, src)
+	}
+	r.size += n
+	return n, err
+}
+
+// This allows use in a http.ResponseController, which means that our wrapping is a little less of a pain.
+// We pass through the common interfaces (http.Flusher, http.Hijacker, io.ReaderFrom), but the
+// ResponseController allows hitting any other underlying implementations anyway.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// Finds the statusRecorder in w, which may be wrapped by other middleware.
+func findRecorder(w http.ResponseWriter) *statusRecorder {
+	for {
+		if recw, ok := w.(*statusRecorder); ok {
+			return recw
+		}
+		u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return nil
+		}
+		w = u.Unwrap()
+	}
+}
+
+// Returns w, and the statusRecorder in it. If there isn't one (i.e. LogRequests isn't installed),
+// w is wrapped in a new one. Middleware which needs the response status uses this, so that
+// there is only one recorder, and SkipLogging and ResponseStatus find the right one.
+func withRecorder(w http.ResponseWriter) (http.ResponseWriter, *statusRecorder) {
+	if recw := findRecorder(w); recw != nil {
+		return w, recw
+	}
+	recw := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	return recw, recw
+}
+
+// Returns the status and number of body bytes written so far to w.
+//
+// This works for middleware and handlers running inside LogRequests (or LogRequestsWith).
+// If LogRequests isn't installed, ok is false.
+func ResponseStatus(w http.ResponseWriter) (status int, size int64, ok bool) {
+	recw := findRecorder(w)
+	if recw == nil {
+		return 0, 0, false
+	}
+	return recw.status, recw.size, true
+}
@@ -0,0 +1,73 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestStatusRecorder(t *testing.T) {
+	w := httptest.NewRecorder()
+	recw := &statusRecorder{ResponseWriter: w, status: 200}
+
+	recw.WriteHeader(http.StatusAccepted)
+	recw.WriteHeader(http.StatusTeapot) // superfluous; ignored
+	recw.Write([]byte("hello"))
+	io.Copy(recw, strings.NewReader(" world"))
+	recw.Flush()
+
+	if recw.status != http.StatusAccepted {
+		t.Errorf("status = %d, want %d", recw.status, http.StatusAccepted)
+	}
+	if recw.size != 11 {
+		t.Errorf("size = %d, want 11", recw.size)
+	}
+	if w.Body.String() != "hello world" {
+		t.Errorf("body = %q", w.Body.String())
+	}
+	if !w.Flushed {
+		t.Errorf("flush was not passed through")
+	}
+	if _, _, err := recw.Hijack(); !errors.Is(err, http.ErrNotSupported) {
+		t.Errorf("Hijack error = %v, want ErrNotSupported", err)
+	}
+}
+
+func TestStatusRecorder_Informational(t *testing.T) {
+	recw := &statusRecorder{ResponseWriter: httptest.NewRecorder(), status: 200}
+	recw.WriteHeader(http.StatusEarlyHints)
+	if recw.status != 200 {
+		t.Errorf("status = %d after 1xx, want 200", recw.status)
+	}
+	recw.WriteHeader(http.StatusNoContent)
+	if recw.status != http.StatusNoContent {
+		t.Errorf("status = %d, want %d", recw.status, http.StatusNoContent)
+	}
+}
+
+func TestResponseStatus(t *testing.T) {
+	var status int
+	var size int64
+	var ok bool
+	handler := LogRequestsWith(LogOptions{Output: io.Discard, Format: LogFormatCommon})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("abc"))
+		status, size, ok = ResponseStatus(w)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	if !ok || status != http.StatusCreated || size != 3 {
+		t.Errorf("got (%d, %d, %v), want (201, 3, true)", status, size, ok)
+	}
+
+	if _, _, ok := ResponseStatus(httptest.NewRecorder()); ok {
+		t.Errorf("expected ok = false without LogRequests")
+	}
+}
@@ -9,9 +9,22 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"errors"
+	"github.com/rburchell/gosh/log/slogx"
+	"log/slog"
 	"net/http"
 )
 
+// Adds the CID and RID to records logged with a request's context. See slogx.NewContextHandler.
+func init() {
+	slogx.RegisterContextExtractor(func(ctx context.Context) []slog.Attr {
+		ids, ok := ctx.Value(idsKey).(ids)
+		if !ok {
+			return nil
+		}
+		return []slog.Attr{slog.String("cid", string(ids.cid)), slog.String("rid", string(ids.rid))}
+	})
+}
+
 // A unique ID for a client making HTTP requests
 // See TagWithRequestID.
 type CID string
@@ -20,8 +33,19 @@ type CID string
 // See TagWithRequestID.
 type RID string
 
+// The header used to propagate request IDs between services.
+const RequestIDHeader = "X-Request-ID"
+
+// The longest inbound request ID that will be accepted.
+const maxRequestIDLength = 128
+
 // TagWithRequestID tags requests with CID and RIDs, for later access during request processing.
 //
+// If the request carries a valid X-Request-ID header (e.g. from a proxy or another service),
+// it is used as the RID, so that requests can be correlated across services.
+// Otherwise, a new RID is generated. Either way, the RID is sent back in the X-Request-ID response header.
+// A valid inbound ID is at most 128 characters from [A-Za-z0-9._:-].
+//
 // NOTE: CID is passed back to the client as a cookie, so it is *INSECURE*.
 // You *MUST NOT* rely on it for anything security-related.
 // The client may (intentionally or not) lose the CID, may forge the CID, or similar.
@@ -52,8 +76,12 @@ func TagWithRequestID(next http.Handler) http.Handler {
 			cid = cidCookie.Value
 		}
 
-		// Generate new request ID
-		rid := randomHex(idLength)
+		// Use the inbound request ID if there is one, or generate a new one
+		rid := r.Header.Get(RequestIDHeader)
+		if !isValidRequestID(rid) {
+			rid = randomHex(idLength)
+		}
+		w.Header().Set(RequestIDHeader, rid)
 
 		// Store IDs in context for easy access
 		ctx := r.Context()
@@ -62,17 +90,28 @@ func TagWithRequestID(next http.Handler) http.Handler {
 	})
 }
 
+// Returns true if s is acceptable as an inbound request ID.
+func isValidRequestID(s string) bool {
+	if len(s) == 0 || len(s) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range s {
+		switch {
+		case c >= '0' && c <= '9', c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
 func randomHex(n int) string {
 	b := make([]byte, (n+1)/2) // halve the length because hex doubles the size.
 	rand.Read(b)
 	return hex.EncodeToString(b)
 }
 
-// Context keys
-type ctxKey int
-
-const idsKey ctxKey = iota
-
 type ids struct {
 	cid CID
 	rid RID
@@ -5,8 +5,13 @@
 package middleware
 
 import (
+	"bytes"
+	"github.com/rburchell/gosh/log/slogx"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"regexp"
+	"strings"
 	"testing"
 )
 
@@ -88,3 +93,58 @@ func TestTagWithRequestID_DifferentClients(t *testing.T) {
 		t.Errorf("expected different clients to have different CIDs, but got %s", cids)
 	}
 }
+
+func TestTagWithRequestID_Propagation(t *testing.T) {
+	var capturedRID RID
+	handler := TagWithRequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		capturedRID, _ = RequestID(r)
+	}))
+
+	tests := []struct {
+		name    string
+		inbound string
+		want    string // empty means a new ID must be generated
+	}{
+		{"no inbound ID", "", ""},
+		{"valid inbound ID", "abc-123_x.y:z", "abc-123_x.y:z"},
+		{"uuid", "a6075bc7-1a09-443a-b1c0-64de253fb2d6", "a6075bc7-1a09-443a-b1c0-64de253fb2d6"},
+		{"invalid characters", "abc 123", ""},
+		{"header injection", "abc\r\nX-Evil: 1", ""},
+		{"too long", strings.Repeat("a", 129), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.inbound != "" {
+				req.Header.Set("X-Request-ID", tt.inbound)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if tt.want != "" && string(capturedRID) != tt.want {
+				t.Errorf("RID = %q, want %q", capturedRID, tt.want)
+			}
+			if tt.want == "" && (capturedRID == "" || string(capturedRID) == tt.inbound) {
+				t.Errorf("expected a newly generated RID, got %q", capturedRID)
+			}
+			if got := w.Header().Get("X-Request-ID"); got != string(capturedRID) {
+				t.Errorf("response header = %q, want %q", got, capturedRID)
+			}
+		})
+	}
+}
+
+func TestTagWithRequestID_ContextLogging(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slogx.NewContextHandler(slog.NewTextHandler(&buf, nil)))
+	handler := TagWithRequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger.InfoContext(r.Context(), "handling")
+	}))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(RequestIDHeader, "abc-123")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if got := buf.String(); !regexp.MustCompile(`msg=handling cid=[0-9a-f]{6} rid=abc-123\n$`).MatchString(got) {
+		t.Errorf("got %q", got)
+	}
+}
@@ -0,0 +1,341 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Returned by a SessionStore when a session doesn't exist, or has expired.
+var ErrSessionNotFound = errors.New("session not found")
+
+// A SessionStore persists session data. See Sessions.
+//
+// Implementations must be safe for concurrent use.
+type SessionStore interface {
+	// Returns the data for session id, or ErrSessionNotFound if it doesn't exist or has expired.
+	Load(id string) (map[string]string, error)
+	// Stores data for session id, until expiry.
+	Save(id string, data map[string]string, expiry time.Time) error
+	// Removes session id. Deleting a missing session is not an error.
+	Delete(id string) error
+}
+
+// Configures Sessions.
+type SessionOptions struct {
+	// Where session data is kept. If nil, a new MemorySessionStore is used.
+	Store SessionStore
+
+	// The key used to sign session cookies. Required.
+	// It should be at least 32 random bytes, and kept secret.
+	SigningKey []byte
+
+	// If set, session cookies are also encrypted (AES-GCM) with this key.
+	// It must be 16, 24, or 32 bytes long.
+	EncryptionKey []byte
+
+	// The name of the session cookie. If empty, "session" is used.
+	CookieName string
+
+	// How long a session lives after it was last changed. If zero, 24 hours is used.
+	MaxAge time.Duration
+}
+
+// The length of a session ID, in hex characters.
+const sessionIDLength = 64
+
+type session struct {
+	mu        sync.Mutex
+	id        string
+	oldID     string // set if the session was rotated
+	data      map[string]string
+	isNew     bool
+	dirty     bool
+	destroyed bool
+}
+
+// Sessions returns a middleware which provides a session to each request.
+//
+// The session's ID is kept in a signed (and optionally encrypted) cookie,
+// and the session's data is kept in a SessionStore.
+// Handlers use SessionGet, SessionSet, SessionDelete, SessionRotate and SessionDestroy.
+//
+// A cookie is only issued once something is stored in a session.
+// Session changes are saved when the response starts being written,
+// so changes made after that point are lost.
+//
+// Unlike the CID (see TagWithRequestID), a session can't be forged by the client.
+// Call SessionRotate after changing privilege (e.g. logging in), to prevent session fixation.
+func Sessions(opts SessionOptions) func(http.Handler) http.Handler {
+	if len(opts.SigningKey) == 0 {
+		panic("Sessions: SigningKey is required")
+	}
+	var aead cipher.AEAD
+	if opts.EncryptionKey != nil {
+		block, err := aes.NewCipher(opts.EncryptionKey)
+		if err != nil {
+			panic("Sessions: bad EncryptionKey: " + err.Error())
+		}
+		aead, err = cipher.NewGCM(block)
+		if err != nil {
+			panic("Sessions: bad EncryptionKey: " + err.Error())
+		}
+	}
+	if opts.Store == nil {
+		opts.Store = NewMemorySessionStore()
+	}
+	if opts.CookieName == "" {
+		opts.CookieName = "session"
+	}
+	if opts.MaxAge == 0 {
+		opts.MaxAge = 24 * time.Hour
+	}
+
+	c := &sessionCodec{signingKey: opts.SigningKey, aead: aead}
+
+	load := func(r *http.Request) *session {
+		if cookie, err := r.Cookie(opts.CookieName); err == nil {
+			if id, ok := c.open(cookie.Value); ok {
+				data, err := opts.Store.Load(id)
+				if err == nil {
+					return &session{id: id, data: data}
+				}
+				if !errors.Is(err, ErrSessionNotFound) {
+					log.ErrorContext(r.Context(), "Loading session failed", "err", err)
+				}
+			}
+		}
+		return &session{id: randomHex(sessionIDLength), data: map[string]string{}, isNew: true}
+	}
+
+	commit := func(w http.ResponseWriter, r *http.Request, s *session) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if s.oldID != "" {
+			if err := opts.Store.Delete(s.oldID); err != nil {
+				log.ErrorContext(r.Context(), "Deleting rotated session failed", "err", err)
+			}
+		}
+
+		cookie := &http.Cookie{
+			Name:     opts.CookieName,
+			Path:     "/",
+			HttpOnly: true,
+			Secure:   r.TLS != nil,
+			SameSite: http.SameSiteLaxMode,
+		}
+
+		if s.destroyed {
+			if err := opts.Store.Delete(s.id); err != nil {
+				log.ErrorContext(r.Context(), "Deleting session failed", "err", err)
+			}
+			if _, err := r.Cookie(opts.CookieName); err == nil {
+				cookie.MaxAge = -1
+				http.SetCookie(w, cookie)
+			}
+			return
+		}
+
+		if !s.dirty && s.oldID == "" {
+			return
+		}
+		if s.isNew && len(s.data) == 0 {
+			return
+		}
+
+		expiry := time.Now().Add(opts.MaxAge)
+		if err := opts.Store.Save(s.id, s.data, expiry); err != nil {
+			log.ErrorContext(r.Context(), "Saving session failed", "err", err)
+			return
+		}
+		cookie.Value = c.seal(s.id)
+		cookie.Expires = expiry
+		http.SetCookie(w, cookie)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			s := load(r)
+			committed := false
+			commitOnce := func() {
+				if !committed {
+					committed = true
+					commit(w, r, s)
+				}
+			}
+			// Save the session just before the response starts, so that the cookie can still be set.
+			w, recw := withRecorder(w)
+			recw.onStart = append(recw.onStart, commitOnce)
+
+			ctx := context.WithValue(r.Context(), sessionKey, s)
+			next.ServeHTTP(w, r.WithContext(ctx))
+			commitOnce()
+		})
+	}
+}
+
+// Signs (and optionally encrypts) session IDs for use in cookies.
+type sessionCodec struct {
+	signingKey []byte
+	aead       cipher.AEAD
+}
+
+func (c *sessionCodec) mac(payload []byte) []byte {
+	h := hmac.New(sha256.New, c.signingKey)
+	h.Write(payload)
+	return h.Sum(nil)
+}
+
+// Returns the cookie value for id.
+func (c *sessionCodec) seal(id string) string {
+	payload := []byte(id)
+	if c.aead != nil {
+		nonce := make([]byte, c.aead.NonceSize())
+		rand.Read(nonce)
+		payload = c.aead.Seal(nonce, nonce, payload, nil)
+	}
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString(payload) + "." + enc.EncodeToString(c.mac(payload))
+}
+
+// Returns the session ID from a cookie value, if it is authentic.
+func (c *sessionCodec) open(value string) (string, bool) {
+	enc := base64.RawURLEncoding
+	payloadStr, macStr, ok := strings.Cut(value, ".")
+	if !ok {
+		return "", false
+	}
+	payload, err := enc.DecodeString(payloadStr)
+	if err != nil {
+		return "", false
+	}
+	mac, err := enc.DecodeString(macStr)
+	if err != nil || !hmac.Equal(mac, c.mac(payload)) {
+		return "", false
+	}
+	if c.aead != nil {
+		ns := c.aead.NonceSize()
+		if len(payload) < ns {
+			return "", false
+		}
+		payload, err = c.aead.Open(nil, payload[:ns], payload[ns:], nil)
+		if err != nil {
+			return "", false
+		}
+	}
+	id := string(payload)
+	if !isValidSessionID(id) {
+		return "", false
+	}
+	return id, true
+}
+
+func isValidSessionID(s string) bool {
+	if len(s) != sessionIDLength {
+		return false
+	}
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func sessionFrom(r *http.Request) (*session, error) {
+	if s, ok := r.Context().Value(sessionKey).(*session); ok {
+		return s, nil
+	}
+
+	// if this is hit, you are accessing the session either too early (before the session handler),
+	// or the session handler isn't installed.
+	return nil, errors.New("session not found in request")
+}
+
+// Fetch the value for key from the request's session.
+// See Sessions.
+func SessionGet(r *http.Request, key string) (string, bool) {
+	s, err := sessionFrom(r)
+	if err != nil {
+		return "", false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.data[key]
+	return v, ok
+}
+
+// Stores value for key in the request's session, or error.
+// See Sessions.
+func SessionSet(r *http.Request, key, value string) error {
+	s, err := sessionFrom(r)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data[key] = value
+	s.dirty = true
+	return nil
+}
+
+// Removes key from the request's session, or error.
+// See Sessions.
+func SessionDelete(r *http.Request, key string) error {
+	s, err := sessionFrom(r)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.data, key)
+	s.dirty = true
+	return nil
+}
+
+// Gives the request's session a new ID, keeping its data, or error.
+//
+// This should be done whenever privilege changes (e.g. logging in), to prevent session fixation.
+// See Sessions.
+func SessionRotate(r *http.Request) error {
+	s, err := sessionFrom(r)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.isNew && s.oldID == "" {
+		s.oldID = s.id
+	}
+	s.id = randomHex(sessionIDLength)
+	s.dirty = true
+	return nil
+}
+
+// Removes the request's session entirely (e.g. logging out), or error.
+// See Sessions.
+func SessionDestroy(r *http.Request) error {
+	s, err := sessionFrom(r)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data = map[string]string{}
+	s.destroyed = true
+	return nil
+}
@@ -0,0 +1,148 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSessions(t *testing.T) {
+	for _, encrypt := range []bool{false, true} {
+		name := "signed"
+		opts := SessionOptions{SigningKey: []byte("0123456789abcdef0123456789abcdef")}
+		if encrypt {
+			name = "encrypted"
+			opts.EncryptionKey = []byte("fedcba9876543210")
+		}
+
+		t.Run(name, func(t *testing.T) {
+			var got string
+			var found bool
+			handler := Sessions(opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/set":
+					SessionSet(r, "user", "alice")
+				case "/login":
+					SessionRotate(r)
+				case "/logout":
+					SessionDestroy(r)
+				case "/unset":
+					SessionDelete(r, "user")
+				}
+				got, found = SessionGet(r, "user")
+				w.Write([]byte("ok"))
+			}))
+
+			do := func(path string, cookie *http.Cookie) *http.Cookie {
+				req := httptest.NewRequest("GET", path, nil)
+				if cookie != nil {
+					req.AddCookie(cookie)
+				}
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, req)
+				for _, c := range w.Result().Cookies() {
+					if c.Name == "session" {
+						return c
+					}
+				}
+				return nil
+			}
+
+			// Nothing stored: no cookie.
+			if c := do("/", nil); c != nil {
+				t.Fatalf("unexpected cookie for empty session: %v", c)
+			}
+
+			cookie := do("/set", nil)
+			if cookie == nil {
+				t.Fatalf("expected a session cookie")
+			}
+			if encrypt && strings.Contains(cookie.Value, "alice") {
+				t.Fatalf("cookie leaks data: %q", cookie.Value)
+			}
+
+			do("/", cookie)
+			if !found || got != "alice" {
+				t.Fatalf("got (%q, %v), want alice", got, found)
+			}
+
+			// Tampering with the cookie gives a fresh session.
+			tampered := *cookie
+			tampered.Value = "x" + tampered.Value[1:]
+			do("/", &tampered)
+			if found {
+				t.Errorf("tampered cookie was accepted")
+			}
+
+			// Rotation changes the ID, keeps the data, and invalidates the old ID.
+			rotated := do("/login", cookie)
+			if rotated == nil || rotated.Value == cookie.Value {
+				t.Fatalf("expected a new cookie after rotation")
+			}
+			do("/", rotated)
+			if !found || got != "alice" {
+				t.Errorf("rotated session lost data")
+			}
+			do("/", cookie)
+			if found {
+				t.Errorf("old session still valid after rotation")
+			}
+
+			// Deleting a key.
+			do("/unset", rotated)
+			do("/", rotated)
+			if found {
+				t.Errorf("deleted key still present")
+			}
+
+			// Destroying expires the cookie, and the session.
+			do("/set", rotated)
+			expired := do("/logout", rotated)
+			if expired == nil || expired.MaxAge >= 0 {
+				t.Errorf("expected cookie to be expired, got %v", expired)
+			}
+			do("/", rotated)
+			if found {
+				t.Errorf("destroyed session still valid")
+			}
+		})
+	}
+}
+
+func TestSessions_Writer(t *testing.T) {
+	opts := SessionOptions{SigningKey: []byte("0123456789abcdef0123456789abcdef")}
+	handler := LogRequestsWith(LogOptions{Output: io.Discard, Format: LogFormatCommon})(
+		Sessions(opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, ok := w.(http.Hijacker); !ok {
+				t.Errorf("%T isn't a http.Hijacker", w)
+			}
+			SessionSet(r, "user", "alice")
+			// io.Copy uses ReadFrom, which starts the response without Write or WriteHeader.
+			io.Copy(w, strings.NewReader("ok"))
+			if status, _, ok := ResponseStatus(w); !ok || status != http.StatusOK {
+				t.Errorf("ResponseStatus = %d, %v", status, ok)
+			}
+		})))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if cookies := w.Result().Cookies(); len(cookies) != 1 || cookies[0].Name != "session" {
+		t.Errorf("cookies = %v", cookies)
+	}
+}
+
+func TestSessions_Missing(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	if err := SessionSet(req, "a", "b"); err == nil {
+		t.Errorf("expected error without session middleware")
+	}
+	if _, ok := SessionGet(req, "a"); ok {
+		t.Errorf("expected nothing without session middleware")
+	}
+}
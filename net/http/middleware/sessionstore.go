@@ -0,0 +1,151 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/rburchell/gosh/fs/fsatomic"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// A SessionStore which keeps sessions in memory.
+//
+// Sessions are lost when the process exits.
+type MemorySessionStore struct {
+	mu        sync.Mutex
+	sessions  map[string]memorySession
+	lastSweep time.Time
+}
+
+type memorySession struct {
+	data   map[string]string
+	expiry time.Time
+}
+
+var _ SessionStore = &MemorySessionStore{}
+
+// Creates an empty MemorySessionStore.
+func NewMemorySessionStore() *MemorySessionStore {
+	return &MemorySessionStore{sessions: map[string]memorySession{}, lastSweep: time.Now()}
+}
+
+func (s *MemorySessionStore) Load(id string) (map[string]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ms, ok := s.sessions[id]
+	if !ok || time.Now().After(ms.expiry) {
+		return nil, ErrSessionNotFound
+	}
+	return maps.Clone(ms.data), nil
+}
+
+func (s *MemorySessionStore) Save(id string, data map[string]string, expiry time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions[id] = memorySession{data: maps.Clone(data), expiry: expiry}
+
+	// Expired sessions are only removed here, once in a while, to stop them piling up.
+	if now := time.Now(); now.Sub(s.lastSweep) > time.Minute {
+		s.lastSweep = now
+		maps.DeleteFunc(s.sessions, func(_ string, ms memorySession) bool {
+			return now.After(ms.expiry)
+		})
+	}
+	return nil
+}
+
+func (s *MemorySessionStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, id)
+	return nil
+}
+
+// A SessionStore which keeps each session in a file in a directory.
+//
+// Files are written atomically (see fsatomic), so sessions survive restarts and crashes.
+// Expired files are removed when they are next loaded.
+type FileSessionStore struct {
+	dir string
+}
+
+var _ SessionStore = &FileSessionStore{}
+
+// Creates a FileSessionStore keeping sessions in dir, creating it if needed.
+func NewFileSessionStore(dir string) (*FileSessionStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &FileSessionStore{dir: dir}, nil
+}
+
+type fileSession struct {
+	Data   map[string]string `json:"data"`
+	Expiry time.Time         `json:"expiry"`
+}
+
+func (s *FileSessionStore) path(id string) (string, error) {
+	// IDs come from cookies, so make sure nobody can escape the directory.
+	if !isValidSessionID(id) {
+		return "", fmt.Errorf("invalid session ID")
+	}
+	return filepath.Join(s.dir, id), nil
+}
+
+func (s *FileSessionStore) Load(id string) (map[string]string, error) {
+	p, err := s.path(id)
+	if err != nil {
+		return nil, ErrSessionNotFound
+	}
+	b, err := os.ReadFile(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var fsess fileSession
+	if err := json.Unmarshal(b, &fsess); err != nil {
+		return nil, fmt.Errorf("session %s: %w", id, err)
+	}
+	if time.Now().After(fsess.Expiry) {
+		os.Remove(p)
+		return nil, ErrSessionNotFound
+	}
+	if fsess.Data == nil {
+		fsess.Data = map[string]string{}
+	}
+	return fsess.Data, nil
+}
+
+func (s *FileSessionStore) Save(id string, data map[string]string, expiry time.Time) error {
+	p, err := s.path(id)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(fileSession{Data: data, Expiry: expiry})
+	if err != nil {
+		return err
+	}
+	return fsatomic.WriteFile(p, b, 0600)
+}
+
+func (s *FileSessionStore) Delete(id string) error {
+	p, err := s.path(id)
+	if err != nil {
+		return nil
+	}
+	if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSessionStores(t *testing.T) {
+	fileStore, err := NewFileSessionStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileSessionStore: %v", err)
+	}
+
+	stores := map[string]SessionStore{
+		"memory": NewMemorySessionStore(),
+		"file":   fileStore,
+	}
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			id := strings.Repeat("a", sessionIDLength)
+			expiredID := strings.Repeat("b", sessionIDLength)
+
+			if _, err := store.Load(id); !errors.Is(err, ErrSessionNotFound) {
+				t.Fatalf("Load of missing session: err = %v", err)
+			}
+
+			data := map[string]string{"k": "v"}
+			if err := store.Save(id, data, time.Now().Add(time.Hour)); err != nil {
+				t.Fatalf("Save: %v", err)
+			}
+			data["k"] = "changed after save"
+
+			got, err := store.Load(id)
+			if err != nil || got["k"] != "v" {
+				t.Fatalf("Load = %v, %v", got, err)
+			}
+
+			if err := store.Save(expiredID, data, time.Now().Add(-time.Second)); err != nil {
+				t.Fatalf("Save: %v", err)
+			}
+			if _, err := store.Load(expiredID); !errors.Is(err, ErrSessionNotFound) {
+				t.Errorf("Load of expired session: err = %v", err)
+			}
+
+			if err := store.Delete(id); err != nil {
+				t.Fatalf("Delete: %v", err)
+			}
+			if err := store.Delete(id); err != nil {
+				t.Fatalf("Delete of missing session: %v", err)
+			}
+			if _, err := store.Load(id); !errors.Is(err, ErrSessionNotFound) {
+				t.Errorf("Load after Delete: err = %v", err)
+			}
+		})
+	}
+}
+
+func TestFileSessionStore_InvalidID(t *testing.T) {
+	store, err := NewFileSessionStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileSessionStore: %v", err)
+	}
+	if err := store.Save("../../etc/passwd", nil, time.Now().Add(time.Hour)); err == nil {
+		t.Errorf("expected error for invalid ID")
+	}
+}
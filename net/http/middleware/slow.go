@@ -0,0 +1,105 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"runtime"
+	"strconv"
+	"time"
+)
+
+// Configures DetectSlowRequests.
+type SlowOptions struct {
+	// How long a request may run before it is reported. If zero, 10 seconds is used.
+	Threshold time.Duration
+
+	// The logger to write to. If nil, the "http" category is used.
+	Logger *slog.Logger
+
+	// If set, the stack of the handler goroutine is not included in the report.
+	NoStack bool
+}
+
+// DetectSlowRequests returns a middleware which logs a warning when a request is still running
+// after opts.Threshold, along with the stack of the goroutine handling it (as a sample
+// of what it is stuck on).
+//
+// This is separate from the access log (see LogRequests), which is only written when the
+// request finishes, so hung handlers are noticed while they are still hung.
+// Each request is reported at most once.
+func DetectSlowRequests(opts SlowOptions) func(http.Handler) http.Handler {
+	threshold := opts.Threshold
+	if threshold <= 0 {
+		threshold = 10 * time.Second
+	}
+	logger := opts.Logger
+	if logger == nil {
+		logger = log
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			gid := goroutineID()
+			start := time.Now()
+			timer := time.AfterFunc(threshold, func() {
+				attrs := []slog.Attr{
+					slog.String("method", r.Method),
+					slog.String("path", r.URL.Path),
+					slog.Duration("elapsed", time.Since(start)),
+				}
+				if _, rid, err := IDs(r); err == nil {
+					attrs = append(attrs, slog.String("rid", string(rid)))
+				}
+				if !opts.NoStack {
+					attrs = append(attrs, slog.String("stack", string(goroutineStack(gid))))
+				}
+				logger.LogAttrs(r.Context(), slog.LevelWarn, "Slow request", attrs...)
+			})
+			defer timer.Stop()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Returns the ID of the calling goroutine, as shown in stack traces.
+func goroutineID() []byte {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	// "goroutine 123 [running]:..."
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i > 0 {
+		if _, err := strconv.ParseUint(string(b[:i]), 10, 64); err == nil {
+			return append([]byte(nil), b[:i]...)
+		}
+	}
+	return nil
+}
+
+// Returns the stack of the goroutine with the given ID, or nil if it isn't found.
+func goroutineStack(gid []byte) []byte {
+	if gid == nil {
+		return nil
+	}
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) || len(buf) >= 16<<20 {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+
+	header := append(append([]byte("goroutine "), gid...), ' ')
+	for stack := range bytes.SplitSeq(buf, []byte("\n\n")) {
+		if bytes.HasPrefix(stack, header) {
+			return bytes.TrimSpace(stack)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func stuckInHandler(done chan struct{}) { <-done }
+
+func TestDetectSlowRequests(t *testing.T) {
+	var out syncBuffer
+	logger := slog.New(slog.NewTextHandler(&out, nil))
+	reported := make(chan struct{})
+
+	done := make(chan struct{})
+	handler := DetectSlowRequests(SlowOptions{Threshold: 10 * time.Millisecond, Logger: logger})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/slow" {
+				go func() {
+					for !strings.Contains(out.String(), "Slow request") {
+						time.Sleep(time.Millisecond)
+					}
+					close(reported)
+				}()
+				stuckInHandler(done)
+			}
+		}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/fast", nil))
+	time.Sleep(20 * time.Millisecond)
+	if out.String() != "" {
+		t.Fatalf("fast request was reported: %s", out.String())
+	}
+
+	go func() {
+		<-reported
+		close(done)
+	}()
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/slow", nil))
+
+	got := out.String()
+	if !strings.Contains(got, "level=WARN") || !strings.Contains(got, "path=/slow") {
+		t.Errorf("unexpected report: %s", got)
+	}
+	if !strings.Contains(got, "stuckInHandler") {
+		t.Errorf("report does not include handler stack: %s", got)
+	}
+}
@@ -0,0 +1,159 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"github.com/rburchell/gosh/log/slogx"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// Adds the trace and span IDs to records logged with a request's context. See slogx.NewContextHandler.
+func init() {
+	slogx.RegisterContextExtractor(func(ctx context.Context) []slog.Attr {
+		span, ok := SpanFromContext(ctx)
+		if !ok {
+			return nil
+		}
+		return []slog.Attr{slog.String("trace_id", span.TraceID.String()), slog.String("span_id", span.SpanID.String())}
+	})
+}
+
+// The ID of a distributed trace, as defined by W3C Trace Context.
+type TraceID [16]byte
+
+func (t TraceID) String() string { return hex.EncodeToString(t[:]) }
+
+// The ID of a single span within a trace, as defined by W3C Trace Context.
+type SpanID [8]byte
+
+func (s SpanID) String() string { return hex.EncodeToString(s[:]) }
+
+// Identifies the span a request is being handled in. See Tracing.
+type Span struct {
+	TraceID TraceID
+	SpanID  SpanID
+	// The span that caused this one (i.e. in the calling service), if any.
+	ParentID SpanID
+	// Whether the caller asked for this trace to be recorded.
+	Sampled bool
+	// Vendor-specific trace data (the tracestate header), passed through as is.
+	TraceState string
+}
+
+// Tracing propagates W3C Trace Context (traceparent/tracestate headers) through requests.
+//
+// Each request gets a new span. If the request carries a valid traceparent header,
+// the span joins the caller's trace, otherwise a new trace is started.
+// The span is available from SpanFromContext, and can be passed on to outbound requests
+// using InjectTrace or TracingTransport.
+//
+// Include LogTraceID and LogSpanID in LogOptions.Fields to correlate access logs with traces.
+// As with TagWithRequestID, Tracing must wrap LogRequests for those fields to be filled in.
+func Tracing(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		span, ok := parseTraceparent(r.Header.Get("traceparent"))
+		if ok {
+			span.ParentID = span.SpanID
+			span.TraceState = r.Header.Get("tracestate")
+		} else {
+			span = Span{Sampled: true}
+			rand.Read(span.TraceID[:])
+		}
+		rand.Read(span.SpanID[:])
+
+		ctx := context.WithValue(r.Context(), spanKey, span)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// Fetch the span associated with ctx, if there is one.
+// See Tracing.
+func SpanFromContext(ctx context.Context) (Span, bool) {
+	span, ok := ctx.Value(spanKey).(Span)
+	return span, ok
+}
+
+// Sets the traceparent (and tracestate) headers in h from the span in ctx,
+// so that the receiving service continues the same trace.
+//
+// If ctx has no span, h is not changed.
+func InjectTrace(ctx context.Context, h http.Header) {
+	span, ok := SpanFromContext(ctx)
+	if !ok {
+		return
+	}
+	flags := 0
+	if span.Sampled {
+		flags = 1
+	}
+	h.Set("traceparent", fmt.Sprintf("00-%s-%s-%02x", span.TraceID, span.SpanID, flags))
+	if span.TraceState != "" {
+		h.Set("tracestate", span.TraceState)
+	}
+}
+
+// TracingTransport returns a http.RoundTripper which calls InjectTrace on each outbound request,
+// using the request's context. If base is nil, http.DefaultTransport is used.
+//
+// For example:
+//
+//	client := &http.Client{Transport: middleware.TracingTransport(nil)}
+//	req, _ := http.NewRequestWithContext(r.Context(), "GET", "http://other-service/", nil)
+//	client.Do(req)
+func TracingTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if _, ok := SpanFromContext(req.Context()); ok {
+			// RoundTrippers must not modify the request, so make a copy.
+			req = req.Clone(req.Context())
+			InjectTrace(req.Context(), req.Header)
+		}
+		return base.RoundTrip(req)
+	})
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+// Parses a traceparent header, e.g. 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01
+func parseTraceparent(s string) (Span, bool) {
+	var span Span
+	// version-traceid-spanid-flags, with possibly more fields in future versions.
+	if len(s) < 55 || (len(s) > 55 && s[55] != '-') {
+		return span, false
+	}
+	if s[2] != '-' || s[35] != '-' || s[52] != '-' {
+		return span, false
+	}
+	isLowerHex := func(s string) bool {
+		return strings.Trim(s, "0123456789abcdef") == ""
+	}
+	version, traceID, spanID, flags := s[0:2], s[3:35], s[36:52], s[53:55]
+	if !isLowerHex(version) || !isLowerHex(traceID) || !isLowerHex(spanID) || !isLowerHex(flags) {
+		return span, false
+	}
+	if version == "ff" || (version == "00" && len(s) != 55) {
+		return span, false
+	}
+
+	hex.Decode(span.TraceID[:], []byte(traceID))
+	hex.Decode(span.SpanID[:], []byte(spanID))
+	if span.TraceID == (TraceID{}) || span.SpanID == (SpanID{}) {
+		return span, false
+	}
+	var f [1]byte
+	hex.Decode(f[:], []byte(flags))
+	span.Sampled = f[0]&1 == 1
+	return span, true
+}
@@ -0,0 +1,106 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		wantOK  bool
+		sampled bool
+	}{
+		{"valid sampled", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", true, true},
+		{"valid unsampled", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", true, false},
+		{"future version with extra fields", "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", true, true},
+		{"version 00 with extra fields", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", false, false},
+		{"version ff", "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", false, false},
+		{"uppercase", "00-4BF92F3577B34DA6A3CE929D0E0E4736-00F067AA0BA902B7-01", false, false},
+		{"zero trace id", "00-00000000000000000000000000000000-00f067aa0ba902b7-01", false, false},
+		{"zero span id", "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", false, false},
+		{"too short", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7", false, false},
+		{"empty", "", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			span, ok := parseTraceparent(tt.in)
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && span.Sampled != tt.sampled {
+				t.Errorf("sampled = %v, want %v", span.Sampled, tt.sampled)
+			}
+		})
+	}
+}
+
+func TestTracing(t *testing.T) {
+	var span Span
+	var outbound http.Header
+	handler := Tracing(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		span, _ = SpanFromContext(r.Context())
+		outbound = http.Header{}
+		InjectTrace(r.Context(), outbound)
+	}))
+
+	// Joining an existing trace
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	req.Header.Set("tracestate", "vendor=x")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if span.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("trace ID = %s", span.TraceID)
+	}
+	if span.ParentID.String() != "00f067aa0ba902b7" {
+		t.Errorf("parent ID = %s", span.ParentID)
+	}
+	if span.SpanID == span.ParentID || span.SpanID == (SpanID{}) {
+		t.Errorf("expected a new span ID, got %s", span.SpanID)
+	}
+	want := "00-4bf92f3577b34da6a3ce929d0e0e4736-" + span.SpanID.String() + "-01"
+	if got := outbound.Get("traceparent"); got != want {
+		t.Errorf("outbound traceparent = %q, want %q", got, want)
+	}
+	if got := outbound.Get("tracestate"); got != "vendor=x" {
+		t.Errorf("outbound tracestate = %q", got)
+	}
+
+	// Starting a new trace
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	if span.TraceID == (TraceID{}) || span.ParentID != (SpanID{}) || !span.Sampled {
+		t.Errorf("unexpected new span: %+v", span)
+	}
+}
+
+func TestTracingTransport(t *testing.T) {
+	var got string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("traceparent")
+	}))
+	defer upstream.Close()
+
+	client := &http.Client{Transport: TracingTransport(nil)}
+	ctx := context.WithValue(context.Background(), spanKey, Span{TraceID: TraceID{1}, SpanID: SpanID{2}})
+	req, _ := http.NewRequestWithContext(ctx, "GET", upstream.URL, nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if want := "00-01000000000000000000000000000000-0200000000000000-00"; got != want {
+		t.Errorf("traceparent = %q, want %q", got, want)
+	}
+	if req.Header.Get("traceparent") != "" {
+		t.Errorf("original request was modified")
+	}
+}
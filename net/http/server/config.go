@@ -0,0 +1,165 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"github.com/rburchell/gosh/flagx"
+	"github.com/rburchell/gosh/net/http/middleware"
+	"log/slog"
+	"time"
+)
+
+// The standard configuration of a server. See FromFlags and FromConfig.
+type Config struct {
+	// The address to listen on. See ListenAndServe.
+	Addr string
+
+	// If both are set, https is served using this certificate and key.
+	TLSCert string
+	TLSKey  string
+
+	// See the fields of the same name on http.Server. Zero means no timeout.
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	IdleTimeout  time.Duration
+
+	// See Builder.ShutdownTimeout. Zero means the default.
+	ShutdownTimeout time.Duration
+
+	// The minimum level of access logs.
+	LogLevel slog.Level
+}
+
+// Holds the values of the flags registered by FromFlags, until they are turned into a Config.
+type Flags struct {
+	addr            string
+	tlsCert         string
+	tlsKey          string
+	readTimeout     string
+	writeTimeout    string
+	idleTimeout     string
+	shutdownTimeout string
+	logLevel        string
+}
+
+// Registers the standard server flags with flagx, so that every binary doesn't need to redefine them:
+//
+//	addr, tls_cert, tls_key, read_timeout, write_timeout, idle_timeout, shutdown_timeout, log_level
+//
+// Timeouts are durations (e.g. "30s"), and log_level is one of debug, info, warn, or error.
+// Like any other flagx var, they can also be set in the environment (e.g. ADDR), or envkv.
+//
+// Call Config after flagx.Parse, for example:
+//
+//	flags := server.FromFlags()
+//	flagx.Parse()
+//	cfg, err := flags.Config()
+//	if err != nil {
+//	    ...
+//	}
+//	err = server.FromConfig(cfg).
+//	HandleFunc("/ping", handlePingPong).
+//	RunConfig(context.Background())
+func FromFlags() *Flags {
+	f := &Flags{}
+	flagx.StringVar(&f.addr, "addr", ":8080", "the address to listen on")
+	flagx.StringVar(&f.tlsCert, "tls_cert", "", "the TLS certificate file; serves https if set with tls_key")
+	flagx.StringVar(&f.tlsKey, "tls_key", "", "the TLS key file; serves https if set with tls_cert")
+	flagx.StringVar(&f.readTimeout, "read_timeout", "0s", "the maximum duration for reading a request")
+	flagx.StringVar(&f.writeTimeout, "write_timeout", "0s", "the maximum duration for writing a response")
+	flagx.StringVar(&f.idleTimeout, "idle_timeout", "0s", "the maximum duration to keep idle connections open")
+	flagx.StringVar(&f.shutdownTimeout, "shutdown_timeout", "30s", "how long to wait for in-flight requests when shutting down")
+	flagx.StringVar(&f.logLevel, "log_level", "info", "the minimum level of access logs (debug, info, warn, error)")
+	return f
+}
+
+// Returns the Config described by the flags, or an error if any of them are invalid.
+func (f *Flags) Config() (Config, error) {
+	cfg := Config{Addr: f.addr, TLSCert: f.tlsCert, TLSKey: f.tlsKey}
+
+	durations := []struct {
+		name string
+		val  string
+		dst  *time.Duration
+	}{
+		{"read_timeout", f.readTimeout, &cfg.ReadTimeout},
+		{"write_timeout", f.writeTimeout, &cfg.WriteTimeout},
+		{"idle_timeout", f.idleTimeout, &cfg.IdleTimeout},
+		{"shutdown_timeout", f.shutdownTimeout, &cfg.ShutdownTimeout},
+	}
+	for _, d := range durations {
+		v, err := time.ParseDuration(d.val)
+		if err != nil {
+			return Config{}, fmt.Errorf("server: bad %s: %w", d.name, err)
+		}
+		*d.dst = v
+	}
+
+	if err := cfg.LogLevel.UnmarshalText([]byte(f.logLevel)); err != nil {
+		return Config{}, fmt.Errorf("server: bad log_level: %w", err)
+	}
+	return cfg, nil
+}
+
+// Starts a Builder (with a new mux) configured by cfg. Use RunConfig to serve it.
+//
+// Access logs below cfg.LogLevel are dropped. To do that, DefaultMiddleware is replaced
+// with its stock equivalent: request IDs, and access logging at cfg.LogLevel.
+func FromConfig(cfg Config) *Builder {
+	b := Build(nil)
+	b.config = &cfg
+	b.Timeouts(cfg.ReadTimeout, cfg.WriteTimeout, cfg.IdleTimeout)
+	if cfg.ShutdownTimeout > 0 {
+		b.ShutdownTimeout(cfg.ShutdownTimeout)
+	}
+
+	logger := slog.New(minLevelHandler{Handler: log.Handler(), level: cfg.LogLevel})
+	return b.WithoutDefaults().Use(
+		middleware.TagWithRequestID,
+		middleware.LogRequestsWith(middleware.LogOptions{Logger: logger}),
+	)
+}
+
+// minLevelHandler drops records below level, as well as those its Handler drops.
+type minLevelHandler struct {
+	slog.Handler
+	level slog.Level
+}
+
+func (h minLevelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.level && h.Handler.Enabled(ctx, level)
+}
+
+func (h minLevelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return minLevelHandler{h.Handler.WithAttrs(attrs), h.level}
+}
+
+func (h minLevelHandler) WithGroup(name string) slog.Handler {
+	return minLevelHandler{h.Handler.WithGroup(name), h.level}
+}
+
+// Sets the read, write, and idle timeouts of the servers Builder creates.
+// See the fields of the same name on http.Server. Zero means no timeout.
+func (b *Builder) Timeouts(read, write, idle time.Duration) *Builder {
+	b.readTimeout = read
+	b.writeTimeout = write
+	b.idleTimeout = idle
+	return b
+}
+
+// Serves the Builder as configured by FromConfig: https if TLSCert and TLSKey are set, or http otherwise.
+// See Run and RunTLS.
+func (b *Builder) RunConfig(ctx context.Context) error {
+	if b.config == nil {
+		panic("RunConfig: Builder wasn't created by FromConfig")
+	}
+	cfg := b.config
+	if cfg.TLSCert != "" && cfg.TLSKey != "" {
+		return b.RunTLS(ctx, cfg.Addr, cfg.TLSCert, cfg.TLSKey)
+	}
+	return b.Run(ctx, cfg.Addr)
+}
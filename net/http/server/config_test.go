@@ -0,0 +1,104 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"github.com/rburchell/gosh/flagx"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFromFlags(t *testing.T) {
+	oldArgs, oldCommandLine := os.Args, flag.CommandLine
+	defer func() { os.Args, flag.CommandLine = oldArgs, oldCommandLine }()
+	flag.CommandLine = flag.NewFlagSet("test", flag.ExitOnError)
+	os.Args = []string{"test", "-addr", "127.0.0.1:9000", "-read_timeout", "5s", "-log_level", "warn"}
+	t.Setenv("TLS_CERT", "cert.pem")
+	t.Setenv("TLS_KEY", "key.pem")
+
+	flags := FromFlags()
+	flagx.Parse()
+	cfg, err := flags.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := Config{
+		Addr:            "127.0.0.1:9000",
+		TLSCert:         "cert.pem",
+		TLSKey:          "key.pem",
+		ReadTimeout:     5 * time.Second,
+		ShutdownTimeout: 30 * time.Second,
+		LogLevel:        slog.LevelWarn,
+	}
+	if cfg != want {
+		t.Errorf("got %+v, want %+v", cfg, want)
+	}
+}
+
+func TestFlags_ConfigErrors(t *testing.T) {
+	valid := Flags{readTimeout: "0s", writeTimeout: "0s", idleTimeout: "0s", shutdownTimeout: "0s", logLevel: "info"}
+	tests := []struct {
+		name   string
+		modify func(f *Flags)
+	}{
+		{"bad duration", func(f *Flags) { f.writeTimeout = "soon" }},
+		{"bad level", func(f *Flags) { f.logLevel = "loud" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := valid
+			tt.modify(&f)
+			if _, err := f.Config(); err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+	if _, err := valid.Config(); err != nil {
+		t.Errorf("valid flags: %v", err)
+	}
+}
+
+func TestFromConfig(t *testing.T) {
+	b := FromConfig(Config{
+		Addr:            "127.0.0.1:0",
+		ReadTimeout:     time.Second,
+		WriteTimeout:    2 * time.Second,
+		IdleTimeout:     3 * time.Second,
+		ShutdownTimeout: 4 * time.Second,
+	})
+	if b.shutdownTimeout != 4*time.Second {
+		t.Errorf("shutdownTimeout = %v", b.shutdownTimeout)
+	}
+	srv := b.newServer(":0")
+	if srv.ReadTimeout != time.Second || srv.WriteTimeout != 2*time.Second || srv.IdleTimeout != 3*time.Second {
+		t.Errorf("got timeouts %v/%v/%v", srv.ReadTimeout, srv.WriteTimeout, srv.IdleTimeout)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := b.RunConfig(ctx); err != nil {
+		t.Errorf("RunConfig returned %v", err)
+	}
+}
+
+func TestMinLevelHandler(t *testing.T) {
+	var buf bytes.Buffer
+	base := slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo})
+	logger := slog.New(minLevelHandler{Handler: base, level: slog.LevelWarn}).With("a", 1).WithGroup("g")
+
+	logger.Debug("debug")
+	logger.Info("info")
+	logger.Warn("warn", "b", 2)
+	if got := buf.String(); strings.Count(got, "\n") != 1 || !strings.Contains(got, "msg=warn a=1 g.b=2") {
+		t.Errorf("got:\n%s", got)
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBuilder_EnableDebug(t *testing.T) {
+	handler := Build(nil).EnableDebug("/debug").Build()
+
+	tests := []struct {
+		path, remoteAddr string
+		xff              string
+		wantCode         int
+		wantBody         string
+	}{
+		{"/debug/pprof/", "127.0.0.1:1234", "", 200, "goroutine"},
+		{"/debug/pprof/goroutine?debug=1", "127.0.0.1:1234", "", 200, "goroutine profile"},
+		{"/debug/pprof/cmdline", "[::1]:1234", "", 200, ""},
+		{"/debug/vars", "127.0.0.1:1234", "", 200, "memstats"},
+		{"/debug/vars", "192.0.2.1:1234", "", 403, ""},
+		{"/debug/pprof/", "192.0.2.1:1234", "", 403, ""},
+		{"/debug/vars", "127.0.0.1:1234", "127.0.0.1", 403, ""},
+		{"/debug/vars", "127.0.0.1:1234", "192.0.2.1", 403, ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", tt.path, nil)
+		req.RemoteAddr = tt.remoteAddr
+		if tt.xff != "" {
+			req.Header.Set("X-Forwarded-For", tt.xff)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		if w.Code != tt.wantCode || !strings.Contains(w.Body.String(), tt.wantBody) {
+			t.Errorf("%s from %s (%s): code = %d, body = %.100q", tt.path, tt.remoteAddr, tt.xff, w.Code, w.Body.String())
+		}
+	}
+
+	// With an explicit guard, it replaces the loopback restriction
+	open := func(next http.Handler) http.Handler { return next }
+	req := httptest.NewRequest("GET", "/debug/vars", nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	w := httptest.NewRecorder()
+	Build(nil).EnableDebug("/debug", open).ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("with guard: code = %d", w.Code)
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package server
+
+import "net/http"
+
+// Sets the handler for requests that match no route, instead of the mux's plain text 404.
+//
+// For example, for an API:
+//
+//	b.NotFound(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+//	    server.Error(w, http.StatusNotFound, "not_found", "no such endpoint")
+//	}))
+//
+// It is called inside all middleware, so these requests are still logged.
+func (b *Builder) NotFound(h http.Handler) *Builder {
+	b.notFound = h
+	return b
+}
+
+// Sets the handler for requests that match a route's path, but not its method (see Get, etc),
+// instead of the mux's plain text 405. The Allow header is already set when h is called.
+func (b *Builder) MethodNotAllowed(h http.Handler) *Builder {
+	b.notAllowed = h
+	return b
+}
+
+// Wraps mux, so that unmatched requests go to the NotFound and MethodNotAllowed handlers, if set.
+func (b *Builder) withFallbacks(mux *http.ServeMux) http.Handler {
+	if b.notFound == nil && b.notAllowed == nil {
+		return mux
+	}
+	notFound, notAllowed := b.notFound, b.notAllowed
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, pattern := mux.Handler(r); pattern != "" {
+			mux.ServeHTTP(w, r)
+			return
+		}
+
+		// Let the mux decide between 404 and 405 (and set Allow), but intercept its response.
+		fw := &fallbackWriter{ResponseWriter: w, notFound: notFound != nil, notAllowed: notAllowed != nil}
+		mux.ServeHTTP(fw, r)
+		switch fw.intercepted {
+		case http.StatusNotFound:
+			notFound.ServeHTTP(w, r)
+		case http.StatusMethodNotAllowed:
+			notAllowed.ServeHTTP(w, r)
+		}
+	})
+}
+
+// fallbackWriter swallows the mux's own 404 or 405 responses, when there are replacements for them.
+type fallbackWriter struct {
+	http.ResponseWriter
+	notFound, notAllowed bool
+	intercepted          int
+}
+
+func (w *fallbackWriter) WriteHeader(code int) {
+	if (code == http.StatusNotFound && w.notFound) || (code == http.StatusMethodNotAllowed && w.notAllowed) {
+		w.intercepted = code
+		// Undo what http.Error set, so the replacement starts clean.
+		w.Header().Del("Content-Type")
+		w.Header().Del("X-Content-Type-Options")
+		return
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *fallbackWriter) Write(b []byte) (int, error) {
+	if w.intercepted != 0 {
+		return len(b), nil
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *fallbackWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
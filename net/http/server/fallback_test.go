@@ -0,0 +1,42 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBuilder_Fallbacks(t *testing.T) {
+	handler := Build(nil).
+		Get("/items", func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "handler's own 404", http.StatusNotFound)
+		}).
+		NotFound(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			Error(w, http.StatusNotFound, "not_found", "no such endpoint")
+		})).
+		MethodNotAllowed(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			Error(w, http.StatusMethodNotAllowed, "method_not_allowed", "allowed: "+w.Header().Get("Allow"))
+		})).
+		Build()
+
+	tests := []struct {
+		method, path string
+		wantCode     int
+		wantBody     string
+	}{
+		{"GET", "/nope", 404, "{\"error\":\"no such endpoint\",\"code\":\"not_found\"}\n"},
+		{"POST", "/items", 405, "{\"error\":\"allowed: GET, HEAD\",\"code\":\"method_not_allowed\"}\n"},
+		{"GET", "/items", 404, "handler's own 404\n"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+		if w.Code != tt.wantCode || w.Body.String() != tt.wantBody {
+			t.Errorf("%s %s: code = %d, body = %q", tt.method, tt.path, w.Code, w.Body.String())
+		}
+	}
+}
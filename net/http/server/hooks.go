@@ -0,0 +1,56 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+)
+
+// Adds fn to be called once the server is listening, with the address it is listening on
+// (which is useful when listening on ":0"). Requests may already be arriving while it runs.
+//
+// If fn returns an error, the server is shut down, and the error is returned
+// (from ListenAndServe, Run, etc).
+//
+// With AlsoServe, addr is that of the main listener.
+func (b *Builder) OnStart(fn func(addr net.Addr) error) *Builder {
+	b.onStart = append(b.onStart, fn)
+	return b
+}
+
+// Adds fn to be called when the server is shut down, after in-flight requests have finished,
+// e.g. to close database connections. ctx expires after the ShutdownTimeout.
+//
+// Hooks are called in reverse order of being added, and all of them are called,
+// even if some fail.
+func (b *Builder) OnStop(fn func(ctx context.Context) error) *Builder {
+	b.onStop = append(b.onStop, fn)
+	return b
+}
+
+// Calls the OnStart hooks, stopping at the first error.
+func (b *Builder) started(addr net.Addr) error {
+	for _, fn := range b.onStart {
+		if err := fn(addr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Calls the OnStop hooks.
+func (b *Builder) stopped() error {
+	ctx, cancel := context.WithTimeout(context.Background(), b.shutdownTimeout)
+	defer cancel()
+	var errs []error
+	for i := len(b.onStop) - 1; i >= 0; i-- {
+		if err := b.onStop[i](ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,61 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestBuilder_Hooks(t *testing.T) {
+	var events []string
+	var body string
+	b := Build(nil).HandleFunc("/ping", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("pong"))
+	})
+	b.OnStart(func(addr net.Addr) error {
+		events = append(events, "start")
+		resp, err := http.Get("http://" + addr.String() + "/ping")
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		body = string(data)
+		return errors.New("stop now")
+	})
+	b.OnStart(func(addr net.Addr) error {
+		events = append(events, "not reached")
+		return nil
+	})
+	b.OnStop(func(ctx context.Context) error {
+		events = append(events, "stop 1")
+		return nil
+	})
+	b.OnStop(func(ctx context.Context) error {
+		events = append(events, "stop 2")
+		return errors.New("cleanup failed")
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = b.Serve(ln)
+	if err == nil || err.Error() != "stop now" {
+		t.Errorf("Serve = %v, want the OnStart error", err)
+	}
+	if body != "pong" {
+		t.Errorf("request from OnStart got %q", body)
+	}
+	if got := strings.Join(events, ","); got != "start,stop 2,stop 1" {
+		t.Errorf("events = %s", got)
+	}
+}
@@ -0,0 +1,22 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package server
+
+import "net/http"
+
+// Serves HTTP/2 without TLS ("h2c", with prior knowledge) as well as HTTP/1.1, on non-TLS listeners.
+// This is useful behind load balancers or gRPC gateways which speak HTTP/2 to backends in cleartext.
+//
+// HTTP/2 is always available over TLS, regardless of this.
+func (b *Builder) H2C() *Builder {
+	b.h2c = true
+	return b
+}
+
+// Configures HTTP/2, e.g. to raise MaxConcurrentStreams. Zero fields keep their defaults.
+func (b *Builder) HTTP2(cfg http.HTTP2Config) *Builder {
+	b.http2 = &cfg
+	return b
+}
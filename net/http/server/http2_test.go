@@ -0,0 +1,43 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+)
+
+func TestBuilder_H2C(t *testing.T) {
+	b := Build(nil).H2C().HTTP2(http.HTTP2Config{MaxConcurrentStreams: 500}).
+		HandleFunc("/proto", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(r.Proto))
+		})
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := b.newServer(ln.Addr().String())
+	if srv.HTTP2.MaxConcurrentStreams != 500 {
+		t.Errorf("MaxConcurrentStreams = %d, want 500", srv.HTTP2.MaxConcurrentStreams)
+	}
+	go srv.Serve(ln)
+	defer b.Shutdown(context.Background())
+
+	protocols := new(http.Protocols)
+	protocols.SetUnencryptedHTTP2(true)
+	client := &http.Client{Transport: &http.Transport{Protocols: protocols}}
+	resp, err := client.Get("http://" + ln.Addr().String() + "/proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "HTTP/2.0" {
+		t.Errorf("proto = %q, want HTTP/2.0", body)
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/rburchell/gosh/log/slogx"
+	"log/slog"
+	"net/http"
+)
+
+// Serves a handler at path for viewing and changing slogx category levels at runtime, e.g. to turn on
+// debug logging for one category in production, without a restart.
+//
+// GET responds with a JSON object of category names to levels (see slogx.Levels).
+// PUT takes a JSON object of category names to levels, as understood by slog.Level.UnmarshalText,
+// and applies them with slogx.SetLevel; a null level applies slogx.ResetLevel instead.
+//
+// guard (e.g. middleware.BasicAuth) must protect it, since anyone who can change levels
+// can flood the logs. For example:
+//
+//	b.EnableLevels("/debug/levels", middleware.BasicAuth(checkOps))
+//	// then: curl -u ops:secret -X PUT -d '{"db":"debug","http":null}' http://localhost:8080/debug/levels
+func (b *Builder) EnableLevels(path string, guard func(http.Handler) http.Handler) *Builder {
+	if guard == nil {
+		panic("server: EnableLevels requires a guard")
+	}
+	return b.Handle(path, guard(levelHandler()))
+}
+
+// Returns the handler served by EnableLevels.
+func levelHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+		case http.MethodPut:
+			var req map[string]*slog.Level
+			if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+				http.Error(w, fmt.Sprintf("bad request: %v", err), http.StatusBadRequest)
+				return
+			}
+			for category, level := range req {
+				if level == nil {
+					slogx.ResetLevel(category)
+				} else {
+					slogx.SetLevel(category, *level)
+				}
+			}
+		default:
+			w.Header().Set("Allow", "GET, HEAD, PUT")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(slogx.Levels())
+	})
+}
@@ -0,0 +1,79 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"github.com/rburchell/gosh/log/slogx"
+	"github.com/rburchell/gosh/log/slogx/slogxtest"
+	"github.com/rburchell/gosh/net/http/middleware"
+	"log/slog"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBuilder_EnableLevels(t *testing.T) {
+	defer slogx.Configure("")
+	guard := middleware.BasicAuth(func(user, pass string) bool { return user == "ops" && pass == "secret" })
+	handler := Build(nil).EnableLevels("/levels", guard).Build()
+
+	tests := []struct {
+		method, user string
+		wantCode     int
+	}{
+		{"PUT", "", 401},
+		{"PUT", "ops", 200},
+		{"GET", "ops", 200},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, "/levels", strings.NewReader(`{"srv-test":"debug"}`))
+		if tt.user != "" {
+			req.SetBasicAuth(tt.user, "secret")
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		if w.Code != tt.wantCode {
+			t.Errorf("%s as %q: code = %d, body = %s", tt.method, tt.user, w.Code, w.Body)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected a panic without a guard")
+		}
+	}()
+	Build(nil).EnableLevels("/levels", nil)
+}
+
+func TestLevelHandler(t *testing.T) {
+	defer slogx.Configure("")
+	slogx.NewCategory("lvl-db", slogxtest.NewHandler(), slog.LevelInfo)
+	slogx.NewCategory("lvl-http", slogxtest.NewHandler(), slog.LevelWarn)
+	slogx.Configure("lvl-http=error")
+
+	tests := []struct {
+		method, body string
+		wantCode     int
+		want         []string
+	}{
+		{"GET", "", 200, []string{`"lvl-db":"INFO"`, `"lvl-http":"ERROR"`}},
+		{"PUT", `{"lvl-db":"debug","lvl-http":null}`, 200, []string{`"lvl-db":"DEBUG"`, `"lvl-http":"WARN"`}},
+		{"PUT", `{"lvl-db":"loud"}`, 400, nil},
+		{"GET", "", 200, []string{`"lvl-db":"DEBUG"`}},
+		{"DELETE", "", 405, nil},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		levelHandler().ServeHTTP(w, httptest.NewRequest(tt.method, "/levels", strings.NewReader(tt.body)))
+		if w.Code != tt.wantCode || (w.Code == 200 && w.Header().Get("Content-Type") != "application/json") {
+			t.Errorf("%s %s: code = %d, body = %s", tt.method, tt.body, w.Code, w.Body)
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(w.Body.String(), want) {
+				t.Errorf("%s %s: body = %s, want %s", tt.method, tt.body, w.Body, want)
+			}
+		}
+	}
+}
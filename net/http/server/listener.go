@@ -0,0 +1,86 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+// Sets the permissions of unix sockets created for "unix://" addresses, e.g. 0660 to allow
+// a reverse proxy in the same group to connect. If not set, the umask decides.
+func (b *Builder) SocketMode(mode os.FileMode) *Builder {
+	b.socketMode = mode
+	return b
+}
+
+// Constructs the final http.Handler (i.e. does Build()), and serves it on l, e.g. a listener
+// passed in by systemd socket activation.
+//
+// It returns nil once Shutdown is called. l is closed when Serve returns.
+func (b *Builder) Serve(l net.Listener) error {
+	srv := b.newServer(l.Addr().String())
+	b.logHosting("http", l.Addr())
+	return b.serveAll(context.Background(), l, func() error { return srv.Serve(l) })
+}
+
+// The same as Run, but serves on l. See Serve.
+func (b *Builder) RunListener(ctx context.Context, l net.Listener) error {
+	srv := b.newServer(l.Addr().String())
+	b.logHosting("http", l.Addr())
+	return b.run(ctx, l, func() error { return srv.Serve(l) })
+}
+
+// Listens on addr, which is either a TCP address, or "unix://" followed by a socket path.
+//
+// A stale unix socket left behind by a previous run is removed, and the socket is removed again
+// when the listener is closed.
+func (b *Builder) listen(addr string) (net.Listener, error) {
+	path, ok := strings.CutPrefix(addr, "unix://")
+	if !ok {
+		if addr == "" {
+			addr = ":http"
+		}
+		return net.Listen("tcp", addr)
+	}
+
+	if err := removeStaleSocket(path); err != nil {
+		return nil, err
+	}
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if b.socketMode != 0 {
+		if err := os.Chmod(path, b.socketMode); err != nil {
+			ln.Close()
+			return nil, err
+		}
+	}
+	return ln, nil
+}
+
+// Removes the unix socket at path, if there is one, and nothing is listening on it.
+func removeStaleSocket(path string) error {
+	fi, err := os.Lstat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if fi.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("server: %s exists, and is not a socket", path)
+	}
+	if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+		conn.Close()
+		return fmt.Errorf("server: %s is in use by another process", path)
+	}
+	return os.Remove(path)
+}
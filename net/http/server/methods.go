@@ -0,0 +1,35 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package server
+
+import "net/http"
+
+// These register method-qualified patterns (e.g. "GET /users/{id}"), so handlers don't need to check r.Method.
+// Requests for a registered path with another method get a 405, with an Allow header listing the methods that are registered.
+
+// Adds a route for GET (and so HEAD) requests to path.
+func (b *Builder) Get(path string, handler http.HandlerFunc, opts ...RouteOption) *Builder {
+	return b.Handle(http.MethodGet+" "+path, handler, opts...)
+}
+
+// Adds a route for POST requests to path.
+func (b *Builder) Post(path string, handler http.HandlerFunc, opts ...RouteOption) *Builder {
+	return b.Handle(http.MethodPost+" "+path, handler, opts...)
+}
+
+// Adds a route for PUT requests to path.
+func (b *Builder) Put(path string, handler http.HandlerFunc, opts ...RouteOption) *Builder {
+	return b.Handle(http.MethodPut+" "+path, handler, opts...)
+}
+
+// Adds a route for PATCH requests to path.
+func (b *Builder) Patch(path string, handler http.HandlerFunc, opts ...RouteOption) *Builder {
+	return b.Handle(http.MethodPatch+" "+path, handler, opts...)
+}
+
+// Adds a route for DELETE requests to path.
+func (b *Builder) Delete(path string, handler http.HandlerFunc, opts ...RouteOption) *Builder {
+	return b.Handle(http.MethodDelete+" "+path, handler, opts...)
+}
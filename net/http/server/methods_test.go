@@ -0,0 +1,42 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBuilder_Methods(t *testing.T) {
+	echo := func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Method + " " + r.PathValue("id")))
+	}
+	handler := Build(nil).
+		Get("/items/{id}", echo).
+		Post("/items/{id}", echo).
+		Put("/items/{id}", echo).
+		Patch("/items/{id}", echo).
+		Delete("/items/{id}", echo).
+		Post("/only-post", echo).
+		Build()
+
+	for _, method := range []string{"GET", "POST", "PUT", "PATCH", "DELETE"} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(method, "/items/42", nil))
+		if want := method + " 42"; w.Code != http.StatusOK || w.Body.String() != want {
+			t.Errorf("%s: code = %d, body = %q", method, w.Code, w.Body.String())
+		}
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/only-post", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("code = %d, want 405", w.Code)
+	}
+	if allow := w.Header().Get("Allow"); allow != "POST" {
+		t.Errorf("Allow = %q, want POST", allow)
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Mounts handler at prefix: every request under prefix is passed to handler, with prefix
+// stripped from the path. So a handler serving "/users" mounted at "/admin" serves "/admin/users".
+//
+// handler may be another Builder, so that applications can be assembled from independently
+// developed route modules. In that case, only the mounted Builder's own middleware (see Use)
+// is applied to its routes, inside this Builder's; DefaultMiddleware is not applied twice.
+// Its NotFound and MethodNotAllowed handlers are used for requests under prefix.
+// It is built when it first serves a request, so it can still be changed after being mounted.
+//
+// For example:
+//
+//	admin := server.Build(nil).Use(requireAdmin).Get("/users", listUsers)
+//	server.Build(nil).Mount("/admin", admin).Run(ctx, ":8080")
+func (b *Builder) Mount(prefix string, handler http.Handler) *Builder {
+	prefix = strings.TrimSuffix(prefix, "/")
+	sub, ok := handler.(*Builder)
+	if !ok {
+		return b.Handle(prefix+"/", http.StripPrefix(b.prefix+prefix, handler))
+	}
+
+	// Record the mounted routes, rather than the mount itself.
+	b.handle(prefix+"/", http.StripPrefix(b.prefix+prefix, http.HandlerFunc(sub.serveMounted)))
+	for _, r := range sub.routes {
+		r.Pattern = joinPattern(b.prefix+prefix, r.Pattern)
+		b.routes = append(b.routes, r)
+	}
+	return b
+}
+
+// Serves r as a Builder mounted in another: like ServeHTTP, but without DefaultMiddleware.
+func (b *Builder) serveMounted(w http.ResponseWriter, r *http.Request) {
+	b.mountOnce.Do(func() {
+		b.mounted = b.chain.Then(b.withFallbacks(b.mux))
+	})
+	b.mounted.ServeHTTP(w, r)
+}
+
+// Builds the Builder's handler (if it isn't built yet), and serves r with it.
+// This allows a Builder to be used anywhere a http.Handler is wanted.
+func (b *Builder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	b.serveOnce.Do(func() {
+		b.serving = b.wrapped
+		if b.serving == nil {
+			b.serving = b.Build()
+		}
+	})
+	b.serving.ServeHTTP(w, r)
+}
@@ -0,0 +1,98 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBuilder_Mount(t *testing.T) {
+	var order []string
+	tag := func(name string) func(http.Handler) http.Handler {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+	echo := func(w http.ResponseWriter, r *http.Request) { w.Write([]byte(r.URL.Path)) }
+
+	admin := Build(nil).Use(tag("admin")).Get("/users", echo)
+	handler := Build(nil).Use(tag("root")).
+		Mount("/admin/", admin).
+		Mount("/static", http.HandlerFunc(echo)).
+		Group("/v1").Mount("/admin", admin).
+		Build()
+
+	tests := []struct {
+		path, wantBody, wantOrder string
+	}{
+		{"/admin/users", "/users", "root,admin"},
+		{"/static/css/site.css", "/css/site.css", "root"},
+		{"/v1/admin/users", "/users", "root,admin"},
+	}
+	for _, tt := range tests {
+		order = nil
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+		if w.Body.String() != tt.wantBody {
+			t.Errorf("%s: body = %q, want %q", tt.path, w.Body.String(), tt.wantBody)
+		}
+		if got := strings.Join(order, ","); got != tt.wantOrder {
+			t.Errorf("%s: middleware = %s, want %s", tt.path, got, tt.wantOrder)
+		}
+		if ids := w.Header().Values("X-Request-ID"); len(ids) != 1 {
+			t.Errorf("%s: got %d request IDs, want 1", tt.path, len(ids))
+		}
+	}
+}
+
+func TestBuilder_ServeHTTP(t *testing.T) {
+	b := Build(nil).HandleFunc("/ping", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("pong"))
+	})
+	w := httptest.NewRecorder()
+	b.ServeHTTP(w, httptest.NewRequest("GET", "/ping", nil))
+	if w.Body.String() != "pong" {
+		t.Errorf("body = %q", w.Body.String())
+	}
+}
+
+func TestBuilder_MountLate(t *testing.T) {
+	admin := Build(nil).Get("/users", func(w http.ResponseWriter, r *http.Request) {})
+	handler := Build(nil).Mount("/admin", admin).Build()
+
+	// Changes made to admin after mounting it still apply.
+	admin.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Admin", "1")
+			next.ServeHTTP(w, r)
+		})
+	})
+	admin.NotFound(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "no such admin page", http.StatusNotFound)
+	}))
+
+	tests := []struct {
+		method, path string
+		wantCode     int
+		wantBody     string
+	}{
+		{"GET", "/admin/users", 200, ""},
+		{"GET", "/admin/nope", 404, "no such admin page\n"},
+		{"POST", "/admin/users", 405, "Method Not Allowed\n"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+		if w.Code != tt.wantCode || w.Body.String() != tt.wantBody || w.Header().Get("X-Admin") != "1" {
+			t.Errorf("%s %s: code = %d, body = %q, X-Admin = %q", tt.method, tt.path, w.Code, w.Body.String(), w.Header().Get("X-Admin"))
+		}
+	}
+}
@@ -0,0 +1,45 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"net"
+	"net/http"
+)
+
+type extraListener struct {
+	addr    string
+	handler http.Handler
+}
+
+// Also serves handler on addr (which may be a "unix://" address), whenever the Builder is served
+// (by ListenAndServe, Run, Serve, etc). If handler is nil, the Builder's own handler is used.
+//
+// This allows e.g. an admin interface on a separate, internal-only port:
+//
+//	admin := server.Build(nil).EnableDebug("/debug").Get("/status", status)
+//	server.Build(nil).
+//	HandleFunc("/", home).
+//	AlsoServe("127.0.0.1:9090", admin).
+//	Run(ctx, ":8080")
+//
+// The servers run together: if any fails, the others are shut down, and the error is returned.
+func (b *Builder) AlsoServe(addr string, handler http.Handler) *Builder {
+	b.extra = append(b.extra, extraListener{addr: addr, handler: handler})
+	return b
+}
+
+// Returns a function to serve extra on ln.
+func (b *Builder) serveExtra(extra extraListener, ln net.Listener) func() error {
+	var srv *http.Server
+	if extra.handler == nil {
+		srv = b.newServer(ln.Addr().String())
+	} else {
+		srv = b.newServerFor(ln.Addr().String(), extra.handler)
+	}
+	b.stats.listening(ln.Addr())
+	b.logHosting("http", ln.Addr())
+	return func() error { return srv.Serve(ln) }
+}
@@ -0,0 +1,92 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBuilder_AlsoServe(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "admin.sock")
+	admin := Build(nil).HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+	b := Build(nil).
+		HandleFunc("/", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("main")) }).
+		AlsoServe("unix://"+sock, admin)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- b.Serve(ln) }()
+
+	get := func(client *http.Client, url string) string {
+		t.Helper()
+		var resp *http.Response
+		var err error
+		for range 100 {
+			if resp, err = client.Get(url); err == nil {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return string(body)
+	}
+
+	if got := get(http.DefaultClient, "http://"+ln.Addr().String()+"/"); got != "main" {
+		t.Errorf("main = %q", got)
+	}
+	unixClient := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", sock)
+		},
+	}}
+	if got := get(unixClient, "http://unix/status"); got != "ok" {
+		t.Errorf("admin = %q", got)
+	}
+
+	// Shutting down stops both
+	b.Shutdown(context.Background())
+	if err := <-done; err != nil {
+		t.Errorf("Serve = %v", err)
+	}
+}
+
+func TestBuilder_AlsoServeFails(t *testing.T) {
+	busy, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer busy.Close()
+
+	sock := filepath.Join(t.TempDir(), "admin.sock")
+	b := Build(nil).AlsoServe("unix://"+sock, nil).AlsoServe(busy.Addr().String(), nil)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Serve(ln); err == nil {
+		t.Fatalf("expected error when an extra address is in use")
+	}
+
+	// The extra listener opened before the failure is closed too.
+	if conn, err := net.Dial("unix", sock); err == nil {
+		conn.Close()
+		t.Errorf("%s is still listening", sock)
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"context"
+	"errors"
+	"github.com/rburchell/gosh/net/http/middleware"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"slices"
+	"time"
+)
+
+// Configures Proxy and NewProxy.
+type ProxyOptions struct {
+	// Removed from the start of the request path before proxying, e.g. "/api".
+	StripPrefix string
+
+	// How long the upstream has to respond. If zero, there is no limit (beyond the client going away).
+	Timeout time.Duration
+
+	// Headers set on requests to the upstream.
+	RequestHeaders http.Header
+
+	// Headers set on responses from the upstream.
+	ResponseHeaders http.Header
+
+	// If set, the Host header of the client request is passed on, rather than the upstream's host.
+	PreserveHost bool
+
+	// The transport to use. If nil, http.DefaultTransport is used.
+	// middleware.TracingTransport, or a middleware.CircuitBreaker's Transport, are useful here.
+	Transport http.RoundTripper
+}
+
+// Adds a route for pattern, which proxies requests to upstream (see NewProxy).
+// It panics if upstream isn't a valid absolute URL.
+//
+// For example:
+//
+//	b.Proxy("/api/", "http://127.0.0.1:9000/v1", server.ProxyOptions{StripPrefix: "/api", Timeout: 10 * time.Second})
+func (b *Builder) Proxy(pattern string, upstream string, opts ProxyOptions) *Builder {
+	u, err := url.Parse(upstream)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		panic("server: bad proxy upstream " + upstream)
+	}
+	return b.Handle(pattern, NewProxy(u, opts))
+}
+
+// Returns a handler which proxies requests to upstream, with the request path appended
+// to upstream's path, and X-Forwarded-* headers set.
+//
+// If the upstream can't be reached, the client gets a 502 (or 504, if it timed out), and the error is logged.
+func NewProxy(upstream *url.URL, opts ProxyOptions) http.Handler {
+	proxy := &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(upstream)
+			pr.SetXForwarded()
+			if opts.PreserveHost {
+				pr.Out.Host = pr.In.Host
+			}
+			for k, v := range opts.RequestHeaders {
+				pr.Out.Header[k] = slices.Clone(v)
+			}
+		},
+		Transport: opts.Transport,
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			status := http.StatusBadGateway
+			if errors.Is(err, context.DeadlineExceeded) {
+				status = http.StatusGatewayTimeout
+			}
+			rid, _ := middleware.RequestID(r)
+			log.WarnContext(r.Context(), "Proxy error", "upstream", upstream.Host, "path", r.URL.Path, "rid", rid, "err", err)
+			http.Error(w, http.StatusText(status), status)
+		},
+	}
+	if len(opts.ResponseHeaders) > 0 {
+		proxy.ModifyResponse = func(resp *http.Response) error {
+			for k, v := range opts.ResponseHeaders {
+				resp.Header[k] = slices.Clone(v)
+			}
+			return nil
+		}
+	}
+
+	var handler http.Handler = proxy
+	if opts.Timeout > 0 {
+		handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), opts.Timeout)
+			defer cancel()
+			proxy.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+	if opts.StripPrefix != "" {
+		handler = http.StripPrefix(opts.StripPrefix, handler)
+	}
+	return handler
+}
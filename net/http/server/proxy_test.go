@@ -0,0 +1,60 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestBuilder_Proxy(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/slow" {
+			time.Sleep(100 * time.Millisecond)
+		}
+		w.Write([]byte(r.URL.Path + " " + r.Header.Get("X-Api-Key") + " " + r.Header.Get("X-Forwarded-Host")))
+	}))
+	defer upstream.Close()
+
+	b := Build(nil).
+		Proxy("/api/", upstream.URL+"/v1", ProxyOptions{
+			StripPrefix:     "/api",
+			Timeout:         50 * time.Millisecond,
+			RequestHeaders:  http.Header{"X-Api-Key": {"secret"}},
+			ResponseHeaders: http.Header{"X-Proxied": {"yes"}},
+		}).
+		Proxy("/dead/", "http://127.0.0.1:1", ProxyOptions{})
+
+	tests := []struct {
+		path     string
+		wantCode int
+		wantBody string
+	}{
+		{"/api/users", 200, "/v1/users secret example.com"},
+		{"/api/slow", 504, "Gateway Timeout\n"},
+		{"/dead/x", 502, "Bad Gateway\n"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		b.ServeHTTP(w, httptest.NewRequest("GET", "http://example.com"+tt.path, nil))
+		if w.Code != tt.wantCode || w.Body.String() != tt.wantBody {
+			t.Errorf("%s: code = %d, body = %q", tt.path, w.Code, w.Body.String())
+		}
+		if tt.wantCode == 200 && w.Header().Get("X-Proxied") != "yes" {
+			t.Errorf("%s: response header not set", tt.path)
+		}
+	}
+}
+
+func TestBuilder_ProxyBadUpstream(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected panic")
+		}
+	}()
+	Build(nil).Proxy("/", "not a url", ProxyOptions{})
+}
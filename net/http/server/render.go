@@ -0,0 +1,53 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+)
+
+// The body written by Error.
+//
+// It is compatible with the {"error": "message"} bodies written by bind.Handler and the middleware package,
+// with an optional machine-readable code.
+type ErrorBody struct {
+	Error string `json:"error"`
+	Code  string `json:"code,omitempty"`
+}
+
+// If set, called for every response written by Error, e.g. to log or count errors.
+var OnError func(status int, code, message string)
+
+// Writes v as JSON, with the given status.
+//
+// v is encoded before anything is written, so if encoding fails,
+// the client gets a 500 instead of a truncated body.
+func JSON(w http.ResponseWriter, status int, v any) {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(v); err != nil {
+		log.Error("Failed to encode JSON response", "err", err)
+		Error(w, http.StatusInternalServerError, "", http.StatusText(http.StatusInternalServerError))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	w.Write(buf.Bytes())
+}
+
+// Writes an error as JSON, with the given status, in the form {"error": "message", "code": "code"}.
+// code is a machine-readable error code, and is omitted if empty.
+//
+// For example:
+//
+//	server.Error(w, http.StatusNotFound, "user_not_found", "no such user")
+func Error(w http.ResponseWriter, status int, code, message string) {
+	if OnError != nil {
+		OnError(status, code, message)
+	}
+	JSON(w, status, ErrorBody{Error: message, Code: code})
+}
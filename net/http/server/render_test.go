@@ -0,0 +1,50 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestJSON(t *testing.T) {
+	w := httptest.NewRecorder()
+	JSON(w, http.StatusCreated, map[string]int{"id": 1})
+	if w.Code != http.StatusCreated || w.Body.String() != "{\"id\":1}\n" {
+		t.Errorf("code = %d, body = %q", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+
+	// Unencodable values become a 500
+	w = httptest.NewRecorder()
+	JSON(w, http.StatusOK, func() {})
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("code = %d, want 500", w.Code)
+	}
+}
+
+func TestError(t *testing.T) {
+	var hooked int
+	OnError = func(status int, code, message string) { hooked = status }
+	defer func() { OnError = nil }()
+
+	w := httptest.NewRecorder()
+	Error(w, http.StatusNotFound, "user_not_found", "no such user")
+	if want := "{\"error\":\"no such user\",\"code\":\"user_not_found\"}\n"; w.Body.String() != want {
+		t.Errorf("body = %q, want %q", w.Body.String(), want)
+	}
+	if w.Code != http.StatusNotFound || hooked != http.StatusNotFound {
+		t.Errorf("code = %d, hooked = %d", w.Code, hooked)
+	}
+
+	w = httptest.NewRecorder()
+	Error(w, http.StatusBadRequest, "", "bad")
+	if want := "{\"error\":\"bad\"}\n"; w.Body.String() != want {
+		t.Errorf("body = %q, want %q", w.Body.String(), want)
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"context"
+	"github.com/rburchell/gosh/net/http/middleware"
+	"net/http"
+	"time"
+)
+
+// A RouteOption configures a single route, when passed to Handle, HandleFunc, Get, etc.
+//
+// For example, to let an upload route take longer, and accept larger bodies, than the rest:
+//
+//	b.RouteDefaults(server.WithTimeout(10*time.Second), server.WithMaxBodySize(1<<20))
+//	b.Post("/upload", upload, server.WithTimeout(5*time.Minute), server.WithMaxBodySize(100<<20))
+type RouteOption func(*routeConfig)
+
+type routeConfig struct {
+	timeout     time.Duration
+	maxBodySize int64
+	middleware  []func(http.Handler) http.Handler
+	noLog       bool
+}
+
+// Limits how long the route's requests may take. The request context is cancelled after d,
+// and the connection's read and write deadlines are set to match, overriding the server's.
+// Zero means no limit.
+func WithTimeout(d time.Duration) RouteOption {
+	return func(c *routeConfig) { c.timeout = d }
+}
+
+// Limits the size of the route's request bodies to n bytes; reading more fails, and the
+// client gets a 413 if the handler then writes an error. Zero means no limit.
+func WithMaxBodySize(n int64) RouteOption {
+	return func(c *routeConfig) { c.maxBodySize = n }
+}
+
+// Wraps the route in mw, in order (the first is outermost), inside any Builder or group middleware.
+func WithMiddleware(mw ...func(http.Handler) http.Handler) RouteOption {
+	return func(c *routeConfig) { c.middleware = append(c.middleware, mw...) }
+}
+
+// Sets options applied to every route added after this, before the route's own options
+// (which can so override them).
+func (b *Builder) RouteDefaults(opts ...RouteOption) *Builder {
+	b.routeDefaults = append(b.routeDefaults, opts...)
+	return b
+}
+
+// Wraps handler as configured by the route defaults, then opts.
+func (b *Builder) applyRouteOptions(handler http.Handler, opts []RouteOption) http.Handler {
+	var cfg routeConfig
+	for _, opt := range b.routeDefaults {
+		opt(&cfg)
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	for i := len(cfg.middleware) - 1; i >= 0; i-- {
+		handler = cfg.middleware[i](handler)
+	}
+	if cfg.maxBodySize > 0 {
+		next, limit := handler, cfg.maxBodySize
+		handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > limit {
+				http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, limit)
+			next.ServeHTTP(w, r)
+		})
+	}
+	if cfg.noLog {
+		next := handler
+		handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			middleware.SkipLogging(w)
+			next.ServeHTTP(w, r)
+		})
+	}
+	if cfg.timeout > 0 {
+		next, timeout := handler, cfg.timeout
+		handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			deadline := time.Now().Add(timeout)
+			// Not all ResponseWriters support deadlines (e.g. in tests); the context still applies.
+			rc := http.NewResponseController(w)
+			rc.SetReadDeadline(deadline)
+			rc.SetWriteDeadline(deadline)
+			ctx, cancel := context.WithDeadline(r.Context(), deadline)
+			defer cancel()
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+	return handler
+}
+
+// Stops the route's requests from being logged by middleware.LogRequests (which is part of
+// DefaultMiddleware), e.g. for health checks and metrics.
+func WithoutLogging() RouteOption {
+	return func(c *routeConfig) { c.noLog = true }
+}
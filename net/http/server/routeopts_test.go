@@ -0,0 +1,86 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"bytes"
+	"github.com/rburchell/gosh/net/http/middleware"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRouteOptions(t *testing.T) {
+	readAll := func(w http.ResponseWriter, r *http.Request) {
+		if _, err := io.ReadAll(r.Body); err != nil {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		deadline, _ := r.Context().Deadline()
+		w.Write([]byte(time.Until(deadline).Round(time.Minute).String()))
+	}
+	var tagged bool
+	tag := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tagged = true
+			next.ServeHTTP(w, r)
+		})
+	}
+
+	b := Build(nil).
+		RouteDefaults(WithTimeout(time.Minute), WithMaxBodySize(10)).
+		Post("/small", readAll).
+		Post("/upload", readAll, WithTimeout(5*time.Minute), WithMaxBodySize(100), WithMiddleware(tag))
+
+	tests := []struct {
+		path     string
+		size     int
+		wantCode int
+		wantBody string
+	}{
+		{"/small", 5, 200, "1m0s"},
+		{"/small", 50, 413, ""},
+		{"/upload", 50, 200, "5m0s"},
+		{"/upload", 500, 413, ""},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		// Hide the length, so the limit is hit while reading.
+		body := io.MultiReader(strings.NewReader(strings.Repeat("x", tt.size)))
+		b.ServeHTTP(w, httptest.NewRequest("POST", tt.path, body))
+		if w.Code != tt.wantCode || (tt.wantBody != "" && w.Body.String() != tt.wantBody) {
+			t.Errorf("%s with %d bytes: code = %d, body = %q", tt.path, tt.size, w.Code, w.Body.String())
+		}
+	}
+	if !tagged {
+		t.Errorf("route middleware not applied")
+	}
+
+	// A declared length over the limit is rejected up front
+	w := httptest.NewRecorder()
+	b.ServeHTTP(w, httptest.NewRequest("POST", "/small", strings.NewReader(strings.Repeat("x", 50))))
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("code = %d, want 413", w.Code)
+	}
+}
+
+func TestRouteOptions_WithoutLogging(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+	b := Build(nil).WithoutDefaults().Use(middleware.LogRequestsWith(middleware.LogOptions{Logger: logger})).
+		Get("/healthz", ok, WithoutLogging()).
+		Get("/logged", ok)
+
+	b.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/healthz", nil))
+	b.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/logged", nil))
+	if got := buf.String(); strings.Count(got, "msg=Finished") != 1 || !strings.Contains(got, "path=/logged") {
+		t.Errorf("got:\n%s", got)
+	}
+}
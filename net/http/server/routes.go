@@ -0,0 +1,61 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// Describes a route added to a Builder.
+type RouteInfo struct {
+	// The full pattern, including any group or mount prefix, e.g. "GET /api/v1/users/{id}".
+	Pattern string `json:"pattern"`
+
+	// The methods the route matches, or nil if it matches any method.
+	Methods []string `json:"methods,omitempty"`
+
+	// The name of the handler: the function name for a http.HandlerFunc, otherwise its type.
+	Handler string `json:"handler"`
+}
+
+func newRouteInfo(pattern string, handler http.Handler) RouteInfo {
+	info := RouteInfo{Pattern: pattern, Handler: handlerName(handler)}
+	if method, _, found := strings.Cut(pattern, " "); found {
+		info.Methods = []string{method}
+		if method == http.MethodGet {
+			info.Methods = append(info.Methods, http.MethodHead)
+		}
+	}
+	return info
+}
+
+// Returns a readable name for h.
+func handlerName(h http.Handler) string {
+	if f, ok := h.(http.HandlerFunc); ok {
+		if fn := runtime.FuncForPC(reflect.ValueOf(f).Pointer()); fn != nil {
+			return fn.Name()
+		}
+	}
+	return fmt.Sprintf("%T", h)
+}
+
+// Returns the routes added so far, in the order they were added.
+func (b *Builder) Routes() []RouteInfo {
+	return append([]RouteInfo(nil), b.routes...)
+}
+
+// Adds a GET route at path, which lists all routes (see Routes) as JSON.
+//
+// The route table can reveal more about an application than you may want public,
+// so consider wrapping it (e.g. with middleware.IPFilter), or only enabling it in development.
+func (b *Builder) EnableRoutes(path string) *Builder {
+	return b.Get(path, func(w http.ResponseWriter, r *http.Request) {
+		JSON(w, http.StatusOK, b.Routes())
+	})
+}
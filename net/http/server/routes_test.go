@@ -0,0 +1,56 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func listUsers(w http.ResponseWriter, r *http.Request) {}
+
+func TestBuilder_Routes(t *testing.T) {
+	admin := Build(nil).Post("/reindex", listUsers)
+	b := Build(nil).
+		HandleFunc("/ping", listUsers).
+		Handle("/files/", http.FileServer(http.Dir("."))).
+		Mount("/admin", admin).
+		EnableRoutes("/_routes")
+	b.Group("/api").Get("/users", listUsers)
+
+	var patterns []string
+	for _, r := range b.Routes() {
+		patterns = append(patterns, r.Pattern)
+	}
+	want := []string{"/ping", "/files/", "POST /admin/reindex", "GET /_routes", "GET /api/users"}
+	if !reflect.DeepEqual(patterns, want) {
+		t.Errorf("patterns = %v, want %v", patterns, want)
+	}
+
+	routes := b.Routes()
+	if !strings.HasSuffix(routes[0].Handler, ".listUsers") || routes[0].Methods != nil {
+		t.Errorf("routes[0] = %+v", routes[0])
+	}
+	if routes[1].Handler != "*http.fileHandler" {
+		t.Errorf("routes[1].Handler = %q", routes[1].Handler)
+	}
+	if !reflect.DeepEqual(routes[4].Methods, []string{"GET", "HEAD"}) {
+		t.Errorf("routes[4].Methods = %v", routes[4].Methods)
+	}
+
+	w := httptest.NewRecorder()
+	b.ServeHTTP(w, httptest.NewRequest("GET", "/_routes", nil))
+	var got []RouteInfo
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(want) {
+		t.Errorf("/_routes returned %d routes, want %d", len(got), len(want))
+	}
+}
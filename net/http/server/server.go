@@ -18,24 +18,85 @@
 //	ListenAndServeOrDie(":8080")
 //
 // The snippet above will respond to /ping on :8080, otherwise, terminate if it can't listen.
+//
+// For production use, Run serves until interrupted (by SIGINT or SIGTERM),
+// then waits for in-flight requests to finish:
+//
+//	err := server.Build(nil).
+//	HandleFunc("/ping", handlePingPong).
+//	Run(context.Background(), ":8080")
 package server
 
 import (
+	"context"
+	"crypto/tls"
+	"errors"
 	"github.com/rburchell/gosh/log/slogx"
 	"github.com/rburchell/gosh/net/http/middleware"
 	"log/slog"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
 	"strings"
+	"sync"
+	"syscall"
+	"time"
 )
 
 var log *slog.Logger = slogx.NewCategory("http", slogx.TextHandler, slog.LevelDebug)
 
+// The middleware applied to all routes, outside any added with Use or With, unless WithoutDefaults is used.
+var DefaultMiddleware = middleware.Chain{middleware.TagWithRequestID, middleware.LogRequests}
+
 // Builds a http.Handler, and optionally serves it.
 type Builder struct {
-	mux     *http.ServeMux
-	routes  []any
-	wrapped http.Handler
+	*shared
+
+	// The middleware added with Use; for a group, only the group's own middleware.
+	chain middleware.Chain
+
+	// For a group, the Builder it came from, and its full path prefix.
+	parent *Builder
+	prefix string
+}
+
+// The state shared between a Builder and its groups.
+type shared struct {
+	mux        *http.ServeMux
+	routes     []RouteInfo
+	noDefaults bool
+	wrapped    http.Handler
+
+	// What ServeHTTP and Mount serve, built on first use.
+	serveOnce sync.Once
+	serving   http.Handler
+	mountOnce sync.Once
+	mounted   http.Handler
+
+	config          *Config
+	shutdownTimeout time.Duration
+	readTimeout     time.Duration
+	writeTimeout    time.Duration
+	idleTimeout     time.Duration
+	socketMode      os.FileMode
+	notFound        http.Handler
+	notAllowed      http.Handler
+	h2c             bool
+	http2           *http.HTTP2Config
+
+	tlsConfig   *tls.Config
+	certManager CertManager
+	acmeAddr    string
+
+	extra         []extraListener
+	routeDefaults []RouteOption
+	onStart       []func(addr net.Addr) error
+	onStop        []func(ctx context.Context) error
+
+	stats   serverStats
+	mu      sync.Mutex
+	servers []*http.Server
 }
 
 // Starts a Builder using the base 'mux'. If nil is provided, uses http.NewServeMux().
@@ -43,19 +104,128 @@ func Build(mux *http.ServeMux) *Builder {
 	if mux == nil {
 		mux = http.NewServeMux()
 	}
-	return &Builder{mux: mux}
+	return &Builder{shared: &shared{mux: mux, shutdownTimeout: 30 * time.Second}}
 }
 
 // Adds a single route (pattern and handler) to the Builder.
-func (b *Builder) Handle(pattern string, handler http.Handler) *Builder {
-	b.mux.Handle(pattern, handler)
-	b.routes = append(b.routes, pattern)
+//
+// On a group, the pattern is prefixed with the group's prefix, and the handler is wrapped
+// in the group's middleware.
+//
+// opts configure just this route; see RouteOption.
+func (b *Builder) Handle(pattern string, handler http.Handler, opts ...RouteOption) *Builder {
+	b.handle(pattern, b.applyRouteOptions(handler, opts))
+	b.routes = append(b.routes, newRouteInfo(b.fullPattern(pattern), handler))
+	return b
+}
+
+// Registers handler for pattern, without recording the route.
+func (b *Builder) handle(pattern string, handler http.Handler) {
+	if b.parent != nil {
+		handler = b.groupHandler(handler)
+	}
+	b.mux.Handle(b.fullPattern(pattern), handler)
+}
+
+// Returns pattern, with the group prefix (if any) inserted.
+func (b *Builder) fullPattern(pattern string) string {
+	if b.parent == nil {
+		return pattern
+	}
+	return joinPattern(b.prefix, pattern)
+}
+
+func (b *Builder) HandleFunc(pattern string, handler http.HandlerFunc, opts ...RouteOption) *Builder {
+	return b.Handle(pattern, handler, opts...)
+}
+
+// Returns a sub-builder for a group of routes, whose patterns are all prefixed with prefix,
+// and which can have its own middleware (see Use), applied inside that of its parent.
+// Groups can be nested.
+//
+// The group shares everything else (the mux, and server configuration) with its parent;
+// Build, and serving, can be done through either.
+//
+// For example:
+//
+//	b := server.Build(nil)
+//	api := b.Group("/api/v1")
+//	api.HandleFunc("GET /users", listUsers) // GET /api/v1/users
+//	admin := api.Group("/admin").Use(requireAdmin)
+//	admin.HandleFunc("POST /reindex", reindex) // POST /api/v1/admin/reindex, for admins only
+func (b *Builder) Group(prefix string) *Builder {
+	return &Builder{
+		shared: b.shared,
+		parent: b,
+		prefix: b.prefix + strings.TrimSuffix(prefix, "/"),
+	}
+}
+
+// Wraps handler in the middleware of this group, and of the groups it is nested in.
+// The middleware is resolved on first use, so Use applies to routes added before it, too.
+func (b *Builder) groupHandler(handler http.Handler) http.Handler {
+	var once sync.Once
+	var wrapped http.Handler
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		once.Do(func() {
+			var chain middleware.Chain
+			for g := b; g.parent != nil; g = g.parent {
+				chain = g.chain.Extend(chain)
+			}
+			wrapped = chain.Then(handler)
+		})
+		wrapped.ServeHTTP(w, r)
+	})
+}
+
+// Inserts prefix into a mux pattern, after any method and host.
+func joinPattern(prefix, pattern string) string {
+	method, rest, found := strings.Cut(pattern, " ")
+	if !found {
+		method, rest = "", pattern
+	} else {
+		method += " "
+		rest = strings.TrimLeft(rest, " \t")
+	}
+	i := strings.Index(rest, "/")
+	if i < 0 {
+		return method + rest + prefix
+	}
+	return method + rest[:i] + prefix + rest[i:]
+}
+
+// Adds mw, to be applied to all routes, in order (the first is outermost).
+// On a group, they only apply to the group's routes.
+//
+// They are placed inside the DefaultMiddleware (request IDs and logging),
+// so e.g. requests they reject are still logged.
+//
+// For example:
+//
+//	server.Build(nil).
+//	Use(recovery, cors, metrics).
+//	HandleFunc("/ping", handlePingPong)
+func (b *Builder) Use(mw ...func(http.Handler) http.Handler) *Builder {
+	b.chain = b.chain.Append(mw...)
 	return b
 }
 
-func (b *Builder) HandleFunc(pattern string, handler http.HandlerFunc) *Builder {
-	b.mux.Handle(pattern, handler)
-	b.routes = append(b.routes, pattern)
+// Adds the middleware in c, to be applied to all routes. See Use.
+func (b *Builder) With(c middleware.Chain) *Builder {
+	return b.Use(c...)
+}
+
+// Stops DefaultMiddleware from being applied, so that the application has full control of the order
+// of middleware (e.g. to put recovery outside of logging). They can be added back explicitly with Use.
+func (b *Builder) WithoutDefaults() *Builder {
+	b.noDefaults = true
+	return b
+}
+
+// Sets how long Shutdown (and so Run) waits for in-flight requests to finish,
+// before closing their connections. The default is 30 seconds.
+func (b *Builder) ShutdownTimeout(d time.Duration) *Builder {
+	b.shutdownTimeout = d
 	return b
 }
 
@@ -63,33 +233,197 @@ func (b *Builder) HandleFunc(pattern string, handler http.HandlerFunc) *Builder
 //
 // If you want to use it right away, ListenAndServeOrDie might be useful.
 func (b *Builder) Build() http.Handler {
-	// Wrap in middleware.
-	// Remember that these are called bottom-up.. Order matters.
-	var wrapped http.Handler = b.mux
-	wrapped = middleware.LogRequests(wrapped)
-	wrapped = middleware.TagWithRequestID(wrapped)
+	if b.parent != nil {
+		return b.parent.Build()
+	}
+
+	// Wrap in middleware. Order matters: the first in the chain sees the request first.
+	chain := b.chain
+	if !b.noDefaults {
+		chain = DefaultMiddleware.Extend(chain)
+	}
+	wrapped := chain.Then(b.withFallbacks(b.mux))
 	b.wrapped = wrapped
 	return wrapped
 }
 
 // Constructs the final http.Handler (i.e. does Build()), and listens to the provided addr.
+//
+// addr is either a TCP address (e.g. ":8080"), or a unix socket path (e.g. "unix:///run/app.sock").
+//
+// It returns nil once Shutdown is called.
 func (b *Builder) ListenAndServe(addr string) error {
-	if b.wrapped == nil {
-		b.Build()
-	}
-	friendlyAddr := addr
-	if strings.HasPrefix(addr, ":") {
-		friendlyAddr = "localhost" + addr + " (on all interfaces)"
+	ln, err := b.listen(addr)
+	if err != nil {
+		return err
 	}
-	log.Debug("Hosting routes", "count", len(b.routes), "addr", "http://"+friendlyAddr)
-	return http.ListenAndServe(addr, b.wrapped)
+	return b.Serve(ln)
 }
 
 // The same as ListenAndServe, but fatally exits if ListenAndServe returns an error.
 func (b *Builder) ListenAndServeOrDie(addr string) {
 	err := b.ListenAndServe(addr)
 	if err != nil {
-		log.Error(err.Error())
-		os.Exit(1)
+		slogx.Fatal(log, err.Error())
+	}
+}
+
+// Constructs the final http.Handler (i.e. does Build()), and serves it on addr,
+// until ctx is done, or the process gets SIGINT or SIGTERM.
+//
+// It then stops accepting connections, and waits for in-flight requests to finish (see ShutdownTimeout).
+// A second signal during that wait kills the process as usual.
+//
+// It returns nil if the server was shut down cleanly.
+func (b *Builder) Run(ctx context.Context, addr string) error {
+	ln, err := b.listen(addr)
+	if err != nil {
+		return err
+	}
+	return b.RunListener(ctx, ln)
+}
+
+// Serves using serve (see serveAll), until ctx is done or a signal arrives, then shuts down.
+func (b *Builder) run(ctx context.Context, main net.Listener, serve func() error) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	// Restore default signal behaviour once we start shutting down, so a second signal kills us.
+	context.AfterFunc(ctx, stop)
+	return b.serveAll(ctx, main, serve)
+}
+
+// Serves using serve (which serves on main), and on any listeners added with AlsoServe,
+// until one of them stops, or ctx is done. Then everything is shut down.
+func (b *Builder) serveAll(ctx context.Context, main net.Listener, serve func() error) error {
+	b.stats.start(main.Addr())
+
+	// Open every listener before serving any, so a failure leaves nothing behind.
+	var extras []net.Listener
+	for _, extra := range b.extra {
+		ln, err := b.listen(extra.addr)
+		if err != nil {
+			for _, ln := range extras {
+				ln.Close()
+			}
+			main.Close()
+			b.Shutdown(context.Background())
+			return err
+		}
+		extras = append(extras, ln)
+	}
+	serves := []func() error{serve}
+	for i, extra := range b.extra {
+		serves = append(serves, b.serveExtra(extra, extras[i]))
+	}
+
+	errc := make(chan error, len(serves))
+	for _, s := range serves {
+		go func() { errc <- serveErr(s()) }()
+	}
+
+	var err error
+	pending := len(serves)
+	if err = b.started(main.Addr()); err == nil {
+		select {
+		case err = <-errc:
+			// One failed (or Shutdown was called); take the others down with it.
+			pending--
+		case <-ctx.Done():
+			log.Info("Shutting down", "timeout", b.shutdownTimeout)
+		}
+	}
+
+	if serr := b.Shutdown(context.Background()); err == nil {
+		err = serr
+	}
+	for ; pending > 0; pending-- {
+		if serr := <-errc; err == nil {
+			err = serr
+		}
+	}
+	if serr := b.stopped(); err == nil {
+		err = serr
+	}
+	b.stats.stop(false)
+	return err
+}
+
+// Gracefully stops servers started by ListenAndServe, Run, or their TLS variants: they stop accepting connections,
+// and waits for in-flight requests to finish, for up to the ShutdownTimeout (or until ctx is done).
+// Connections still open after that are closed, and an error is returned.
+func (b *Builder) Shutdown(ctx context.Context) error {
+	b.mu.Lock()
+	servers := b.servers
+	b.servers = nil
+	b.mu.Unlock()
+	b.stats.stop(true)
+
+	ctx, cancel := context.WithTimeout(ctx, b.shutdownTimeout)
+	defer cancel()
+	var errs []error
+	for _, srv := range servers {
+		if err := srv.Shutdown(ctx); err != nil {
+			srv.Close()
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Builds (if needed), and creates a http.Server that Shutdown will stop.
+func (b *Builder) newServer(addr string) *http.Server {
+	if b.wrapped == nil {
+		b.Build()
+	}
+	srv := b.newServerFor(addr, b.wrapped)
+	if b.h2c {
+		srv.Protocols = new(http.Protocols)
+		srv.Protocols.SetHTTP1(true)
+		srv.Protocols.SetHTTP2(true)
+		srv.Protocols.SetUnencryptedHTTP2(true)
+	}
+	return srv
+}
+
+// Creates a plain http.Server for handler, that Shutdown will stop.
+func (b *Builder) newServerFor(addr string, handler http.Handler) *http.Server {
+	return b.track(&http.Server{
+		Addr:         addr,
+		Handler:      b.stats.count(handler),
+		ConnState:    b.stats.connState,
+		HTTP2:        b.http2,
+		ReadTimeout:  b.readTimeout,
+		WriteTimeout: b.writeTimeout,
+		IdleTimeout:  b.idleTimeout,
+	})
+}
+
+// Registers srv to be stopped by Shutdown.
+func (b *Builder) track(srv *http.Server) *http.Server {
+	b.mu.Lock()
+	b.servers = append(b.servers, srv)
+	b.mu.Unlock()
+	return srv
+}
+
+func (b *Builder) logHosting(scheme string, addr net.Addr) {
+	friendlyAddr := scheme + "://" + addr.String()
+	if addr.Network() == "unix" {
+		friendlyAddr = "unix://" + addr.String()
+	} else if strings.HasPrefix(addr.String(), "[::]:") || strings.HasPrefix(addr.String(), "0.0.0.0:") {
+		_, port, _ := net.SplitHostPort(addr.String())
+		friendlyAddr = scheme + "://localhost:" + port + " (on all interfaces)"
+	}
+	log.Debug("Hosting routes", "count", len(b.routes), "addr", friendlyAddr)
+	for _, r := range b.routes {
+		log.Debug("Route", "pattern", r.Pattern, "handler", r.Handler)
+	}
+}
+
+// Returns err, unless it just means that the server was shut down.
+func serveErr(err error) error {
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
 	}
+	return err
 }
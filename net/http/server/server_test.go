@@ -5,9 +5,15 @@
 package server
 
 import (
+	"context"
+	"github.com/rburchell/gosh/net/http/middleware"
+	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestBuilder_HandleFunc(t *testing.T) {
@@ -29,3 +35,146 @@ func TestBuilder_HandleFunc(t *testing.T) {
 		t.Fatalf(`expected body "pong", got %q`, body)
 	}
 }
+
+func TestBuilder_With(t *testing.T) {
+	deny := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "nope", http.StatusForbidden)
+		})
+	}
+	handler := Build(nil).
+		HandleFunc("/ping", func(w http.ResponseWriter, r *http.Request) {}).
+		With(middleware.Chain{deny}).
+		Build()
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/ping", nil))
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected status 403, got %d", w.Code)
+	}
+	if w.Header().Get(middleware.RequestIDHeader) == "" {
+		t.Errorf("default middleware not applied")
+	}
+}
+
+func TestBuilder_Run(t *testing.T) {
+	started := make(chan struct{})
+	b := Build(nil).HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(50 * time.Millisecond)
+		w.Write([]byte("done"))
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := b.newServer(ln.Addr().String())
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error, 1)
+	go func() { runErr <- b.run(ctx, ln, func() error { return srv.Serve(ln) }) }()
+
+	// Shut down while a request is in flight; it should still complete.
+	body := make(chan string, 1)
+	go func() {
+		resp, err := http.Get("http://" + ln.Addr().String() + "/slow")
+		if err != nil {
+			body <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		body <- string(b)
+	}()
+	<-started
+	cancel()
+
+	if got := <-body; got != "done" {
+		t.Errorf("in-flight request got %q", got)
+	}
+	if err := <-runErr; err != nil {
+		t.Fatalf("run returned %v", err)
+	}
+}
+
+func TestBuilder_ShutdownNotRunning(t *testing.T) {
+	if err := Build(nil).Shutdown(context.Background()); err != nil {
+		t.Errorf("Shutdown = %v", err)
+	}
+}
+
+func TestBuilder_Use(t *testing.T) {
+	var order []string
+	tag := func(name string) func(http.Handler) http.Handler {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+	ping := func(w http.ResponseWriter, r *http.Request) {}
+
+	w := httptest.NewRecorder()
+	Build(nil).Use(tag("a"), tag("b")).Use(tag("c")).HandleFunc("/ping", ping).Build().
+		ServeHTTP(w, httptest.NewRequest("GET", "/ping", nil))
+	if got := strings.Join(order, ","); got != "a,b,c" {
+		t.Errorf("order = %s", got)
+	}
+
+	w = httptest.NewRecorder()
+	Build(nil).WithoutDefaults().HandleFunc("/ping", ping).Build().
+		ServeHTTP(w, httptest.NewRequest("GET", "/ping", nil))
+	if w.Header().Get(middleware.RequestIDHeader) != "" {
+		t.Errorf("default middleware applied despite WithoutDefaults")
+	}
+}
+
+func TestBuilder_Group(t *testing.T) {
+	deny := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "nope", http.StatusForbidden)
+		})
+	}
+	echo := func(w http.ResponseWriter, r *http.Request) { w.Write([]byte(r.URL.Path)) }
+
+	b := Build(nil)
+	api := b.Group("/api/v1/")
+	api.HandleFunc("GET /users", echo)
+	admin := api.Group("/admin")
+	admin.HandleFunc("/reindex", echo)
+	admin.Use(deny) // applies to routes added before, too
+	handler := api.Build()
+
+	tests := []struct {
+		method, path string
+		wantCode     int
+	}{
+		{"GET", "/api/v1/users", http.StatusOK},
+		{"POST", "/api/v1/users", http.StatusMethodNotAllowed},
+		{"GET", "/users", http.StatusNotFound},
+		{"GET", "/api/v1/admin/reindex", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+		if w.Code != tt.wantCode {
+			t.Errorf("%s %s: code = %d, want %d", tt.method, tt.path, w.Code, tt.wantCode)
+		}
+	}
+}
+
+func TestJoinPattern(t *testing.T) {
+	tests := []struct{ prefix, pattern, want string }{
+		{"/api", "/users", "/api/users"},
+		{"/api", "/", "/api/"},
+		{"/api", "GET /users", "GET /api/users"},
+		{"/api", "GET example.com/users", "GET example.com/api/users"},
+		{"/api", "example.com/", "example.com/api/"},
+	}
+	for _, tt := range tests {
+		if got := joinPattern(tt.prefix, tt.pattern); got != tt.want {
+			t.Errorf("joinPattern(%q, %q) = %q, want %q", tt.prefix, tt.pattern, got, tt.want)
+		}
+	}
+}
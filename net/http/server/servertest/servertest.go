@@ -0,0 +1,46 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package servertest provides utilities for testing applications built with package server.
+package servertest
+
+import (
+	"context"
+	"github.com/rburchell/gosh/net/http/server"
+	"net"
+	"net/http"
+	"testing"
+)
+
+// Serves b on an ephemeral port on 127.0.0.1 for the duration of a test, for integration tests.
+// It returns the base URL (e.g. "http://127.0.0.1:43567"), and a client to make requests with.
+//
+// The server is shut down when the test finishes (see testing.T.Cleanup),
+// and the test fails if it didn't shut down cleanly.
+//
+// For example:
+//
+//	url, client := servertest.Serve(t, server.Build(nil).HandleFunc("/ping", handlePingPong))
+//	resp, err := client.Get(url + "/ping")
+func Serve(t testing.TB, b *server.Builder) (string, *http.Client) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("servertest.Serve: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- b.RunListener(ctx, ln) }()
+
+	client := &http.Client{Transport: &http.Transport{}}
+	t.Cleanup(func() {
+		client.CloseIdleConnections()
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("servertest.Serve: %v", err)
+		}
+	})
+	return "http://" + ln.Addr().String(), client
+}
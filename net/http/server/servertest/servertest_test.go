@@ -0,0 +1,45 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package servertest
+
+import (
+	"context"
+	"github.com/rburchell/gosh/net/http/server"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestServe(t *testing.T) {
+	stopped := false
+	b := server.Build(nil).
+		HandleFunc("/ping", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("pong"))
+		}).
+		OnStop(func(ctx context.Context) error {
+			stopped = true
+			return nil
+		})
+
+	t.Run("serve", func(t *testing.T) {
+		url, client := Serve(t, b)
+		if !strings.HasPrefix(url, "http://127.0.0.1:") {
+			t.Errorf("url = %q", url)
+		}
+		resp, err := client.Get(url + "/ping")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if body, _ := io.ReadAll(resp.Body); string(body) != "pong" {
+			t.Errorf("body = %q", body)
+		}
+	})
+
+	if !stopped {
+		t.Errorf("server wasn't shut down after the test")
+	}
+}
@@ -0,0 +1,155 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// An EventStream sends server-sent events (text/event-stream) to a client.
+//
+// Each event is flushed as it is sent, through any middleware wrapping the ResponseWriter.
+// Send and Comment are safe to call from multiple goroutines.
+type EventStream struct {
+	w   http.ResponseWriter
+	rc  *http.ResponseController
+	ctx context.Context
+
+	mu sync.Mutex
+}
+
+// Starts an event stream on w: the headers are written and flushed immediately.
+//
+// It fails if w can't be flushed (i.e. streaming isn't possible).
+func NewEventStream(w http.ResponseWriter, r *http.Request) (*EventStream, error) {
+	rc := http.NewResponseController(w)
+	h := w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("X-Accel-Buffering", "no") // stop nginx from buffering
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		return nil, err
+	}
+	return &EventStream{w: w, rc: rc, ctx: r.Context()}, nil
+}
+
+// Returns a channel which is closed when the client goes away.
+func (s *EventStream) Done() <-chan struct{} {
+	return s.ctx.Done()
+}
+
+// Sends an event. If event is empty, the client gets it as a "message" event.
+//
+// A string or []byte data is sent as-is (split over several data lines if it contains newlines);
+// anything else is encoded as JSON.
+func (s *EventStream) Send(event string, data any) error {
+	return s.SendEvent(Event{Event: event, Data: data})
+}
+
+// An Event for EventStream.SendEvent, for when more than the name and data are needed.
+type Event struct {
+	// Sets the client's last event ID, which it sends back in the Last-Event-ID header when reconnecting.
+	ID string
+	// The event name.
+	Event string
+	// The data; see Send.
+	Data any
+	// If non-zero, tells the client how long to wait before reconnecting, in milliseconds.
+	Retry int
+}
+
+// Sends ev.
+func (s *EventStream) SendEvent(ev Event) error {
+	var data string
+	switch d := ev.Data.(type) {
+	case string:
+		data = d
+	case []byte:
+		data = string(d)
+	default:
+		b, err := json.Marshal(d)
+		if err != nil {
+			return err
+		}
+		data = string(b)
+	}
+
+	var b strings.Builder
+	if ev.ID != "" {
+		b.WriteString("id: " + oneLine(ev.ID) + "\n")
+	}
+	if ev.Event != "" {
+		b.WriteString("event: " + oneLine(ev.Event) + "\n")
+	}
+	if ev.Retry > 0 {
+		b.WriteString("retry: " + strconv.Itoa(ev.Retry) + "\n")
+	}
+	for line := range strings.Lines(strings.ReplaceAll(data, "\r\n", "\n")) {
+		b.WriteString("data: " + strings.TrimSuffix(line, "\n") + "\n")
+	}
+	if data == "" {
+		b.WriteString("data\n")
+	}
+	b.WriteString("\n")
+	return s.write(b.String())
+}
+
+// Sends a comment, which clients ignore. This is useful as a keep-alive, so that idle
+// connections aren't closed by proxies.
+func (s *EventStream) Comment(text string) error {
+	return s.write(": " + oneLine(text) + "\n\n")
+}
+
+func (s *EventStream) write(str string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.ctx.Err(); err != nil {
+		return err
+	}
+	if _, err := s.w.Write([]byte(str)); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
+
+// Adapts fn into a handler serving an event stream. fn should return when the stream's Done channel is closed.
+//
+// For example:
+//
+//	b.Get("/events", server.EventHandler(func(r *http.Request, s *server.EventStream) error {
+//	    for {
+//	        select {
+//	        case <-s.Done():
+//	            return nil
+//	        case t := <-ticker.C:
+//	            if err := s.Send("tick", t); err != nil {
+//	                return err
+//	            }
+//	        }
+//	    }
+//	}))
+func EventHandler(fn func(r *http.Request, s *EventStream) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s, err := NewEventStream(w, r)
+		if err != nil {
+			log.ErrorContext(r.Context(), "Can't stream events", "path", r.URL.Path, "err", err)
+			return
+		}
+		if err := fn(r, s); err != nil && r.Context().Err() == nil {
+			log.WarnContext(r.Context(), "Event stream failed", "path", r.URL.Path, "err", err)
+		}
+	}
+}
+
+// Replaces newlines, which would break the stream framing.
+func oneLine(s string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+}
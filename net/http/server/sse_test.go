@@ -0,0 +1,55 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestEventHandler(t *testing.T) {
+	finished := make(chan struct{})
+	b := Build(nil).Get("/events", EventHandler(func(r *http.Request, s *EventStream) error {
+		defer close(finished)
+		s.Comment("hello")
+		s.Send("", "plain")
+		s.Send("update", map[string]int{"n": 1})
+		s.SendEvent(Event{ID: "7", Event: "multi", Data: "a\nb", Retry: 1000})
+		<-s.Done()
+		return nil
+	}))
+	srv := httptest.NewServer(b)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+
+	want := []string{
+		": hello", "",
+		"data: plain", "",
+		"event: update", `data: {"n":1}`, "",
+		"id: 7", "event: multi", "retry: 1000", "data: a", "data: b", "",
+	}
+	scanner := bufio.NewScanner(resp.Body)
+	var got []string
+	for len(got) < len(want) && scanner.Scan() {
+		got = append(got, scanner.Text())
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	// Disconnecting ends the handler
+	resp.Body.Close()
+	<-finished
+}
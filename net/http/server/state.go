@@ -0,0 +1,120 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// A snapshot of a server's state. See Builder.State.
+type State struct {
+	// The addresses being listened on (including those from AlsoServe), or nil if not serving.
+	Addrs []string `json:"addrs"`
+	// Whether the server is shutting down, and waiting for in-flight requests to finish.
+	Draining bool `json:"draining"`
+	// The number of open connections, including idle ones.
+	ActiveConns int64 `json:"active_conns"`
+	// The number of requests that have been served, over the Builder's lifetime.
+	TotalServed uint64 `json:"total_served"`
+	// When serving began, or zero if not serving.
+	Started time.Time `json:"started,omitzero"`
+	// How long the server has been serving for.
+	Uptime time.Duration `json:"-"`
+}
+
+// Counts what the servers created by a Builder are doing.
+type serverStats struct {
+	activeConns atomic.Int64
+	totalServed atomic.Uint64
+
+	mu       sync.Mutex
+	addrs    []string
+	started  time.Time
+	draining bool
+}
+
+// Returns a snapshot of the server's state, for e.g. dashboards and smoke tests.
+// See also EnableStatus.
+func (b *Builder) State() State {
+	s := &b.stats
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := State{
+		Addrs:       append([]string(nil), s.addrs...),
+		Draining:    s.draining,
+		ActiveConns: s.activeConns.Load(),
+		TotalServed: s.totalServed.Load(),
+		Started:     s.started,
+	}
+	if !st.Started.IsZero() {
+		st.Uptime = time.Since(st.Started)
+	}
+	return st
+}
+
+// Adds a GET route at path, which reports State as JSON, with uptime in (fractional) seconds.
+//
+// Like EnableRoutes, consider restricting access to it.
+func (b *Builder) EnableStatus(path string) *Builder {
+	return b.Get(path, func(w http.ResponseWriter, r *http.Request) {
+		st := b.State()
+		JSON(w, http.StatusOK, struct {
+			State
+			UptimeSeconds float64 `json:"uptime_seconds"`
+		}{st, st.Uptime.Seconds()})
+	})
+}
+
+// Records that serving has begun on addr.
+func (s *serverStats) start(addr net.Addr) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.addrs = []string{addr.String()}
+	s.started = time.Now()
+	s.draining = false
+}
+
+// Records that addr is also being listened on.
+func (s *serverStats) listening(addr net.Addr) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.addrs = append(s.addrs, addr.String())
+}
+
+// Records that serving is shutting down (drain true), or has stopped (drain false).
+func (s *serverStats) stop(drain bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if drain && s.started.IsZero() {
+		return
+	}
+	s.draining = drain
+	if !drain {
+		s.addrs = nil
+		s.started = time.Time{}
+	}
+}
+
+// Tracks open connections, for use as http.Server.ConnState.
+func (s *serverStats) connState(c net.Conn, state http.ConnState) {
+	switch state {
+	case http.StateNew:
+		s.activeConns.Add(1)
+	case http.StateHijacked, http.StateClosed:
+		s.activeConns.Add(-1)
+	}
+}
+
+// Wraps next to count served requests.
+func (s *serverStats) count(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r)
+		s.totalServed.Add(1)
+	})
+}
@@ -0,0 +1,76 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestBuilder_State(t *testing.T) {
+	b := Build(nil).EnableStatus("/_status")
+	if st := b.State(); st.Addrs != nil || !st.Started.IsZero() {
+		t.Errorf("state before serving = %+v", st)
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := b.newServer(ln.Addr().String())
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error, 1)
+	up := make(chan struct{})
+	b.OnStart(func(net.Addr) error { close(up); return nil })
+	go func() { runErr <- b.run(ctx, ln, func() error { return srv.Serve(ln) }) }()
+	<-up
+
+	client := &http.Client{Transport: &http.Transport{}}
+	for range 2 {
+		resp, err := client.Get("http://" + ln.Addr().String() + "/_status")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got struct {
+			Addrs         []string `json:"addrs"`
+			ActiveConns   int64    `json:"active_conns"`
+			TotalServed   uint64   `json:"total_served"`
+			UptimeSeconds float64  `json:"uptime_seconds"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&got)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got.Addrs) != 1 || got.Addrs[0] != ln.Addr().String() || got.ActiveConns != 1 || got.UptimeSeconds <= 0 {
+			t.Errorf("status = %+v", got)
+		}
+	}
+
+	if st := b.State(); st.TotalServed != 2 {
+		t.Errorf("TotalServed = %d, want 2", st.TotalServed)
+	}
+
+	cancel()
+	if err := <-runErr; err != nil {
+		t.Fatal(err)
+	}
+	if st := b.State(); st.Addrs != nil || st.Draining {
+		t.Errorf("state after serving = %+v", st)
+	}
+
+	// Connections are closed asynchronously.
+	deadline := time.Now().Add(time.Second)
+	for b.State().ActiveConns != 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if n := b.State().ActiveConns; n != 0 {
+		t.Errorf("ActiveConns after serving = %d", n)
+	}
+}
@@ -0,0 +1,194 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"sync"
+)
+
+// Configures NewTemplates.
+type TemplateOptions struct {
+	// A glob (see fs.Glob) matching the page templates, which are rendered by name (e.g. "index.html").
+	// If empty, "*.html" is used.
+	Pages string
+
+	// Globs matching templates parsed along with every page, e.g. layouts and partials.
+	Shared []string
+
+	// If set, the template executed to render a page, e.g. "base", defined in a Shared template.
+	// Pages then fill in its blocks with {{define}}. If empty, the page itself is executed.
+	Layout string
+
+	// Functions available to templates.
+	Funcs template.FuncMap
+
+	// Re-parses templates on every render, so edits show up without a restart.
+	// Use in development, with an os.DirFS.
+	Reload bool
+}
+
+// Templates renders HTML pages from a set of templates. See NewTemplates.
+type Templates struct {
+	fsys fs.FS
+	opts TemplateOptions
+
+	mu    sync.RWMutex
+	pages map[string]*template.Template
+}
+
+// Parses the templates in fsys (e.g. an embed.FS), as configured by opts.
+//
+// For example, with pages/*.html each containing {{define "content"}}...{{end}},
+// and layouts/base.html containing {{define "base"}}<html>...{{block "content" .}}{{end}}...{{end}}:
+//
+//	t, err := server.NewTemplates(templateFS, server.TemplateOptions{
+//	    Pages:  "pages/*.html",
+//	    Shared: []string{"layouts/*.html"},
+//	    Layout: "base",
+//	})
+//	b.Templates(t).Get("/", func(w http.ResponseWriter, r *http.Request) {
+//	    server.Render(w, r, http.StatusOK, "pages/index.html", data)
+//	})
+func NewTemplates(fsys fs.FS, opts TemplateOptions) (*Templates, error) {
+	if opts.Pages == "" {
+		opts.Pages = "*.html"
+	}
+	t := &Templates{fsys: fsys, opts: opts}
+	if err := t.parse(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Parses all pages.
+func (t *Templates) parse() error {
+	var shared []string
+	for _, glob := range t.opts.Shared {
+		matches, err := fs.Glob(t.fsys, glob)
+		if err != nil {
+			return err
+		}
+		shared = append(shared, matches...)
+	}
+	base := template.New("").Funcs(t.opts.Funcs)
+	if len(shared) > 0 {
+		var err error
+		if base, err = base.ParseFS(t.fsys, shared...); err != nil {
+			return err
+		}
+	}
+
+	names, err := fs.Glob(t.fsys, t.opts.Pages)
+	if err != nil {
+		return err
+	}
+	pages := make(map[string]*template.Template, len(names))
+	for _, name := range names {
+		page, err := base.Clone()
+		if err != nil {
+			return err
+		}
+		content, err := fs.ReadFile(t.fsys, name)
+		if err != nil {
+			return err
+		}
+		if page, err = page.New(name).Parse(string(content)); err != nil {
+			return err
+		}
+		pages[name] = page
+	}
+
+	t.mu.Lock()
+	t.pages = pages
+	t.mu.Unlock()
+	return nil
+}
+
+// Renders the page name with data, and writes it with the given status.
+//
+// The page is rendered to a buffer first, so if it fails, nothing is written, and the error is returned.
+func (t *Templates) Render(w http.ResponseWriter, status int, name string, data any) error {
+	buf, err := t.execute(name, data)
+	if err != nil {
+		return err
+	}
+	return writeHTML(w, status, buf)
+}
+
+// Renders the page name with data, to a buffer.
+func (t *Templates) execute(name string, data any) (*bytes.Buffer, error) {
+	if t.opts.Reload {
+		if err := t.parse(); err != nil {
+			return nil, err
+		}
+	}
+
+	t.mu.RLock()
+	page, ok := t.pages[name]
+	t.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("server: no template %q", name)
+	}
+
+	exec := name
+	if t.opts.Layout != "" {
+		exec = t.opts.Layout
+	}
+	var buf bytes.Buffer
+	if err := page.ExecuteTemplate(&buf, exec, data); err != nil {
+		return nil, err
+	}
+	return &buf, nil
+}
+
+// Writes the rendered page in buf, with the given status.
+func writeHTML(w http.ResponseWriter, status int, buf *bytes.Buffer) error {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+type ctxKey int
+
+const templatesKey ctxKey = iota
+
+// Makes t available to handlers through Render.
+func (b *Builder) Templates(t *Templates) *Builder {
+	return b.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), templatesKey, t)))
+		})
+	})
+}
+
+// Renders the page name with data, using the Templates given to the Builder (see Builder.Templates).
+//
+// If rendering fails, the error is logged, and a 500 is written instead.
+// If writing the page fails, the response has already started, so the error is only logged.
+func Render(w http.ResponseWriter, r *http.Request, status int, name string, data any) {
+	t, ok := r.Context().Value(templatesKey).(*Templates)
+	if !ok {
+		// if this is hit, Builder.Templates wasn't called.
+		log.ErrorContext(r.Context(), "Failed to render template", "name", name, "err", "templates not found in request")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	buf, err := t.execute(name, data)
+	if err != nil {
+		log.ErrorContext(r.Context(), "Failed to render template", "name", name, "err", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	if err := writeHTML(w, status, buf); err != nil {
+		log.WarnContext(r.Context(), "Failed to write template", "name", name, "err", err)
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestTemplates(t *testing.T) {
+	fsys := fstest.MapFS{
+		"layouts/base.html": {Data: []byte(`{{define "base"}}<title>{{block "title" .}}Site{{end}}</title>{{template "content" .}}{{end}}`)},
+		"pages/index.html":  {Data: []byte(`{{define "content"}}Hello {{upper .}}{{end}}`)},
+		"pages/about.html":  {Data: []byte(`{{define "title"}}About{{end}}{{define "content"}}About{{end}}`)},
+		"pages/broken.html": {Data: []byte(`{{define "content"}}{{.Missing.Field}}{{end}}`)},
+	}
+	tmpl, err := NewTemplates(fsys, TemplateOptions{
+		Pages:  "pages/*.html",
+		Shared: []string{"layouts/*.html"},
+		Layout: "base",
+		Funcs:  map[string]any{"upper": strings.ToUpper},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := Build(nil).Templates(tmpl).Get("/{page}", func(w http.ResponseWriter, r *http.Request) {
+		Render(w, r, http.StatusOK, "pages/"+r.PathValue("page")+".html", "<world>")
+	})
+
+	tests := []struct {
+		path     string
+		wantCode int
+		wantBody string
+	}{
+		{"/index", 200, "<title>Site</title>Hello &lt;WORLD&gt;"},
+		{"/about", 200, "<title>About</title>About"},
+		{"/broken", 500, "Internal Server Error\n"},
+		{"/missing", 500, "Internal Server Error\n"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		b.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+		if w.Code != tt.wantCode || w.Body.String() != tt.wantBody {
+			t.Errorf("%s: code = %d, body = %q", tt.path, w.Code, w.Body.String())
+		}
+	}
+}
+
+// A ResponseWriter whose writes fail, e.g. because the client went away.
+type failingWriter struct {
+	*httptest.ResponseRecorder
+	headers int
+}
+
+func (w *failingWriter) WriteHeader(code int) {
+	w.headers++
+	w.ResponseRecorder.WriteHeader(code)
+}
+
+func (w *failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("connection reset")
+}
+
+func TestRender_WriteFails(t *testing.T) {
+	tmpl, err := NewTemplates(fstest.MapFS{"page.html": {Data: []byte("hello")}}, TemplateOptions{Pages: "*.html"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := Build(nil).Templates(tmpl).Get("/", func(w http.ResponseWriter, r *http.Request) {
+		Render(w, r, http.StatusOK, "page.html", nil)
+	})
+	w := &failingWriter{ResponseRecorder: httptest.NewRecorder()}
+	b.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if w.headers != 1 || w.Code != http.StatusOK {
+		t.Errorf("WriteHeader called %d times, code = %d", w.headers, w.Code)
+	}
+}
+
+func TestTemplates_Reload(t *testing.T) {
+	dir := t.TempDir()
+	page := filepath.Join(dir, "page.html")
+	os.WriteFile(page, []byte("v1"), 0600)
+
+	tmpl, err := NewTemplates(os.DirFS(dir), TemplateOptions{Reload: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(page, []byte("v2"), 0600)
+
+	w := httptest.NewRecorder()
+	if err := tmpl.Render(w, http.StatusOK, "page.html", nil); err != nil {
+		t.Fatal(err)
+	}
+	if w.Body.String() != "v2" {
+		t.Errorf("body = %q, want reloaded v2", w.Body.String())
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"github.com/rburchell/gosh/log/slogx"
+	"net/http"
+)
+
+// A CertManager provides certificates automatically, e.g. from Let's Encrypt.
+//
+// It is satisfied by *autocert.Manager from golang.org/x/crypto/acme/autocert,
+// which is not a dependency of this package, so you need to bring it yourself:
+//
+//	m := &autocert.Manager{
+//	    Prompt:     autocert.AcceptTOS,
+//	    Cache:      autocert.DirCache("/var/lib/myapp/certs"),
+//	    HostPolicy: autocert.HostWhitelist("example.com"),
+//	}
+//	server.Build(nil).AutoCert(m, ":80").Handle(...).RunTLS(ctx, ":443", "", "")
+type CertManager interface {
+	// Returns a certificate for the TLS handshake.
+	GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error)
+
+	// Returns a handler answering HTTP challenges, and passing everything else to fallback
+	// (or redirecting to https, if fallback is nil).
+	HTTPHandler(fallback http.Handler) http.Handler
+}
+
+// Returns the TLS configuration used by default: TLS 1.2 or newer, with only forward-secret AEAD cipher suites.
+func DefaultTLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		CipherSuites: []uint16{
+			// Only used for TLS 1.2; TLS 1.3 suites are not configurable, and are all fine.
+			tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+		},
+	}
+}
+
+// Sets the TLS configuration used by ListenAndServeTLS and RunTLS, instead of DefaultTLSConfig.
+func (b *Builder) TLSConfig(cfg *tls.Config) *Builder {
+	b.tlsConfig = cfg
+	return b
+}
+
+// Gets certificates from m, rather than from files.
+//
+// If httpAddr is not empty, m's HTTP handler is also served there (usually ":80"),
+// to answer HTTP challenges and redirect everything else to https.
+func (b *Builder) AutoCert(m CertManager, httpAddr string) *Builder {
+	b.certManager = m
+	b.acmeAddr = httpAddr
+	return b
+}
+
+// The same as ListenAndServe, but serves https, using the certificate and key in certFile and keyFile.
+// If AutoCert was used, certFile and keyFile must be empty.
+func (b *Builder) ListenAndServeTLS(addr, certFile, keyFile string) error {
+	srv, err := b.newTLSServer(addr, certFile, keyFile)
+	if err != nil {
+		return err
+	}
+	ln, err := b.listen(addr)
+	if err != nil {
+		return err
+	}
+	b.serveACME()
+	b.logHosting("https", ln.Addr())
+	return b.serveAll(context.Background(), ln, func() error { return srv.ServeTLS(ln, certFile, keyFile) })
+}
+
+// The same as ListenAndServeTLS, but fatally exits if ListenAndServeTLS returns an error.
+func (b *Builder) ListenAndServeTLSOrDie(addr, certFile, keyFile string) {
+	err := b.ListenAndServeTLS(addr, certFile, keyFile)
+	if err != nil {
+		slogx.Fatal(log, err.Error())
+	}
+}
+
+// The same as Run, but serves https. See ListenAndServeTLS.
+func (b *Builder) RunTLS(ctx context.Context, addr, certFile, keyFile string) error {
+	srv, err := b.newTLSServer(addr, certFile, keyFile)
+	if err != nil {
+		return err
+	}
+	ln, err := b.listen(addr)
+	if err != nil {
+		return err
+	}
+	b.serveACME()
+	b.logHosting("https", ln.Addr())
+	return b.run(ctx, ln, func() error { return srv.ServeTLS(ln, certFile, keyFile) })
+}
+
+// Creates a http.Server configured for TLS.
+func (b *Builder) newTLSServer(addr, certFile, keyFile string) (*http.Server, error) {
+	cfg := b.tlsConfig
+	if cfg == nil {
+		cfg = DefaultTLSConfig()
+	} else {
+		cfg = cfg.Clone()
+	}
+
+	if b.certManager != nil {
+		if certFile != "" || keyFile != "" {
+			return nil, errors.New("server: certFile and keyFile must be empty when using AutoCert")
+		}
+		cfg.GetCertificate = b.certManager.GetCertificate
+		// h2 and http/1.1 as usual, and acme-tls/1 for TLS-ALPN challenges.
+		cfg.NextProtos = append(cfg.NextProtos, "h2", "http/1.1", "acme-tls/1")
+	}
+
+	srv := b.newServer(addr)
+	srv.TLSConfig = cfg
+	return srv, nil
+}
+
+// Serves the CertManager's HTTP handler at the address given to AutoCert, if any, until Shutdown.
+// It is started once the main listener is open, so that a failure to start doesn't leave it running.
+func (b *Builder) serveACME() {
+	if b.certManager == nil || b.acmeAddr == "" {
+		return
+	}
+	acme := b.track(&http.Server{Addr: b.acmeAddr, Handler: b.certManager.HTTPHandler(nil)})
+	go func() {
+		if err := serveErr(acme.ListenAndServe()); err != nil {
+			log.Error("ACME HTTP handler failed", "addr", b.acmeAddr, "err", err)
+		}
+	}()
+}
@@ -0,0 +1,126 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Writes a self-signed certificate for 127.0.0.1, returning the cert and key paths.
+func writeTestCert(t *testing.T) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "test"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)
+	return certFile, keyFile
+}
+
+func TestBuilder_TLS(t *testing.T) {
+	certFile, keyFile := writeTestCert(t)
+	b := Build(nil).HandleFunc("/ping", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("pong"))
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv, err := b.newTLSServer(ln.Addr().String(), certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if srv.TLSConfig.MinVersion != tls.VersionTLS12 {
+		t.Errorf("MinVersion = %x, want TLS 1.2", srv.TLSConfig.MinVersion)
+	}
+	go srv.ServeTLS(ln, certFile, keyFile)
+	defer b.Shutdown(context.Background())
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+	resp, err := client.Get("https://" + ln.Addr().String() + "/ping")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "pong" {
+		t.Errorf("body = %q", body)
+	}
+}
+
+type fakeCertManager struct{}
+
+func (fakeCertManager) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return nil, nil
+}
+func (fakeCertManager) HTTPHandler(fallback http.Handler) http.Handler { return fallback }
+
+func TestBuilder_AutoCert(t *testing.T) {
+	b := Build(nil).AutoCert(fakeCertManager{}, "")
+	if _, err := b.newTLSServer(":0", "cert.pem", "key.pem"); err == nil {
+		t.Errorf("expected error for cert files with AutoCert")
+	}
+	srv, err := b.newTLSServer(":0", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if srv.TLSConfig.GetCertificate == nil {
+		t.Errorf("GetCertificate not set from CertManager")
+	}
+}
+
+func TestBuilder_AutoCertListenFails(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	// The main address is taken, so the ACME handler mustn't be left running.
+	b := Build(nil).AutoCert(fakeCertManager{}, "127.0.0.1:0")
+	if err := b.ListenAndServeTLS(ln.Addr().String(), "", ""); err == nil {
+		t.Fatal("expected an error listening on a used address")
+	}
+	for _, srv := range b.servers {
+		if srv.Addr == "127.0.0.1:0" {
+			t.Errorf("ACME handler was started")
+		}
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"context"
+	"errors"
+	"github.com/rburchell/gosh/net/http/bind"
+	"net/http"
+)
+
+// An error with a HTTP status and error code, for returning from Typed handlers.
+type HTTPError struct {
+	Status  int
+	Code    string
+	Message string
+}
+
+// Returns a *HTTPError.
+func NewError(status int, code, message string) error {
+	return &HTTPError{Status: status, Code: code, Message: message}
+}
+
+func (e *HTTPError) Error() string     { return e.Message }
+func (e *HTTPError) StatusCode() int   { return e.Status }
+func (e *HTTPError) ErrorCode() string { return e.Code }
+
+// Typed adapts a typed function into a http.HandlerFunc, combining bind and JSON.
+//
+// The request is bound to a new In (see bind.Bind), and fn is called with it.
+// The returned Out is written as JSON, with a 200 status.
+//
+// Errors are written with Error. If binding fails, the status is 400, with code "bad_request".
+// If fn fails, the status and code come from the error's `StatusCode() int` and `ErrorCode() string`
+// methods (as on *HTTPError), if it has them, or are 500 and "internal" otherwise. For 5xx statuses,
+// the error is logged, and the client only gets the status text, so that internal details aren't leaked.
+//
+// For example:
+//
+//	type GetUserIn struct {
+//	    ID int `query:"id" binding:"required"`
+//	}
+//
+//	b.Get("/user", server.Typed(func(ctx context.Context, in GetUserIn) (*User, error) {
+//	    u, ok := users[in.ID]
+//	    if !ok {
+//	        return nil, server.NewError(http.StatusNotFound, "user_not_found", "no such user")
+//	    }
+//	    return u, nil
+//	}))
+func Typed[In any, Out any](fn func(ctx context.Context, in In) (Out, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var in In
+		if err := bind.Bind(r, &in); err != nil {
+			Error(w, http.StatusBadRequest, "bad_request", err.Error())
+			return
+		}
+
+		out, err := fn(r.Context(), in)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		JSON(w, http.StatusOK, out)
+	}
+}
+
+// Writes err with Error, using its status and code if it has them.
+// As with bind.Handler, 5xx errors are logged, and the client only gets the status text.
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	status := bind.ErrorStatus(err)
+	var code string
+	var ec interface{ ErrorCode() string }
+	if errors.As(err, &ec) {
+		code = ec.ErrorCode()
+	}
+	if status >= 500 {
+		log.ErrorContext(r.Context(), "Handler failed", "path", r.URL.Path, "err", err)
+		if code == "" {
+			code = "internal"
+		}
+		Error(w, status, code, http.StatusText(status))
+		return
+	}
+	Error(w, status, code, err.Error())
+}
@@ -0,0 +1,55 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTyped(t *testing.T) {
+	type In struct {
+		ID int `query:"id" binding:"required"`
+	}
+	type Out struct {
+		Name string `json:"name"`
+	}
+
+	b := Build(nil).Get("/user", Typed(func(ctx context.Context, in In) (Out, error) {
+		switch in.ID {
+		case 1:
+			return Out{Name: "alice"}, nil
+		case 2:
+			return Out{}, fmt.Errorf("lookup: %w", NewError(http.StatusNotFound, "user_not_found", "no such user"))
+		case 4:
+			return Out{}, NewError(http.StatusServiceUnavailable, "db_down", "database password is hunter2")
+		default:
+			return Out{}, errors.New("database password is hunter2")
+		}
+	}))
+
+	tests := []struct {
+		query    string
+		wantCode int
+		wantBody string
+	}{
+		{"id=1", 200, `{"name":"alice"}`},
+		{"", 400, `{"error":"ID is required","code":"bad_request"}`},
+		{"id=2", 404, `{"error":"lookup: no such user","code":"user_not_found"}`},
+		{"id=3", 500, `{"error":"Internal Server Error","code":"internal"}`},
+		{"id=4", 503, `{"error":"Service Unavailable","code":"db_down"}`},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		b.ServeHTTP(w, httptest.NewRequest("GET", "/user?"+tt.query, nil))
+		if w.Code != tt.wantCode || w.Body.String() != tt.wantBody+"\n" {
+			t.Errorf("%q: code = %d, body = %s", tt.query, w.Code, w.Body.String())
+		}
+	}
+}
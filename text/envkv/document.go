@@ -0,0 +1,159 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package envkv
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// A Document is a parsed envkv file which can be edited, and serialized again,
+// keeping its comments, blank lines, and formatting, except on the lines which were changed.
+// This allows programs to update files which operators also edit by hand.
+type Document struct {
+	lines []docLine
+	// Whether the input ended with a newline.
+	trailingNewline bool
+}
+
+// A line of a Document.
+type docLine struct {
+	// The line as it was read, or "" if it was added or changed.
+	raw string
+	// The key and value, if the line has one; otherwise it is blank or a comment.
+	key   string
+	value string
+	// Any whitespace and comment following the value.
+	comment string
+}
+
+// ParseDocument parses b, which must be valid as for Unmarshal.
+func ParseDocument(b []byte) (*Document, error) {
+	b = bytes.ReplaceAll(b, []byte("\r\n"), []byte("\n"))
+	d := &Document{trailingNewline: len(b) > 0 && b[len(b)-1] == '\n'}
+	if d.trailingNewline {
+		b = b[:len(b)-1]
+	}
+	if len(b) == 0 && !d.trailingNewline {
+		return d, nil
+	}
+
+	seen := map[string]struct{}{}
+	for ln, line := range strings.Split(string(b), "\n") {
+		key, val, keyStart, end, _, err := parseLine(line, ln, false)
+		if err != nil {
+			return nil, err
+		}
+		dl := docLine{raw: line, key: key, value: val}
+		if key != "" {
+			if _, ok := seen[key]; ok {
+				return nil, newParseError(pos{line: ln, col: keyStart, text: line}, CodeDuplicateKey, "duplicate key")
+			}
+			seen[key] = struct{}{}
+			dl.comment = line[end:]
+		}
+		d.lines = append(d.lines, dl)
+	}
+	return d, nil
+}
+
+// Returns the index of the line with key, or -1.
+func (d *Document) find(key string) int {
+	for i, l := range d.lines {
+		if l.key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// Returns the value of key, and whether it is present.
+func (d *Document) Get(key string) (string, bool) {
+	if i := d.find(key); i >= 0 {
+		return d.lines[i].value, true
+	}
+	return "", false
+}
+
+// Sets the value of key, in place if it is already present, otherwise by adding it at the end.
+func (d *Document) Set(key, value string) error {
+	if err := checkKey(key); err != nil {
+		return fmt.Errorf("%w: %q", err, key)
+	}
+	if i := d.find(key); i >= 0 {
+		d.lines[i].value = value
+		d.lines[i].raw = ""
+		return nil
+	}
+	d.lines = append(d.lines, docLine{key: key, value: value})
+	return nil
+}
+
+// Removes key, returning whether it was present.
+// Comments on the lines before it are left alone.
+func (d *Document) Delete(key string) bool {
+	i := d.find(key)
+	if i < 0 {
+		return false
+	}
+	d.lines = append(d.lines[:i], d.lines[i+1:]...)
+	return true
+}
+
+// Renames the key oldKey to newKey, keeping its value and position.
+// It is an error if oldKey isn't present, or newKey already is.
+func (d *Document) Rename(oldKey, newKey string) error {
+	if err := checkKey(newKey); err != nil {
+		return fmt.Errorf("%w: %q", err, newKey)
+	}
+	i := d.find(oldKey)
+	if i < 0 {
+		return fmt.Errorf("no such key: %q", oldKey)
+	}
+	if oldKey == newKey {
+		return nil
+	}
+	if d.find(newKey) >= 0 {
+		return fmt.Errorf("duplicate key: %q", newKey)
+	}
+	d.lines[i].key = newKey
+	d.lines[i].raw = ""
+	return nil
+}
+
+// Returns the keys and values in the document, in order.
+func (d *Document) KVs() []KV {
+	var kvs []KV
+	for _, l := range d.lines {
+		if l.key != "" {
+			kvs = append(kvs, KV{Key: l.key, Value: l.value})
+		}
+	}
+	return kvs
+}
+
+// Returns the document, serialized. Lines which weren't changed are exactly as they were parsed.
+func (d *Document) Bytes() []byte {
+	var buf bytes.Buffer
+	for i, l := range d.lines {
+		if i > 0 {
+			buf.WriteByte('\n')
+		}
+		if l.raw != "" || l.key == "" {
+			buf.WriteString(l.raw)
+			continue
+		}
+		buf.WriteString(l.key)
+		buf.WriteByte('=')
+		appendValue(&buf, l.value)
+		buf.WriteString(l.comment)
+	}
+	// Keep a missing final newline, unless the last line is new.
+	if last := len(d.lines) - 1; d.trailingNewline || last >= 0 && d.lines[last].raw == "" && d.lines[last].key != "" {
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,132 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package envkv
+
+import (
+	"testing"
+)
+
+func TestDocument(t *testing.T) {
+	const input = `# Database settings
+HOST = "db"   # the primary
+PORT=5432
+
+  # Feature flags
+DEBUG="false"
+`
+	tests := []struct {
+		name    string
+		edit    func(d *Document) error
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "unchanged",
+			edit: func(d *Document) error { return nil },
+			want: input,
+		},
+		{
+			name: "set existing keeps comment",
+			edit: func(d *Document) error { return d.Set("HOST", "db two") },
+			want: "# Database settings\nHOST=\"db two\"   # the primary\nPORT=5432\n\n  # Feature flags\nDEBUG=\"false\"\n",
+		},
+		{
+			name: "set new",
+			edit: func(d *Document) error { return d.Set("USER", "app") },
+			want: input + "USER=app\n",
+		},
+		{
+			name: "delete",
+			edit: func(d *Document) error {
+				if !d.Delete("PORT") || d.Delete("NOPE") {
+					t.Errorf("unexpected Delete result")
+				}
+				return nil
+			},
+			want: "# Database settings\nHOST = \"db\"   # the primary\n\n  # Feature flags\nDEBUG=\"false\"\n",
+		},
+		{
+			name: "rename",
+			edit: func(d *Document) error { return d.Rename("PORT", "DBPORT") },
+			want: "# Database settings\nHOST = \"db\"   # the primary\nDBPORT=5432\n\n  # Feature flags\nDEBUG=\"false\"\n",
+		},
+		{
+			name:    "rename to existing",
+			edit:    func(d *Document) error { return d.Rename("PORT", "HOST") },
+			wantErr: true,
+		},
+		{
+			name:    "rename missing",
+			edit:    func(d *Document) error { return d.Rename("NOPE", "OTHER") },
+			wantErr: true,
+		},
+		{
+			name:    "set invalid key",
+			edit:    func(d *Document) error { return d.Set("A B", "x") },
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := ParseDocument([]byte(input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = tt.edit(d)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := string(d.Bytes()); got != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+			if _, err := Unmarshal(d.Bytes()); err != nil {
+				t.Errorf("result doesn't parse: %v", err)
+			}
+		})
+	}
+}
+
+func TestDocument_Accessors(t *testing.T) {
+	d, err := ParseDocument([]byte("A=1\n# c\nB=\"x y\""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := d.Get("B"); !ok || v != "x y" {
+		t.Errorf("Get(B) = %q, %v", v, ok)
+	}
+	if _, ok := d.Get("C"); ok {
+		t.Errorf("Get(C) should be missing")
+	}
+	if got := d.KVs(); !equalKV(got, []KV{{"A", "1"}, {"B", "x y"}}) {
+		t.Errorf("KVs = %+v", got)
+	}
+
+	// A missing final newline is kept, unless a line is added.
+	if got := string(d.Bytes()); got != "A=1\n# c\nB=\"x y\"" {
+		t.Errorf("Bytes = %q", got)
+	}
+	d.Set("C", "3")
+	if got := string(d.Bytes()); got != "A=1\n# c\nB=\"x y\"\nC=3\n" {
+		t.Errorf("Bytes = %q", got)
+	}
+
+	// Values with backslashes can be read back.
+	d.Set("D", `C:\temp`)
+	if d2, err := ParseDocument(d.Bytes()); err != nil {
+		t.Errorf("reparsing %q: %v", d.Bytes(), err)
+	} else if v, _ := d2.Get("D"); v != `C:\temp` {
+		t.Errorf("reparsed D = %q", v)
+	}
+
+	if _, err := ParseDocument([]byte("A=1\nA=2")); err == nil {
+		t.Errorf("expected an error for duplicate keys")
+	}
+	if d, err := ParseDocument(nil); err != nil || len(d.Bytes()) != 0 {
+		t.Errorf("empty document: %v, %q", err, d.Bytes())
+	}
+}
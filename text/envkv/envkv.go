@@ -11,10 +11,13 @@
 //
 // Comments (begun with `#`) are ignored.
 //
-// Keys must only contain alphanumeric characters.
+// Keys must only contain alphanumeric characters and underscores.
 // Duplicate keys are not allowed.
 //
-// Values may be quoted, supporting \" and \n escapes.
+// Values may be quoted, supporting \", \\ and \n escapes.
+//
+// Values may refer to other keys, as ${KEY} or $KEY, if UnmarshalOptions.Expand is used,
+// and to secrets held elsewhere, as "secret://PROVIDER/PATH#KEY", if UnmarshalOptions.Secrets is.
 //
 //	# Example envkv snippet
 //	HOST=localhost
@@ -27,6 +30,9 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 )
 
 // KV represents a key-value pair as used by Unmarshal and Marshal.
@@ -35,152 +41,544 @@ type KV struct {
 	Value string // The assocated value
 }
 
+// UnmarshalOptions configures parsing beyond what Unmarshal does.
+type UnmarshalOptions struct {
+	// If true, references to other keys in values, written as ${KEY} or $KEY, are replaced
+	// with the value of that key, which must be defined earlier in the input (as in a shell).
+	// Other references, including to the key itself, are looked up with LookupEnv, so that
+	// e.g. PATH=$PATH:/opt/bin works; a reference which isn't found is an error.
+	// $$ is a literal $, and a $ which isn't followed by a key or { is left alone.
+	//
+	//	HOST=db.internal
+	//	URL="postgres://${HOST}:5432/app"
+	Expand bool
+
+	// If set, Expand also looks up keys which aren't defined earlier in the input with it (e.g. os.LookupEnv).
+	LookupEnv func(key string) (string, bool)
+
+	// If true, the common .env dialect is accepted too, as written by other tools:
+	//
+	//	export KEY=value             # "export " prefixes are ignored
+	//	KEY='C:\literal "$value"'    # single-quoted values are taken literally, and aren't expanded
+	//	KEY=a value with spaces      # bare values run until a comment, and may contain spaces and backslashes
+	//	some.key-name=value          # keys may contain dots and dashes
+	//
+	// In bare values, # only starts a comment at the start of the value, or after whitespace.
+	Dotenv bool
+
+	// If true, lines of the form "#include PATH" or "@include PATH" are replaced by the contents
+	// of the file at PATH, which is relative to the directory of the including file (for ReadFile),
+	// or the current directory (for Unmarshal). Includes may be nested up to MaxIncludeDepth deep,
+	// but may not include themselves.
+	//
+	// Since "#include" is a comment otherwise, files using it can still be read without Include.
+	Include bool
+
+	// What to do when a key appears more than once (including in included files).
+	Duplicates DuplicatePolicy
+
+	// If set, values which are secret references (see SecretRef) are replaced by the secret,
+	// after any expansion. Failing to resolve one is an error.
+	Secrets SecretResolver
+
+	// If not nil, the reference of each value resolved by Secrets is recorded in it,
+	// so that MarshalOptions.SecretRefs can put them back when writing the KVs out.
+	SecretRefs SecretRefs
+}
+
+// DuplicatePolicy says what to do when a key appears more than once. See UnmarshalOptions.Duplicates.
+type DuplicatePolicy int
+
+const (
+	// Duplicate keys are an error.
+	DuplicateError DuplicatePolicy = iota
+	// The first value of a key is used, and later ones are ignored.
+	DuplicateFirstWins
+	// The last value of a key is used, in the position where the key first appeared.
+	DuplicateLastWins
+)
+
+// The deepest that includes may be nested. See UnmarshalOptions.Include.
+const MaxIncludeDepth = 8
+
 // Unmarshal parses a byte slice of KV
-// Returns an error describing the first encountered formatting issue, with line numbers.
+// Returns an error describing the first encountered formatting issue, as a *ParseError.
 func Unmarshal(b []byte) ([]KV, error) {
-	b = bytes.ReplaceAll(b, []byte("\r\n"), []byte("\n"))
-	lines := bytes.Split(b, []byte("\n"))
+	return UnmarshalOptions{}.Unmarshal(b)
+}
 
-	seen := map[string]struct{}{}
-	var out []KV
+// Unmarshal parses a byte slice of KV, as the Unmarshal function does, but using the options in o.
+func (o UnmarshalOptions) Unmarshal(b []byte) ([]KV, error) {
+	kvs, _, err := o.unmarshal(b, "")
+	return kvs, err
+}
 
-	for ln, line := range lines {
-		i := 0
+// ReadFile reads and parses the file at path, using the options in o.
+// Errors in the file are prefixed with its path.
+func (o UnmarshalOptions) ReadFile(path string) ([]KV, error) {
+	kvs, _, err := o.readFile(path)
+	return kvs, err
+}
 
-		skipWhitespace := func() {
-			for i < len(line) && (line[i] == ' ' || line[i] == '\t') {
-				i++
+// Reads and parses the file at path, returning the KVs, and where each was read from.
+func (o UnmarshalOptions) readFile(path string) ([]KV, []pos, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return o.unmarshal(b, path)
+}
+
+// Parses b, which was read from file (or "", if it wasn't read from a file),
+// returning the KVs, and where each was read from.
+func (o UnmarshalOptions) unmarshal(b []byte, file string) ([]KV, []pos, error) {
+	var stack []string
+	if file != "" {
+		stack = append(stack, file)
+	}
+	out, positions, err := o.parse(b, file, stack)
+	if err != nil {
+		return nil, nil, err
+	}
+	if o.Expand {
+		if err := expand(out, positions, o.LookupEnv); err != nil {
+			return nil, nil, err
+		}
+	}
+	if o.Secrets != nil {
+		if err := resolveSecrets(out, positions, o.Secrets, o.SecretRefs); err != nil {
+			return nil, nil, err
+		}
+	}
+	return out, positions, nil
+}
+
+// Where a KV was read from.
+type pos struct {
+	file    string
+	line    int    // from zero
+	col     int    // from zero
+	text    string // the whole line
+	literal bool   // the value was single-quoted, so isn't expanded
+}
+
+// Parses b, which was read from file (or "", if it wasn't read from a file), returning the KVs
+// it contains, and where each is. stack is the files being included, outermost first.
+func (o UnmarshalOptions) parse(b []byte, file string, stack []string) ([]KV, []pos, error) {
+	// Keys and values are substrings of a single copy of the input, rather than each being copied.
+	// The number of lines bounds the number of KVs, barring includes.
+	s := string(b)
+	n := strings.Count(s, "\n") + 1
+	seen := make(map[string]int, n) // the index of each key in out
+	out := make([]KV, 0, n)
+	positions := make([]pos, 0, n)
+
+	add := func(kv KV, p pos) error {
+		if i, ok := seen[kv.Key]; ok {
+			switch o.Duplicates {
+			case DuplicateFirstWins:
+			case DuplicateLastWins:
+				out[i].Value = kv.Value
+				positions[i] = p
+			default:
+				return newParseError(p, CodeDuplicateKey, "duplicate key")
 			}
+			return nil
 		}
+		seen[kv.Key] = len(out)
+		out = append(out, kv)
+		positions = append(positions, p)
+		return nil
+	}
 
-		// Skip leading whitespace
-		skipWhitespace()
+	for ln := 0; s != ""; ln++ {
+		line, rest, _ := strings.Cut(s, "\n")
+		s = rest
+		line = strings.TrimSuffix(line, "\r")
 
-		// Skip comments
-		if i == len(line) || line[i] == '#' {
+		if path, ok := includePath(line); ok && o.Include {
+			kvs, kvPositions, err := o.include(path, file, stack)
+			if err != nil {
+				return nil, nil, inFile(pos{file: file, line: ln, text: line}, err)
+			}
+			for i, kv := range kvs {
+				if err := add(kv, kvPositions[i]); err != nil {
+					return nil, nil, err
+				}
+			}
 			continue
 		}
 
-		start := i
-		for i < len(line) && isKeyChar(line[i]) {
-			i++
+		key, val, keyStart, _, literal, err := parseLine(line, ln, o.Dotenv)
+		if err != nil {
+			return nil, nil, inFile(pos{file: file}, err)
+		}
+		if key == "" {
+			continue
 		}
-		if start == i {
-			return nil, errf(ln, "empty or invalid key")
+		if err := add(KV{Key: key, Value: val}, pos{file, ln, keyStart, line, literal}); err != nil {
+			return nil, nil, err
 		}
-		key := string(line[start:i])
+	}
 
-		// Skip whitespace trailing key
-		skipWhitespace()
+	return out, positions, nil
+}
 
-		if i == len(line) || line[i] != '=' {
-			return nil, errf(ln, "missing =")
+// Returns the path of an include directive, and whether line is one. See UnmarshalOptions.Include.
+func includePath(line string) (string, bool) {
+	line = strings.TrimSpace(line)
+	for _, directive := range []string{"#include", "@include"} {
+		if rest, ok := strings.CutPrefix(line, directive); ok && len(rest) > 0 && (rest[0] == ' ' || rest[0] == '\t') {
+			return strings.TrimSpace(rest), true
 		}
+	}
+	return "", false
+}
+
+// Reads and parses the file at path, included from file.
+func (o UnmarshalOptions) include(path, file string, stack []string) ([]KV, []pos, error) {
+	if !filepath.IsAbs(path) && file != "" {
+		path = filepath.Join(filepath.Dir(file), path)
+	}
+	if len(stack) >= MaxIncludeDepth {
+		return nil, nil, fmt.Errorf("include %s: nested too deeply", path)
+	}
+	for _, f := range stack {
+		if sameFile(f, path) {
+			return nil, nil, fmt.Errorf("include %s: includes itself", path)
+		}
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("include: %w", err)
+	}
+	return o.parse(b, path, append(stack[:len(stack):len(stack)], path))
+}
+
+// Returns whether the paths a and b refer to the same file.
+func sameFile(a, b string) bool {
+	if filepath.Clean(a) == filepath.Clean(b) {
+		return true
+	}
+	fa, errA := os.Stat(a)
+	fb, errB := os.Stat(b)
+	return errA == nil && errB == nil && os.SameFile(fa, fb)
+}
+
+// Parses line (number ln, from zero), returning its key and value, or an empty key if it is blank or a comment.
+// keyStart is where the key starts, and end is where the value ends, and any trailing whitespace or comment starts.
+// literal is true if the value was single-quoted, so mustn't be expanded.
+// If dotenv is true, the .env dialect is accepted too; see UnmarshalOptions.Dotenv.
+func parseLine(line string, ln int, dotenv bool) (key, val string, keyStart, end int, literal bool, err error) {
+	i := 0
+	fail := func(col int, code ErrorCode, msg string) (string, string, int, int, bool, error) {
+		return "", "", 0, 0, false, newParseError(pos{line: ln, col: col, text: line}, code, msg)
+	}
+
+	skipWhitespace := func() {
+		for i < len(line) && (line[i] == ' ' || line[i] == '\t') {
+			i++
+		}
+	}
+
+	// Skip leading whitespace
+	skipWhitespace()
+
+	// Skip comments
+	if i == len(line) || line[i] == '#' {
+		return "", "", 0, 0, false, nil
+	}
+
+	if rest, ok := strings.CutPrefix(line[i:], "export"); dotenv && ok && len(rest) > 0 && (rest[0] == ' ' || rest[0] == '\t') {
+		i += len("export")
+		skipWhitespace()
+	}
+
+	keyStart = i
+	for i < len(line) && (isKeyChar(line[i]) || dotenv && (line[i] == '.' || line[i] == '-')) {
 		i++
+	}
+	if keyStart == i {
+		return fail(i, CodeInvalidKey, "empty or invalid key")
+	}
+	key = line[keyStart:i]
+
+	// Skip whitespace trailing key
+	skipWhitespace()
 
-		// Skip whitespace trailing =
+	if i == len(line) || line[i] != '=' {
+		return fail(i, CodeMissingEquals, "missing =")
+	}
+	i++
+
+	// Skip whitespace trailing =
+	skipWhitespace()
+
+	if dotenv && i < len(line) && line[i] == '\'' {
+		start := i + 1
+		n := strings.IndexByte(line[start:], '\'')
+		if n < 0 {
+			return fail(i, CodeUnterminatedQuote, "unterminated quote")
+		}
+		val = line[start : start+n]
+		literal = true
+		i = start + n + 1
+		end = i
+
+		// Skip whitespace trailing value
 		skipWhitespace()
 
-		var val string
-		if i < len(line) && line[i] == '"' {
-			i++
-			var buf []byte
-			for {
-				if i >= len(line) {
-					return nil, errf(ln, "unterminated quote")
+		if i < len(line) && line[i] != '#' {
+			return fail(i, CodeTrailingCharacters, "trailing characters after quoted value")
+		}
+	} else if i < len(line) && line[i] == '"' {
+		quote := i
+		i++
+		start := i
+		// The value is only copied (into buf) if it has escapes.
+		var buf []byte
+		escaped := false
+		for {
+			if i >= len(line) {
+				return fail(quote, CodeUnterminatedQuote, "unterminated quote")
+			}
+			if line[i] == '"' {
+				i++
+				break
+			}
+			if line[i] == '\\' {
+				if !escaped {
+					buf = append(make([]byte, 0, len(line)-start), line[start:i]...)
+					escaped = true
 				}
-				if line[i] == '"' {
-					i++
-					break
+				i++
+				if i >= len(line) {
+					return fail(i-1, CodeBadEscape, "bad escape")
 				}
-				if line[i] == '\\' {
-					i++
-					if i >= len(line) {
-						return nil, errf(ln, "bad escape")
-					}
-					switch line[i] {
-					case '"':
-						buf = append(buf, '"')
-					case 'n':
-						buf = append(buf, '\n')
-					default:
-						return nil, errf(ln, "unknown escape")
-					}
-					i++
-					continue
+				switch line[i] {
+				case '"':
+					buf = append(buf, '"')
+				case '\\':
+					buf = append(buf, '\\')
+				case 'n':
+					buf = append(buf, '\n')
+				default:
+					return fail(i-1, CodeBadEscape, "unknown escape")
 				}
-				buf = append(buf, line[i])
 				i++
+				continue
 			}
+			if escaped {
+				buf = append(buf, line[i])
+			}
+			i++
+		}
+		if escaped {
 			val = string(buf)
+		} else {
+			val = line[start : i-1]
+		}
+		end = i
 
-			// Skip whitespace trailing value
-			skipWhitespace()
+		// Skip whitespace trailing value
+		skipWhitespace()
 
-			if i < len(line) && line[i] != '#' {
-				return nil, errf(ln, "trailing characters after quoted value")
+		if i < len(line) && line[i] != '#' {
+			return fail(i, CodeTrailingCharacters, "trailing characters after quoted value")
+		}
+	} else if dotenv {
+		start := i
+		for i < len(line) && !(line[i] == '#' && (i == start || line[i-1] == ' ' || line[i-1] == '\t')) {
+			i++
+		}
+		val = strings.TrimRight(line[start:i], " \t")
+		end = start + len(val)
+	} else {
+		start := i
+		for i < len(line) && line[i] != '#' {
+			if line[i] == ' ' || line[i] == '\t' {
+				return fail(i, CodeBareValue, "whitespace in bare value")
 			}
-		} else {
-			start = i
-			for i < len(line) && line[i] != '#' {
-				if line[i] == ' ' || line[i] == '\t' {
-					return nil, errf(ln, "whitespace in bare value")
+			if line[i] == '\\' {
+				return fail(i, CodeBareValue, "backslash in bare value")
+			}
+			i++
+		}
+		val = line[start:i]
+		end = i
+	}
+
+	return key, val, keyStart, end, literal, nil
+}
+
+// Expands references to other keys in the values of kvs, in place. See UnmarshalOptions.Expand.
+func expand(kvs []KV, positions []pos, lookupEnv func(string) (string, bool)) error {
+	// Only the keys defined so far may be referred to, so each is expanded in turn.
+	index := make(map[string]int, len(kvs))
+	for i := range kvs {
+		if !positions[i].literal {
+			val, err := expandValue(kvs[i].Value, positions[i], func(name string) (string, bool) {
+				if j, ok := index[name]; ok {
+					return kvs[j].Value, true
 				}
-				if line[i] == '\\' {
-					return nil, errf(ln, "backslash in bare value")
+				if lookupEnv != nil {
+					return lookupEnv(name)
 				}
-				i++
+				return "", false
+			})
+			if err != nil {
+				return err
 			}
-			val = string(line[start:i])
+			kvs[i].Value = val
 		}
+		index[kvs[i].Key] = i
+	}
+	return nil
+}
 
-		if _, ok := seen[key]; ok {
-			return nil, errf(ln, "duplicate key")
+// Returns s, the value of the KV at p, with references replaced by the result of lookup.
+// See UnmarshalOptions.Expand.
+func expandValue(s string, p pos, lookup func(name string) (string, bool)) (string, error) {
+	if !strings.Contains(s, "$") {
+		return s, nil
+	}
+	fail := func(off int, code ErrorCode, msg string) error {
+		p.col = valueCol(p, off)
+		return newParseError(p, code, msg)
+	}
+	var buf strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 == len(s) {
+			buf.WriteByte(s[i])
+			continue
 		}
-		seen[key] = struct{}{}
-		out = append(out, KV{Key: key, Value: val})
+		ref := i
+		var name string
+		switch c := s[i+1]; {
+		case c == '$':
+			buf.WriteByte('$')
+			i++
+			continue
+		case c == '{':
+			end := strings.IndexByte(s[i+2:], '}')
+			if end < 0 {
+				return "", fail(ref, CodeBadReference, "unterminated ${")
+			}
+			name = s[i+2 : i+2+end]
+			for j := 0; j < len(name); j++ {
+				if !isKeyChar(name[j]) {
+					return "", fail(ref, CodeBadReference, fmt.Sprintf("invalid reference ${%s}", name))
+				}
+			}
+			if name == "" {
+				return "", fail(ref, CodeBadReference, "empty reference ${}")
+			}
+			i += 2 + end
+		case isKeyChar(c):
+			j := i + 1
+			for j < len(s) && isKeyChar(s[j]) {
+				j++
+			}
+			name = s[i+1 : j]
+			i = j - 1
+		default:
+			buf.WriteByte('$')
+			continue
+		}
+		val, ok := lookup(name)
+		if !ok {
+			return "", fail(ref, CodeUndefinedReference, "undefined reference to "+name)
+		}
+		buf.WriteString(val)
+	}
+	return buf.String(), nil
+}
+
+// Returns the column (from zero) in p.text of byte off of the (unescaped) value of the KV at p.
+func valueCol(p pos, off int) int {
+	text := p.text
+	i := p.col + strings.IndexByte(text[p.col:], '=') + 1
+	for i < len(text) && (text[i] == ' ' || text[i] == '\t') {
+		i++
+	}
+	if i == len(text) || text[i] != '"' {
+		return i + off
+	}
+	// Each escape is two bytes in the line, but one in the value.
+	for i++; off > 0 && i < len(text); off-- {
+		if text[i] == '\\' {
+			i++
+		}
+		i++
 	}
+	return i
+}
+
+// MarshalOptions configures serializing beyond what Marshal does.
+type MarshalOptions struct {
+	// If not nil, the values of keys in it are written as their secret reference, rather than
+	// their actual value, so that files containing secrets can be written (and committed) safely.
+	// It is typically filled in by UnmarshalOptions.SecretRefs.
+	SecretRefs SecretRefs
 
-	return out, nil
+	// The syntax written. If zero, it is FormatEnvkv.
+	Format Format
 }
 
+// A Format is a syntax that MarshalOptions.Marshal can write, so that one file can feed different tools.
+type Format int
+
+const (
+	// The envkv format, as read by Unmarshal.
+	FormatEnvkv Format = iota
+	// Shell commands, to be sourced by sh and compatible shells, e.g. export KEY='value'.
+	// Keys may not start with a digit.
+	FormatShell
+	// The env-file format of docker (--env-file) and docker compose, in which values are
+	// taken literally, so they may not contain newlines.
+	FormatDocker
+)
+
 // Marshal serializes a slice of KV in key=value format, one per line.
 func Marshal(kv []KV) ([]byte, error) {
+	return MarshalOptions{}.Marshal(kv)
+}
+
+// Marshal serializes a slice of KV, as the Marshal function does, but using the options in o.
+func (o MarshalOptions) Marshal(kv []KV) ([]byte, error) {
 	seen := map[string]struct{}{}
 	var buf bytes.Buffer
 
 	for _, e := range kv {
-		if e.Key == "" {
-			return nil, errors.New("empty key")
-		}
-		for i := 0; i < len(e.Key); i++ {
-			if !isKeyChar(e.Key[i]) {
-				return nil, errors.New("invalid key")
-			}
+		if err := checkKey(e.Key); err != nil {
+			return nil, err
 		}
 		if _, ok := seen[e.Key]; ok {
 			return nil, errors.New("duplicate key")
 		}
 		seen[e.Key] = struct{}{}
 
-		buf.WriteString(e.Key)
-		buf.WriteByte('=')
+		if ref, ok := o.SecretRefs[e.Key]; ok {
+			e.Value = ref.String()
+		}
 
-		if needsQuotes(e.Value) {
-			buf.WriteByte('"')
-			for i := 0; i < len(e.Value); i++ {
-				switch e.Value[i] {
-				case '"':
-					buf.WriteString(`\"`)
-				case '\n':
-					buf.WriteString(`\n`)
-				default:
-					buf.WriteByte(e.Value[i])
-				}
+		switch o.Format {
+		case FormatShell:
+			if e.Key[0] >= '0' && e.Key[0] <= '9' {
+				return nil, fmt.Errorf("%s: invalid key for the shell", e.Key)
 			}
-			buf.WriteByte('"')
-		} else {
-			buf.WriteString(e.Value)
+			buf.WriteString("export " + e.Key + "='")
+			buf.WriteString(strings.ReplaceAll(e.Value, "'", `'\''`))
+			buf.WriteByte('\'')
+		case FormatDocker:
+			if strings.ContainsAny(e.Value, "\r\n") {
+				return nil, fmt.Errorf("%s: value contains a newline", e.Key)
+			}
+			buf.WriteString(e.Key + "=" + e.Value)
+		default:
+			buf.WriteString(e.Key)
+			buf.WriteByte('=')
+			appendValue(&buf, e.Value)
 		}
 		buf.WriteByte('\n')
 	}
@@ -188,10 +586,46 @@ func Marshal(kv []KV) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+// Writes v to buf, quoting it if needed.
+func appendValue(buf *bytes.Buffer, v string) {
+	if !needsQuotes(v) {
+		buf.WriteString(v)
+		return
+	}
+	buf.WriteByte('"')
+	for i := 0; i < len(v); i++ {
+		switch v[i] {
+		case '"':
+			buf.WriteString(`\"`)
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\n':
+			buf.WriteString(`\n`)
+		default:
+			buf.WriteByte(v[i])
+		}
+	}
+	buf.WriteByte('"')
+}
+
+// Returns an error if key can't be used as a key.
+func checkKey(key string) error {
+	if key == "" {
+		return errors.New("empty key")
+	}
+	for i := 0; i < len(key); i++ {
+		if !isKeyChar(key[i]) {
+			return errors.New("invalid key")
+		}
+	}
+	return nil
+}
+
 func isKeyChar(b byte) bool {
 	return (b >= 'a' && b <= 'z') ||
 		(b >= 'A' && b <= 'Z') ||
-		(b >= '0' && b <= '9')
+		(b >= '0' && b <= '9') ||
+		b == '_'
 }
 
 func needsQuotes(s string) bool {
@@ -200,13 +634,9 @@ func needsQuotes(s string) bool {
 	}
 	for i := 0; i < len(s); i++ {
 		switch s[i] {
-		case ' ', '\t', '#', '"', '\n':
+		case ' ', '\t', '#', '"', '\\', '\n', '\r':
 			return true
 		}
 	}
 	return false
 }
-
-func errf(line int, msg string) error {
-	return fmt.Errorf("line %d: %s", line, msg)
-}
@@ -5,6 +5,11 @@
 package envkv
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
 	"testing"
 )
 
@@ -36,6 +41,11 @@ func TestUnmarshalMarshal(t *testing.T) {
 			input: `FOO="b\"a\nr"`,
 			want:  []KV{{Key: "FOO", Value: "b\"a\nr"}},
 		},
+		{
+			name:  "quoted value with backslashes",
+			input: `FOO="C:\\temp\\n\""`,
+			want:  []KV{{Key: "FOO", Value: `C:\temp\n"`}},
+		},
 		{
 			name:  "bare value with comment",
 			input: `FOO=bar# comment`,
@@ -110,6 +120,11 @@ BAZ="qux"
 			input:   `FOO=\bar`,
 			wantErr: true,
 		},
+		{
+			name:  "underscores in key",
+			input: `WELCOME_MESSAGE=hi`,
+			want:  []KV{{Key: "WELCOME_MESSAGE", Value: "hi"}},
+		},
 		{
 			name:    "UTF-8 key",
 			input:   `æøå=FOO`,
@@ -168,3 +183,330 @@ func equalKV(a, b []KV) bool {
 	}
 	return true
 }
+
+func TestUnmarshalExpand(t *testing.T) {
+	env := map[string]string{"HOME": "/home/gopher", "HOST": "env.example"}
+	lookupEnv := func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}
+
+	tests := []struct {
+		name    string
+		input   string
+		env     bool
+		dotenv  bool
+		want    []KV
+		wantErr string
+	}{
+		{
+			name:  "braced and bare references",
+			input: "HOST=db\nPORT=5432\nURL=\"postgres://${HOST}:$PORT/app\"",
+			want:  []KV{{"HOST", "db"}, {"PORT", "5432"}, {"URL", "postgres://db:5432/app"}},
+		},
+		{
+			name:  "chained references",
+			input: "C=c\nB=${C}b\nA=$B/a",
+			want:  []KV{{"C", "c"}, {"B", "cb"}, {"A", "cb/a"}},
+		},
+		{
+			name:  "escapes and lone dollars",
+			input: `A="$$HOME costs $ 5$"`,
+			want:  []KV{{"A", "$HOME costs $ 5$"}},
+		},
+		{
+			name:   "single-quoted values aren't expanded",
+			input:  "HOST=db\nLIT='$HOST ${NOPE}'\nURL=\"${LIT}\"\nBARE=$HOST",
+			dotenv: true,
+			want:   []KV{{"HOST", "db"}, {"LIT", "$HOST ${NOPE}"}, {"URL", "$HOST ${NOPE}"}, {"BARE", "db"}},
+		},
+		{
+			name:  "environment",
+			input: "DATA=$HOME/data\nHOST=local\nURL=$HOST",
+			env:   true,
+			want:  []KV{{"DATA", "/home/gopher/data"}, {"HOST", "local"}, {"URL", "local"}},
+		},
+		{
+			name:  "self reference uses the environment",
+			input: "HOME=$HOME/sub\nCACHE=$HOME/cache",
+			env:   true,
+			want:  []KV{{"HOME", "/home/gopher/sub"}, {"CACHE", "/home/gopher/sub/cache"}},
+		},
+		{
+			name:  "later keys use the environment",
+			input: "URL=$HOST\nHOST=local",
+			env:   true,
+			want:  []KV{{"URL", "env.example"}, {"HOST", "local"}},
+		},
+		{
+			name:    "environment not used unless given",
+			input:   "DATA=$HOME/data",
+			wantErr: "line 1, column 6: undefined reference to HOME",
+		},
+		{
+			name:    "undefined",
+			input:   "A=1\nB=x${NOPE}",
+			env:     true,
+			wantErr: "line 2, column 4: undefined reference to NOPE",
+		},
+		{
+			name:    "later keys aren't referred to",
+			input:   "A=$B\nB=1",
+			wantErr: "line 1, column 3: undefined reference to B",
+		},
+		{
+			name:    "self reference without the environment",
+			input:   "PATH=$PATH",
+			wantErr: "line 1, column 6: undefined reference to PATH",
+		},
+		{
+			name:    "unterminated",
+			input:   "A=${B",
+			wantErr: "line 1, column 3: unterminated ${",
+		},
+		{
+			name:    "invalid name",
+			input:   `A = "\"x\" ${B C}"`,
+			wantErr: "line 1, column 12: invalid reference ${B C}",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := UnmarshalOptions{Expand: true, Dotenv: tt.dotenv}
+			if tt.env {
+				opts.LookupEnv = lookupEnv
+			}
+			got, err := opts.Unmarshal([]byte(tt.input))
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !equalKV(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	// Without Expand, values are left alone.
+	got, err := Unmarshal([]byte("A=$B"))
+	if err != nil || !equalKV(got, []KV{{"A", "$B"}}) {
+		t.Errorf("Unmarshal = %+v, %v", got, err)
+	}
+}
+
+func TestUnmarshalDotenv(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []KV
+		wantErr bool
+	}{
+		{
+			name:  "export prefix",
+			input: "export FOO=bar\n\texport\tBAR=baz",
+			want:  []KV{{"FOO", "bar"}, {"BAR", "baz"}},
+		},
+		{
+			name:  "key named export",
+			input: "export=1",
+			want:  []KV{{"export", "1"}},
+		},
+		{
+			name:  "single quotes are literal",
+			input: `FOO='C:\path "x" $y' # comment`,
+			want:  []KV{{"FOO", `C:\path "x" $y`}},
+		},
+		{
+			name:  "bare values with spaces",
+			input: "FOO=hello world   # comment\nBAR=a#b\nBAZ=#empty",
+			want:  []KV{{"FOO", "hello world"}, {"BAR", "a#b"}, {"BAZ", ""}},
+		},
+		{
+			name:  "dots, dashes and underscores in keys",
+			input: "app.log-level=debug\nMY_KEY=1",
+			want:  []KV{{"app.log-level", "debug"}, {"MY_KEY", "1"}},
+		},
+		{
+			name:  "double quotes as usual",
+			input: `FOO="a\"b"`,
+			want:  []KV{{"FOO", `a"b`}},
+		},
+		{
+			name:    "unterminated single quote",
+			input:   "FOO='bar",
+			wantErr: true,
+		},
+		{
+			name:    "trailing characters after single quote",
+			input:   "FOO='bar'baz",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := UnmarshalOptions{Dotenv: true}.Unmarshal([]byte(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !equalKV(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	// None of this is accepted without Dotenv, except underscores.
+	for _, input := range []string{"export FOO=bar", "FOO=a b", "a.b=c"} {
+		if _, err := Unmarshal([]byte(input)); err == nil {
+			t.Errorf("Unmarshal(%q) should fail", input)
+		}
+	}
+}
+
+func TestUnmarshalDuplicates(t *testing.T) {
+	const input = "A=1\nB=2\nA=3\nA=4"
+	tests := []struct {
+		policy  DuplicatePolicy
+		want    []KV
+		wantErr bool
+	}{
+		{DuplicateError, nil, true},
+		{DuplicateFirstWins, []KV{{"A", "1"}, {"B", "2"}}, false},
+		{DuplicateLastWins, []KV{{"A", "4"}, {"B", "2"}}, false},
+	}
+	for _, tt := range tests {
+		got, err := UnmarshalOptions{Duplicates: tt.policy}.Unmarshal([]byte(input))
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("policy %d: error = %v, wantErr %v", tt.policy, err, tt.wantErr)
+		}
+		if !equalKV(got, tt.want) {
+			t.Errorf("policy %d: got %+v, want %+v", tt.policy, got, tt.want)
+		}
+	}
+
+	// Expansion uses the winning value, and reports errors where it is.
+	opts := UnmarshalOptions{Duplicates: DuplicateLastWins, Expand: true}
+	got, err := opts.Unmarshal([]byte("A=1\nB=$A\nA=2"))
+	if err != nil || !equalKV(got, []KV{{"A", "2"}, {"B", "2"}}) {
+		t.Errorf("expand got %+v, %v", got, err)
+	}
+	if _, err := opts.Unmarshal([]byte("A=1\nA=$NOPE")); err == nil || err.Error() != "line 2, column 3: undefined reference to NOPE" {
+		t.Errorf("expand error = %v", err)
+	}
+}
+
+func TestMarshalFormats(t *testing.T) {
+	kvs := []KV{{"A", "plain"}, {"B", "it's \"quoted\" $HOME"}, {"C", ""}}
+	tests := []struct {
+		format  Format
+		kvs     []KV
+		want    string
+		wantErr bool
+	}{
+		{FormatEnvkv, kvs, "A=plain\nB=\"it's \\\"quoted\\\" $HOME\"\nC=\n", false},
+		{FormatShell, kvs, "export A='plain'\nexport B='it'\\''s \"quoted\" $HOME'\nexport C=''\n", false},
+		{FormatShell, []KV{{"A", "two\nlines"}}, "export A='two\nlines'\n", false},
+		{FormatShell, []KV{{"1A", "x"}}, "", true},
+		{FormatDocker, kvs, "A=plain\nB=it's \"quoted\" $HOME\nC=\n", false},
+		{FormatDocker, []KV{{"A", "two\nlines"}}, "", true},
+	}
+	for _, tt := range tests {
+		got, err := MarshalOptions{Format: tt.format}.Marshal(tt.kvs)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("format %d: error = %v, wantErr %v", tt.format, err, tt.wantErr)
+		}
+		if string(got) != tt.want {
+			t.Errorf("format %d: got %q, want %q", tt.format, got, tt.want)
+		}
+	}
+
+	// The shell gets back exactly the values.
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("no sh")
+	}
+	kvs = append(kvs, KV{"D", "two\nlines"})
+	script, _ := MarshalOptions{Format: FormatShell}.Marshal(kvs)
+	script = append(script, `printf '%s|%s|%s|%s' "$A" "$B" "$C" "$D"`...)
+	out, err := exec.Command(sh, "-c", string(script)).Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "plain|it's \"quoted\" $HOME||two\nlines"; string(out) != want {
+		t.Errorf("sh got %q, want %q", out, want)
+	}
+}
+
+func BenchmarkUnmarshal(b *testing.B) {
+	var buf bytes.Buffer
+	buf.WriteString("# generated\n")
+	for i := range 1000 {
+		fmt.Fprintf(&buf, "KEY_%d=value%d\n", i, i)
+		fmt.Fprintf(&buf, "QUOTED_%d = \"a \\\"quoted\\\" value\" # comment\n", i)
+		fmt.Fprintf(&buf, "PLAIN_QUOTED_%d=\"no escapes here\"\n\n", i)
+	}
+	input := buf.Bytes()
+	b.SetBytes(int64(len(input)))
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := Unmarshal(input); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func FuzzUnmarshal(f *testing.F) {
+	for _, s := range []string{
+		"A=1\nB=2\n",
+		"# comment\r\nA = \"quoted \\\"value\\\"\\n\" # trailing\r\n\n",
+		"export A='single' # c\nb.c-d=bare value with spaces\n",
+		"A=\"unterminated\nB",
+		"A=1\nA=2",
+		"=\n\"\n\\",
+		"\r",
+		"A=x\r",
+	} {
+		f.Add(s, false)
+		f.Add(s, true)
+	}
+	f.Fuzz(func(t *testing.T, input string, dotenv bool) {
+		opts := UnmarshalOptions{Dotenv: dotenv}
+		kvs, err := opts.Unmarshal([]byte(input))
+		if err != nil {
+			var pe *ParseError
+			if !errors.As(err, &pe) || pe.Line < 1 || pe.Column < 1 || pe.Column > len(pe.Text)+1 {
+				t.Fatalf("bad error %#v", err)
+			}
+		}
+
+		// The Decoder agrees with Unmarshal.
+		d, _ := opts.NewDecoder(strings.NewReader(input))
+		streamed, streamErr := decodeAll(d)
+		if (err == nil) != (streamErr == nil) || (err != nil && err.Error() != streamErr.Error()) {
+			t.Fatalf("Unmarshal error %v, Decoder error %v", err, streamErr)
+		}
+		if err != nil {
+			return
+		}
+		if !equalKV(kvs, streamed) {
+			t.Fatalf("Unmarshal %q, Decoder %q", kvs, streamed)
+		}
+
+		// Marshal can write anything but the keys only the .env dialect accepts (e.g. with dots).
+		out, err := Marshal(kvs)
+		if err != nil {
+			if dotenv {
+				return
+			}
+			t.Fatalf("Marshal(%q): %v", kvs, err)
+		}
+		again, err := Unmarshal(out)
+		if err != nil || !equalKV(kvs, again) {
+			t.Fatalf("Unmarshal(Marshal(%q)) = %q, %v", kvs, again, err)
+		}
+	})
+}
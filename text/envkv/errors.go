@@ -0,0 +1,69 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package envkv
+
+import (
+	"errors"
+	"fmt"
+)
+
+// An ErrorCode identifies the kind of a ParseError.
+type ErrorCode string
+
+const (
+	CodeInvalidKey         ErrorCode = "invalid-key"
+	CodeMissingEquals      ErrorCode = "missing-equals"
+	CodeUnterminatedQuote  ErrorCode = "unterminated-quote"
+	CodeBadEscape          ErrorCode = "bad-escape"
+	CodeTrailingCharacters ErrorCode = "trailing-characters"
+	CodeBareValue          ErrorCode = "bare-value" // whitespace or backslash in an unquoted value
+	CodeDuplicateKey       ErrorCode = "duplicate-key"
+	CodeBadReference       ErrorCode = "bad-reference"
+	CodeUndefinedReference ErrorCode = "undefined-reference"
+	CodeInclude            ErrorCode = "include"
+	CodeSecret             ErrorCode = "secret"
+)
+
+// A ParseError describes a problem with the input, and where it is,
+// so that tools can point users at it.
+type ParseError struct {
+	File   string // The file, or "" if the input wasn't read from a file
+	Line   int    // The line, from 1
+	Column int    // The column (in bytes), from 1
+	Text   string // The whole of the line
+	Code   ErrorCode
+	Msg    string
+}
+
+func (e *ParseError) Error() string {
+	msg := fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Msg)
+	if e.File != "" {
+		return e.File + ": " + msg
+	}
+	return msg
+}
+
+func newParseError(p pos, code ErrorCode, msg string) *ParseError {
+	return &ParseError{
+		File:   p.file,
+		Line:   p.line + 1,
+		Column: p.col + 1,
+		Text:   p.text,
+		Code:   code,
+		Msg:    msg,
+	}
+}
+
+// Returns err, which happened at p, as a ParseError, unless it already is one for another file.
+func inFile(p pos, err error) error {
+	var pe *ParseError
+	if !errors.As(err, &pe) {
+		return newParseError(p, CodeInclude, err.Error())
+	}
+	if pe.File == "" {
+		pe.File = p.file
+	}
+	return pe
+}
@@ -0,0 +1,44 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package envkv
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseError(t *testing.T) {
+	tests := []struct {
+		input    string
+		opts     UnmarshalOptions
+		wantLine int
+		wantCol  int
+		wantCode ErrorCode
+		wantText string
+	}{
+		{"A=1\n  =2", UnmarshalOptions{}, 2, 3, CodeInvalidKey, "  =2"},
+		{"A 1", UnmarshalOptions{}, 1, 3, CodeMissingEquals, "A 1"},
+		{`A = "abc`, UnmarshalOptions{}, 1, 5, CodeUnterminatedQuote, `A = "abc`},
+		{`A="a\tb"`, UnmarshalOptions{}, 1, 5, CodeBadEscape, `A="a\tb"`},
+		{`A="a" b`, UnmarshalOptions{}, 1, 7, CodeTrailingCharacters, `A="a" b`},
+		{"A=a b", UnmarshalOptions{}, 1, 4, CodeBareValue, "A=a b"},
+		{"A=1\r\n\r\n  A=2", UnmarshalOptions{}, 3, 3, CodeDuplicateKey, "  A=2"},
+		{"A=$B", UnmarshalOptions{Expand: true}, 1, 3, CodeUndefinedReference, "A=$B"},
+		{`A = "\\x ${B"`, UnmarshalOptions{Expand: true}, 1, 10, CodeBadReference, `A = "\\x ${B"`},
+		{"@include /nonexistent", UnmarshalOptions{Include: true}, 1, 1, CodeInclude, "@include /nonexistent"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.wantCode), func(t *testing.T) {
+			_, err := tt.opts.Unmarshal([]byte(tt.input))
+			var pe *ParseError
+			if !errors.As(err, &pe) {
+				t.Fatalf("error = %v, want a *ParseError", err)
+			}
+			if pe.Line != tt.wantLine || pe.Column != tt.wantCol || pe.Code != tt.wantCode || pe.Text != tt.wantText {
+				t.Errorf("got %+v, want line %d, column %d, code %s, text %q", pe, tt.wantLine, tt.wantCol, tt.wantCode, tt.wantText)
+			}
+		})
+	}
+}
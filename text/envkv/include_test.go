@@ -0,0 +1,102 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package envkv
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInclude(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	write("shared/db.envkv", "DBHOST=db\n@include ports.envkv\n")
+	write("shared/ports.envkv", "DBPORT=5432\n")
+	write("shared/bad.envkv", "OOPS\n")
+	write("loop.envkv", "#include loop.envkv\n")
+	write("a.envkv", "#include b.envkv\n")
+	write("b.envkv", "  #include a.envkv\n")
+	for i := range MaxIncludeDepth {
+		write("deep"+strings.Repeat("x", i)+".envkv", "#include deep"+strings.Repeat("x", i+1)+".envkv\n")
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		want    []KV
+		wantErr string
+	}{
+		{
+			name:    "nested and relative",
+			content: "NAME=api\n#include shared/db.envkv\nUSER=app",
+			want:    []KV{{"NAME", "api"}, {"DBHOST", "db"}, {"DBPORT", "5432"}, {"USER", "app"}},
+		},
+		{
+			name:    "duplicates across files",
+			content: "DBPORT=1\n#include shared/ports.envkv",
+			wantErr: "ports.envkv: line 1, column 1: duplicate key",
+		},
+		{
+			name:    "error in included file",
+			content: "#include shared/bad.envkv",
+			wantErr: "bad.envkv: line 1, column 5: missing =",
+		},
+		{
+			name:    "missing file",
+			content: "A=1\n#include nope.envkv",
+			wantErr: "service.envkv: line 2, column 1: include: open ",
+		},
+		{
+			name:    "self",
+			content: "#include loop.envkv",
+			wantErr: "loop.envkv: line 1, column 1: include " + filepath.Join(dir, "loop.envkv") + ": includes itself",
+		},
+		{
+			name:    "cycle",
+			content: "#include a.envkv",
+			wantErr: "includes itself",
+		},
+		{
+			name:    "too deep",
+			content: "#include deep.envkv",
+			wantErr: "nested too deeply",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := write("service.envkv", tt.content)
+			got, err := UnmarshalOptions{Include: true}.ReadFile(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !equalKV(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+
+			// Without Include, includes are comments.
+			got, err = UnmarshalOptions{}.ReadFile(path)
+			if err != nil || len(got) != 2 {
+				t.Errorf("without Include: %+v, %v", got, err)
+			}
+		})
+	}
+}
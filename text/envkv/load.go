@@ -0,0 +1,56 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package envkv
+
+import (
+	"fmt"
+	"os"
+)
+
+// The file read by Load and Overload if no paths are given.
+const DefaultFile = ".envkv"
+
+// Reads each of the files at paths (or DefaultFile, if none are given), following includes
+// (see UnmarshalOptions.Include), and sets the environment
+// variables they contain, so that they are seen by the program (with os.Getenv),
+// and by child processes (e.g. those started with execx).
+//
+// Variables which are already set are left alone, so the real environment takes precedence,
+// as do earlier files over later ones. See Overload.
+func Load(paths ...string) error {
+	return load(paths, false)
+}
+
+// Overload is like Load, but variables which are already set are overwritten,
+// so later files take precedence over earlier ones, and over the real environment.
+func Overload(paths ...string) error {
+	return load(paths, true)
+}
+
+func load(paths []string, overwrite bool) error {
+	if len(paths) == 0 {
+		paths = []string{DefaultFile}
+	}
+	for _, path := range paths {
+		kvs, _, err := readFile(path)
+		if err != nil {
+			return err
+		}
+		for _, kv := range kvs {
+			if _, ok := os.LookupEnv(kv.Key); ok && !overwrite {
+				continue
+			}
+			if err := os.Setenv(kv.Key, kv.Value); err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Reads and parses the file at path, returning errors prefixed with it, and where each KV was read from.
+func readFile(path string) ([]KV, []pos, error) {
+	return UnmarshalOptions{Include: true}.readFile(path)
+}
@@ -0,0 +1,73 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package envkv
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first.envkv")
+	second := filepath.Join(dir, "second.envkv")
+	os.WriteFile(first, []byte("ENVKVA=first\nENVKVB=first\n"), 0644)
+	os.WriteFile(second, []byte("ENVKVB=second\nENVKVC=second\n"), 0644)
+
+	tests := []struct {
+		name string
+		load func(paths ...string) error
+		want map[string]string
+	}{
+		{
+			name: "Load",
+			load: Load,
+			want: map[string]string{"ENVKVA": "real", "ENVKVB": "first", "ENVKVC": "second"},
+		},
+		{
+			name: "Overload",
+			load: Overload,
+			want: map[string]string{"ENVKVA": "first", "ENVKVB": "second", "ENVKVC": "second"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("ENVKVA", "real")
+			t.Setenv("ENVKVB", "")
+			t.Setenv("ENVKVC", "")
+			os.Unsetenv("ENVKVB")
+			os.Unsetenv("ENVKVC")
+
+			if err := tt.load(first, second); err != nil {
+				t.Fatal(err)
+			}
+			for k, want := range tt.want {
+				if got := os.Getenv(k); got != want {
+					t.Errorf("%s = %q, want %q", k, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	dir := t.TempDir()
+	bad := filepath.Join(dir, "bad.envkv")
+	os.WriteFile(bad, []byte("ENVKVA=a b\n"), 0644)
+
+	if err := Load(bad); err == nil || !strings.HasPrefix(err.Error(), bad+": line 1, column 9:") {
+		t.Errorf("bad file: err = %v", err)
+	}
+	if err := Load(filepath.Join(dir, "missing")); !os.IsNotExist(err) {
+		t.Errorf("missing file: err = %v", err)
+	}
+
+	t.Chdir(dir)
+	if err := Load(); !os.IsNotExist(err) {
+		t.Errorf("missing default file: err = %v", err)
+	}
+}
@@ -0,0 +1,12 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !unix
+
+package envkv
+
+// File locking isn't supported here, so this does nothing.
+func lockFile(path string) (func(), error) {
+	return func() {}, nil
+}
@@ -0,0 +1,90 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package envkv
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"time"
+)
+
+// A Map holds values by key, with accessors converting them to other types, for example:
+//
+//	kvs, err := envkv.Unmarshal(b)
+//	...
+//	m := envkv.NewMap(kvs)
+//	port, err := m.GetInt("PORT", 8080)
+//
+// The typed accessors return the default if the key is missing, and an error naming the key
+// (along with the default) if its value can't be converted.
+type Map map[string]string
+
+// NewMap returns a Map of kvs. If a key is repeated, the last value wins.
+func NewMap(kvs []KV) Map {
+	m := make(Map, len(kvs))
+	for _, kv := range kvs {
+		m[kv.Key] = kv.Value
+	}
+	return m
+}
+
+// Returns the value of key, and whether it is present.
+func (m Map) Get(key string) (string, bool) {
+	v, ok := m[key]
+	return v, ok
+}
+
+// Returns the value of key, or def if it is missing.
+func (m Map) GetString(key, def string) string {
+	if v, ok := m[key]; ok {
+		return v
+	}
+	return def
+}
+
+// Returns the value of key as an int, or def if it is missing.
+func (m Map) GetInt(key string, def int) (int, error) {
+	return getAs(m, key, def)
+}
+
+// Returns the value of key as a bool (as understood by strconv.ParseBool), or def if it is missing.
+func (m Map) GetBool(key string, def bool) (bool, error) {
+	return getAs(m, key, def)
+}
+
+// Returns the value of key as a time.Duration (as understood by time.ParseDuration), or def if it is missing.
+func (m Map) GetDuration(key string, def time.Duration) (time.Duration, error) {
+	return getAs(m, key, def)
+}
+
+// Returns the value of key as a URL, or def parsed as one if it is missing (or nil if def is "").
+func (m Map) GetURL(key string, def string) (*url.URL, error) {
+	s, ok := m[key]
+	if !ok {
+		if def == "" {
+			return nil, nil
+		}
+		s = def
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", key, err)
+	}
+	return u, nil
+}
+
+// Returns the value of key converted to T, as UnmarshalTo does for struct fields, or def if it is missing.
+func getAs[T any](m Map, key string, def T) (T, error) {
+	s, ok := m[key]
+	if !ok {
+		return def, nil
+	}
+	var v T
+	if err := setField(reflect.ValueOf(&v).Elem(), s); err != nil {
+		return def, fmt.Errorf("%s: %w", key, err)
+	}
+	return v, nil
+}
@@ -0,0 +1,59 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package envkv
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMap(t *testing.T) {
+	m := NewMap([]KV{
+		{"PORT", "5432"}, {"DEBUG", "true"}, {"TIMEOUT", "1m30s"},
+		{"URL", "https://example.com/x"}, {"BAD", "nope"}, {"BADURL", "%zz"},
+		{"NAME", "first"}, {"NAME", "last"},
+	})
+
+	if v, ok := m.Get("NAME"); !ok || v != "last" {
+		t.Errorf("Get(NAME) = %q, %v", v, ok)
+	}
+	if _, ok := m.Get("MISSING"); ok {
+		t.Errorf("Get(MISSING) should be missing")
+	}
+	if v := m.GetString("MISSING", "def"); v != "def" {
+		t.Errorf("GetString(MISSING) = %q", v)
+	}
+
+	tests := []struct {
+		name    string
+		get     func() (any, error)
+		want    any
+		wantErr string
+	}{
+		{"int", func() (any, error) { return m.GetInt("PORT", 1) }, 5432, ""},
+		{"int default", func() (any, error) { return m.GetInt("MISSING", 1) }, 1, ""},
+		{"int invalid", func() (any, error) { return m.GetInt("BAD", 1) }, 1, `BAD: cannot convert "nope" to int`},
+		{"bool", func() (any, error) { return m.GetBool("DEBUG", false) }, true, ""},
+		{"bool invalid", func() (any, error) { return m.GetBool("BAD", false) }, false, `BAD: cannot convert "nope" to bool`},
+		{"duration", func() (any, error) { return m.GetDuration("TIMEOUT", 0) }, 90 * time.Second, ""},
+		{"duration default", func() (any, error) { return m.GetDuration("MISSING", time.Second) }, time.Second, ""},
+		{"duration invalid", func() (any, error) { return m.GetDuration("BAD", time.Second) }, time.Second, `BAD: time: invalid duration "nope"`},
+		{"url", func() (any, error) { u, err := m.GetURL("URL", ""); return u.String(), err }, "https://example.com/x", ""},
+		{"url default", func() (any, error) { u, err := m.GetURL("MISSING", "http://d"); return u.Host, err }, "d", ""},
+		{"url no default", func() (any, error) { u, err := m.GetURL("MISSING", ""); return u == nil, err }, true, ""},
+		{"url invalid", func() (any, error) { u, err := m.GetURL("BADURL", ""); return u == nil, err }, true, `BADURL: parse "%zz": invalid URL escape "%zz"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.get()
+			if (err != nil || tt.wantErr != "") && (err == nil || err.Error() != tt.wantErr) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
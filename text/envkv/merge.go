@@ -0,0 +1,58 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package envkv
+
+import (
+	"errors"
+	"io/fs"
+)
+
+// Merge combines sources, in increasing order of precedence: where a key is in more than one,
+// the value from the last one wins, in the position where the key first appeared.
+func Merge(sources ...[]KV) []KV {
+	var out []KV
+	index := map[string]int{}
+	for _, kvs := range sources {
+		for _, kv := range kvs {
+			if i, ok := index[kv.Key]; ok {
+				out[i].Value = kv.Value
+				continue
+			}
+			index[kv.Key] = len(out)
+			out = append(out, kv)
+		}
+	}
+	return out
+}
+
+// Sources records where the values returned by LoadAll came from: the path of the file
+// which each key's value was read from (which may be an included file), keyed by key.
+type Sources map[string]string
+
+// LoadAll reads each of the files at paths (following includes), and merges them as Merge does,
+// so that later files override earlier ones. This allows layering, e.g. local overrides of shared settings:
+//
+//	kvs, sources, err := envkv.LoadAll(".envkv", ".envkv.local")
+//
+// Files which don't exist are skipped. Duplicate keys within a file are still an error.
+// Unlike Load, the process environment isn't changed.
+func LoadAll(paths ...string) ([]KV, Sources, error) {
+	var all [][]KV
+	sources := Sources{}
+	for _, path := range paths {
+		kvs, positions, err := readFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		for i, kv := range kvs {
+			sources[kv.Key] = positions[i].file
+		}
+		all = append(all, kvs)
+	}
+	return Merge(all...), sources, nil
+}
@@ -0,0 +1,69 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package envkv
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name    string
+		sources [][]KV
+		want    []KV
+	}{
+		{"none", nil, nil},
+		{"one", [][]KV{{{"A", "1"}}}, []KV{{"A", "1"}}},
+		{
+			name:    "later wins, in the first position",
+			sources: [][]KV{{{"A", "1"}, {"B", "1"}}, {{"C", "2"}, {"A", "2"}}, {{"A", "3"}}},
+			want:    []KV{{"A", "3"}, {"B", "1"}, {"C", "2"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Merge(tt.sources...); !equalKV(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadAll(t *testing.T) {
+	dir := t.TempDir()
+	shared := filepath.Join(dir, ".envkv")
+	local := filepath.Join(dir, ".envkv.local")
+	os.WriteFile(shared, []byte("HOST=db\nPORT=5432\n"), 0644)
+	os.WriteFile(local, []byte("HOST=localhost\n"), 0644)
+
+	kvs, sources, err := LoadAll(shared, local, filepath.Join(dir, "missing"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []KV{{"HOST", "localhost"}, {"PORT", "5432"}}; !equalKV(kvs, want) {
+		t.Errorf("got %+v, want %+v", kvs, want)
+	}
+	if want := (Sources{"HOST": local, "PORT": shared}); !maps.Equal(sources, want) {
+		t.Errorf("sources = %v, want %v", sources, want)
+	}
+
+	// Keys from included files come from those files.
+	common := filepath.Join(dir, "common.envkv")
+	os.WriteFile(common, []byte("PORT=6543\n"), 0644)
+	os.WriteFile(local, []byte("HOST=localhost\n#include common.envkv\n"), 0644)
+	if _, sources, err := LoadAll(shared, local); err != nil {
+		t.Fatal(err)
+	} else if want := (Sources{"HOST": local, "PORT": common}); !maps.Equal(sources, want) {
+		t.Errorf("sources = %v, want %v", sources, want)
+	}
+
+	os.WriteFile(local, []byte("HOST=a\nHOST=b\n"), 0644)
+	if _, _, err := LoadAll(shared, local); err == nil {
+		t.Errorf("expected an error for duplicate keys in a file")
+	}
+}
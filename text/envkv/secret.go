@@ -0,0 +1,96 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package envkv
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The prefix of values which are secret references. See SecretRef.
+const SecretScheme = "secret://"
+
+// A SecretRef refers to a secret held elsewhere, and is written as a value of the form
+// "secret://PROVIDER/PATH#KEY" (where #KEY is optional), e.g:
+//
+//	DB_PASSWORD="secret://vault/db/prod#password"
+//
+// Since # otherwise starts a comment, references need quoting, unless UnmarshalOptions.Dotenv is used.
+type SecretRef struct {
+	Provider string // e.g. "vault"
+	Path     string // e.g. "db/prod"
+	Key      string // e.g. "password", or "" if there was no #KEY
+}
+
+// Parses s as a secret reference, returning false if it isn't one.
+func ParseSecretRef(s string) (SecretRef, bool) {
+	rest, ok := strings.CutPrefix(s, SecretScheme)
+	if !ok {
+		return SecretRef{}, false
+	}
+	var ref SecretRef
+	rest, ref.Key, _ = strings.Cut(rest, "#")
+	ref.Provider, ref.Path, _ = strings.Cut(rest, "/")
+	if ref.Provider == "" || ref.Path == "" {
+		return SecretRef{}, false
+	}
+	return ref, true
+}
+
+// Returns the reference in the form ParseSecretRef accepts.
+func (r SecretRef) String() string {
+	s := SecretScheme + r.Provider + "/" + r.Path
+	if r.Key != "" {
+		s += "#" + r.Key
+	}
+	return s
+}
+
+// A SecretResolver looks up the value of secrets. See UnmarshalOptions.Secrets.
+type SecretResolver interface {
+	ResolveSecret(ref SecretRef) (string, error)
+}
+
+// SecretResolverFunc allows a function to be used as a SecretResolver.
+type SecretResolverFunc func(ref SecretRef) (string, error)
+
+func (f SecretResolverFunc) ResolveSecret(ref SecretRef) (string, error) {
+	return f(ref)
+}
+
+// SecretProviders is a SecretResolver which passes each reference to the resolver for its provider.
+// References to other providers are an error.
+type SecretProviders map[string]SecretResolver
+
+func (p SecretProviders) ResolveSecret(ref SecretRef) (string, error) {
+	r, ok := p[ref.Provider]
+	if !ok {
+		return "", fmt.Errorf("unknown secret provider %q", ref.Provider)
+	}
+	return r.ResolveSecret(ref)
+}
+
+// SecretRefs records which keys had their values resolved from a secret reference, and what the reference was.
+// See UnmarshalOptions.SecretRefs and MarshalOptions.SecretRefs.
+type SecretRefs map[string]SecretRef
+
+// Replaces the values of kvs which are secret references with the secret, recording them in refs (if it isn't nil).
+func resolveSecrets(kvs []KV, positions []pos, r SecretResolver, refs SecretRefs) error {
+	for i := range kvs {
+		ref, ok := ParseSecretRef(kvs[i].Value)
+		if !ok {
+			continue
+		}
+		val, err := r.ResolveSecret(ref)
+		if err != nil {
+			return newParseError(positions[i], CodeSecret, fmt.Sprintf("resolving %s: %v", ref, err))
+		}
+		kvs[i].Value = val
+		if refs != nil {
+			refs[kvs[i].Key] = ref
+		}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package envkv
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseSecretRef(t *testing.T) {
+	tests := []struct {
+		in   string
+		want SecretRef
+		ok   bool
+	}{
+		{"secret://vault/db/prod#password", SecretRef{"vault", "db/prod", "password"}, true},
+		{"secret://aws/api-key", SecretRef{"aws", "api-key", ""}, true},
+		{"secret://vault", SecretRef{}, false},
+		{"secret:///path", SecretRef{}, false},
+		{"https://vault/path", SecretRef{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseSecretRef(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("ParseSecretRef(%q) = %+v, %v, want %+v, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+		if ok && got.String() != tt.in {
+			t.Errorf("String() = %q, want %q", got.String(), tt.in)
+		}
+	}
+}
+
+func TestSecrets(t *testing.T) {
+	vault := SecretResolverFunc(func(ref SecretRef) (string, error) {
+		if ref.Path == "db/prod" && ref.Key == "password" {
+			return "hunter2", nil
+		}
+		return "", errors.New("not found")
+	})
+	input := "ENV=prod\nDB_PASSWORD=\"secret://vault/db/${ENV}#password\"\nHOST=db\n"
+
+	refs := SecretRefs{}
+	opts := UnmarshalOptions{Expand: true, Secrets: SecretProviders{"vault": vault}, SecretRefs: refs}
+	got, err := opts.Unmarshal([]byte(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []KV{{"ENV", "prod"}, {"DB_PASSWORD", "hunter2"}, {"HOST", "db"}}
+	if !equalKV(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	if len(refs) != 1 || refs["DB_PASSWORD"] != (SecretRef{"vault", "db/prod", "password"}) {
+		t.Errorf("refs = %+v", refs)
+	}
+
+	out, err := MarshalOptions{SecretRefs: refs}.Marshal(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "ENV=prod\nDB_PASSWORD=\"secret://vault/db/prod#password\"\nHOST=db\n"; string(out) != want {
+		t.Errorf("Marshal = %q, want %q", out, want)
+	}
+
+	// Without a resolver, references are left alone.
+	if got, _ := Unmarshal([]byte(input)); got[1].Value != "secret://vault/db/${ENV}#password" {
+		t.Errorf("unresolved value = %q", got[1].Value)
+	}
+
+	for _, input := range []string{"A=\"secret://vault/nope\"", "A=\"secret://aws/key\""} {
+		_, err := opts.Unmarshal([]byte(input))
+		var pe *ParseError
+		if !errors.As(err, &pe) || pe.Code != CodeSecret || pe.Line != 1 {
+			t.Errorf("Unmarshal(%q) error = %v", input, err)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package envkv
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+)
+
+// A Decoder reads KVs from an input stream one at a time, without reading all of it into memory first,
+// for large generated files, or pipes. For example:
+//
+//	dec := envkv.NewDecoder(os.Stdin)
+//	for {
+//		var kv envkv.KV
+//		if err := dec.Decode(&kv); err == io.EOF {
+//			break
+//		} else if err != nil {
+//			return err
+//		}
+//		...
+//	}
+//
+// Expansion and includes need the whole input, so they aren't supported.
+type Decoder struct {
+	r          *bufio.Reader
+	dotenv     bool
+	duplicates DuplicatePolicy
+	secrets    SecretResolver
+	secretRefs SecretRefs
+	line       int
+	seen       map[string]struct{}
+	err        error
+}
+
+// NewDecoder returns a Decoder which reads from r.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: bufio.NewReader(r), line: -1, seen: map[string]struct{}{}}
+}
+
+// NewDecoder returns a Decoder which reads from r, accepting the .env dialect if o.Dotenv is set,
+// and resolving secret references as each KV is read if o.Secrets is set.
+// It is an error if o.Expand or o.Include are set.
+//
+// Since KVs are returned as they are read, with DuplicateLastWins, each value of a repeated key is returned
+// (so the last one wins if the caller applies them in order), rather than just the last one.
+func (o UnmarshalOptions) NewDecoder(r io.Reader) (*Decoder, error) {
+	if o.Expand || o.Include {
+		return nil, errors.New("envkv: Decoder doesn't support Expand or Include")
+	}
+	d := NewDecoder(r)
+	d.dotenv = o.Dotenv
+	d.duplicates = o.Duplicates
+	d.secrets = o.Secrets
+	d.secretRefs = o.SecretRefs
+	return d, nil
+}
+
+// Decode reads the next KV, and stores it in kv. At the end of the input, it returns io.EOF.
+// Once it returns an error, it returns the same error from then on.
+func (d *Decoder) Decode(kv *KV) error {
+	for d.err == nil {
+		line, err := d.r.ReadString('\n')
+		if err == io.EOF && len(line) == 0 {
+			d.err = io.EOF
+			break
+		}
+		if err != nil && err != io.EOF {
+			d.err = err
+			break
+		}
+		d.line++
+		line = strings.TrimSuffix(line, "\n")
+		line = strings.TrimSuffix(line, "\r")
+
+		key, val, keyStart, _, _, err := parseLine(line, d.line, d.dotenv)
+		if err != nil {
+			d.err = err
+			break
+		}
+		if key == "" {
+			continue
+		}
+		if _, ok := d.seen[key]; ok && d.duplicates != DuplicateLastWins {
+			if d.duplicates == DuplicateFirstWins {
+				continue
+			}
+			d.err = newParseError(pos{line: d.line, col: keyStart, text: line}, CodeDuplicateKey, "duplicate key")
+			break
+		}
+		d.seen[key] = struct{}{}
+		out := []KV{{Key: key, Value: val}}
+		if d.secrets != nil {
+			if err := resolveSecrets(out, []pos{{line: d.line, col: keyStart, text: line}}, d.secrets, d.secretRefs); err != nil {
+				d.err = err
+				break
+			}
+		}
+		*kv = out[0]
+		return nil
+	}
+	return d.err
+}
+
+// An Encoder writes KVs to an output stream one at a time.
+type Encoder struct {
+	w    io.Writer
+	seen map[string]struct{}
+	buf  bytes.Buffer
+}
+
+// NewEncoder returns an Encoder which writes to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w, seen: map[string]struct{}{}}
+}
+
+// Encode writes kv to the stream, as a line in the same form as Marshal.
+// As with Marshal, it is an error for kv.Key to be invalid, or to have already been written.
+func (e *Encoder) Encode(kv KV) error {
+	if err := checkKey(kv.Key); err != nil {
+		return err
+	}
+	if _, ok := e.seen[kv.Key]; ok {
+		return errors.New("duplicate key")
+	}
+	e.seen[kv.Key] = struct{}{}
+
+	e.buf.Reset()
+	e.buf.WriteString(kv.Key)
+	e.buf.WriteByte('=')
+	appendValue(&e.buf, kv.Value)
+	e.buf.WriteByte('\n')
+	_, err := e.w.Write(e.buf.Bytes())
+	return err
+}
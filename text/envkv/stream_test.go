@@ -0,0 +1,145 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package envkv
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func decodeAll(d *Decoder) ([]KV, error) {
+	var out []KV
+	for {
+		var kv KV
+		err := d.Decode(&kv)
+		if err == io.EOF {
+			return out, nil
+		}
+		if err != nil {
+			return out, err
+		}
+		out = append(out, kv)
+	}
+}
+
+func TestDecoder(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []KV
+		wantErr string
+	}{
+		{"empty", "", nil, ""},
+		{"lines", "# c\r\nA=1\r\n\nB=\"x y\"", []KV{{"A", "1"}, {"B", "x y"}}, ""},
+		{"long line", "A=" + strings.Repeat("x", 100000) + "\nB=2\n", []KV{{"A", strings.Repeat("x", 100000)}, {"B", "2"}}, ""},
+		{"error", "A=1\n\nB", []KV{{"A", "1"}}, "line 3, column 2: missing ="},
+		{"duplicate", "A=1\nA=2", []KV{{"A", "1"}}, "line 2, column 1: duplicate key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// One byte at a time, to be sure nothing relies on reading everything at once.
+			d := NewDecoder(iotest.OneByteReader(strings.NewReader(tt.input)))
+			got, err := decodeAll(d)
+			if (err != nil || tt.wantErr != "") && (err == nil || err.Error() != tt.wantErr) {
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+			if !equalKV(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+			if tt.wantErr != "" {
+				if err2 := d.Decode(new(KV)); err2 != err {
+					t.Errorf("second error = %v, want %v", err2, err)
+				}
+			}
+		})
+	}
+
+	d, err := UnmarshalOptions{Dotenv: true}.NewDecoder(strings.NewReader("export A=b c"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := decodeAll(d); err != nil || !equalKV(got, []KV{{"A", "b c"}}) {
+		t.Errorf("dotenv got %+v, %v", got, err)
+	}
+	if _, err := (UnmarshalOptions{Expand: true}).NewDecoder(strings.NewReader("")); err == nil {
+		t.Errorf("expected an error for Expand")
+	}
+}
+
+func TestEncoder(t *testing.T) {
+	kvs := []KV{{"A", "1"}, {"B", "x y"}, {"C", "q\"\n"}}
+	var buf bytes.Buffer
+	e := NewEncoder(&buf)
+	for _, kv := range kvs {
+		if err := e.Encode(kv); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, _ := Marshal(kvs)
+	if buf.String() != string(want) {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+
+	if err := e.Encode(KV{"A", "2"}); err == nil {
+		t.Errorf("expected an error for a duplicate key")
+	}
+	if err := e.Encode(KV{"", "2"}); err == nil {
+		t.Errorf("expected an error for an empty key")
+	}
+}
+
+func TestDecoderDuplicates(t *testing.T) {
+	const input = "A=1\nA=2\nB=3"
+	tests := []struct {
+		policy DuplicatePolicy
+		want   []KV
+	}{
+		{DuplicateFirstWins, []KV{{"A", "1"}, {"B", "3"}}},
+		{DuplicateLastWins, []KV{{"A", "1"}, {"A", "2"}, {"B", "3"}}},
+	}
+	for _, tt := range tests {
+		d, err := UnmarshalOptions{Duplicates: tt.policy}.NewDecoder(strings.NewReader(input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, err := decodeAll(d); err != nil || !equalKV(got, tt.want) {
+			t.Errorf("policy %d: got %+v, %v, want %+v", tt.policy, got, err, tt.want)
+		}
+	}
+}
+
+func TestDecoderSecrets(t *testing.T) {
+	vault := SecretResolverFunc(func(ref SecretRef) (string, error) {
+		if ref.Path == "db" && ref.Key == "password" {
+			return "hunter2", nil
+		}
+		return "", errors.New("not found")
+	})
+	refs := SecretRefs{}
+	opts := UnmarshalOptions{Secrets: SecretProviders{"vault": vault}, SecretRefs: refs}
+
+	d, err := opts.NewDecoder(strings.NewReader("HOST=db\nPASSWORD=\"secret://vault/db#password\"\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := decodeAll(d)
+	if want := []KV{{"HOST", "db"}, {"PASSWORD", "hunter2"}}; err != nil || !equalKV(got, want) {
+		t.Errorf("got %+v, %v, want %+v", got, err, want)
+	}
+	if refs["PASSWORD"] != (SecretRef{"vault", "db", "password"}) {
+		t.Errorf("refs = %+v", refs)
+	}
+
+	d, _ = opts.NewDecoder(strings.NewReader("A=1\nB=\"secret://vault/nope\"\n"))
+	_, err = decodeAll(d)
+	var pe *ParseError
+	if !errors.As(err, &pe) || pe.Code != CodeSecret || pe.Line != 2 {
+		t.Errorf("error = %v", err)
+	}
+}
@@ -0,0 +1,235 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package envkv
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// UnmarshalTo parses b, and writes the values to the fields of the struct pointed to by v.
+//
+// Each exported field is read from the key named by its `env` struct tag, or its name if it has none.
+// A tag of "-" skips the field. After the name, the tag may have these comma-separated options:
+//
+//   - required: it is an error for the key to be missing.
+//   - default=VALUE: the value used if the key is missing. VALUE can't contain a comma.
+//
+// For example:
+//
+//	type Config struct {
+//	    Host    string        `env:"HOST,required"`
+//	    Port    int           `env:"PORT,default=8080"`
+//	    Timeout time.Duration `env:"TIMEOUT,default=30s"`
+//	}
+//
+// Fields may be strings, bools, integers, floats, time.Duration, or implement encoding.TextUnmarshaler,
+// or be pointers to these (which are only set if the key is present, or has a default).
+// Embedded structs are treated as if their fields were part of the outer struct.
+// Keys without a matching field are ignored.
+func UnmarshalTo(b []byte, v any) error {
+	kvs, err := Unmarshal(b)
+	if err != nil {
+		return err
+	}
+	return decodeStruct(kvs, v)
+}
+
+// MarshalFrom serializes the fields of the struct v (or pointer to one), named as for UnmarshalTo.
+// Nil pointers are left out.
+func MarshalFrom(v any) ([]byte, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Pointer {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("envkv: MarshalFrom of non-struct %T", v)
+	}
+	var kvs []KV
+	err := forEachField(rv, func(fv reflect.Value, key string, opts envTag) error {
+		if fv.Kind() == reflect.Pointer {
+			if fv.IsNil() {
+				return nil
+			}
+			fv = fv.Elem()
+		}
+		s, err := formatField(fv)
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		kvs = append(kvs, KV{Key: key, Value: s})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return Marshal(kvs)
+}
+
+// Writes kvs to the fields of the struct pointed to by v. See UnmarshalTo.
+func decodeStruct(kvs []KV, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("envkv: UnmarshalTo needs a non-nil pointer to a struct, not %T", v)
+	}
+	values := make(map[string]string, len(kvs))
+	for _, kv := range kvs {
+		values[kv.Key] = kv.Value
+	}
+
+	return forEachField(rv.Elem(), func(fv reflect.Value, key string, opts envTag) error {
+		val, ok := values[key]
+		if !ok {
+			if opts.required {
+				return fmt.Errorf("%s: required key is missing", key)
+			}
+			if !opts.hasDefault {
+				return nil
+			}
+			val = opts.def
+		}
+		if fv.Kind() == reflect.Pointer {
+			fv.Set(reflect.New(fv.Type().Elem()))
+			fv = fv.Elem()
+		}
+		if err := setField(fv, val); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		return nil
+	})
+}
+
+// The options of an `env` struct tag.
+type envTag struct {
+	required   bool
+	hasDefault bool
+	def        string
+}
+
+// Calls fn for each exported field of the struct rv (including those of embedded structs),
+// with the key it is named by, and its tag options.
+func forEachField(rv reflect.Value, fn func(fv reflect.Value, key string, opts envTag) error) error {
+	t := rv.Type()
+	for i := range t.NumField() {
+		f := t.Field(i)
+		tag := f.Tag.Get("env")
+		if tag == "-" {
+			continue
+		}
+		if f.Anonymous && f.Type.Kind() == reflect.Struct && tag == "" {
+			if err := forEachField(rv.Field(i), fn); err != nil {
+				return err
+			}
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+
+		key, rest, _ := strings.Cut(tag, ",")
+		if key == "" {
+			key = f.Name
+		}
+		var opts envTag
+		for opt := range strings.SplitSeq(rest, ",") {
+			switch {
+			case opt == "":
+			case opt == "required":
+				opts.required = true
+			case strings.HasPrefix(opt, "default="):
+				opts.hasDefault = true
+				opts.def = strings.TrimPrefix(opt, "default=")
+			default:
+				return fmt.Errorf("envkv: field %s: unknown tag option %q", f.Name, opt)
+			}
+		}
+		if err := fn(rv.Field(i), key, opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var (
+	durationType        = reflect.TypeFor[time.Duration]()
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+	textMarshalerType   = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+// Converts s to the type of fv, and writes it there.
+func setField(fv reflect.Value, s string) error {
+	if reflect.PointerTo(fv.Type()).Implements(textUnmarshalerType) {
+		return fv.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+	if fv.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		fv.SetInt(int64(d))
+		return nil
+	}
+
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("cannot convert %q to bool", s)
+		}
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, fv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("cannot convert %q to %s", s, fv.Type())
+		}
+		fv.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(s, 10, fv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("cannot convert %q to %s", s, fv.Type())
+		}
+		fv.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, fv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("cannot convert %q to %s", s, fv.Type())
+		}
+		fv.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %s", fv.Type())
+	}
+	return nil
+}
+
+// Returns the value of fv as a string, as setField would read it.
+func formatField(fv reflect.Value) (string, error) {
+	if fv.Type().Implements(textMarshalerType) {
+		b, err := fv.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(b), err
+	}
+	if fv.Type() == durationType {
+		return time.Duration(fv.Int()).String(), nil
+	}
+
+	switch fv.Kind() {
+	case reflect.String:
+		return fv.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(fv.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(fv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(fv.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(fv.Float(), 'g', -1, fv.Type().Bits()), nil
+	}
+	return "", fmt.Errorf("unsupported type %s", fv.Type())
+}
@@ -0,0 +1,131 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package envkv
+
+import (
+	"net/netip"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testBase struct {
+	Name string `env:"NAME"`
+}
+
+type testConfig struct {
+	testBase
+	Host    string        `env:"HOST,required"`
+	Port    uint16        `env:"PORT,default=8080"`
+	Debug   bool          `env:"DEBUG"`
+	Ratio   float64       `env:"RATIO"`
+	Timeout time.Duration `env:"TIMEOUT,default=30s"`
+	Addr    netip.Addr    `env:"ADDR"`
+	Retries *int          `env:"RETRIES"`
+	Skipped string        `env:"-"`
+	Plain   string
+	private string
+}
+
+func TestUnmarshalTo(t *testing.T) {
+	three := 3
+	tests := []struct {
+		name    string
+		input   string
+		want    testConfig
+		wantErr string
+	}{
+		{
+			name:  "all fields",
+			input: "NAME=api\nHOST=db\nPORT=5432\nDEBUG=true\nRATIO=0.5\nTIMEOUT=1m\nADDR=10.0.0.1\nRETRIES=3\nSkipped=x\nPlain=p\nUNKNOWN=1",
+			want: testConfig{
+				testBase: testBase{Name: "api"},
+				Host:     "db", Port: 5432, Debug: true, Ratio: 0.5, Timeout: time.Minute,
+				Addr: netip.MustParseAddr("10.0.0.1"), Retries: &three, Plain: "p",
+			},
+		},
+		{
+			name:  "defaults",
+			input: "HOST=db",
+			want:  testConfig{Host: "db", Port: 8080, Timeout: 30 * time.Second},
+		},
+		{
+			name:    "required",
+			input:   "PORT=1",
+			wantErr: "HOST: required key is missing",
+		},
+		{
+			name:    "bad int",
+			input:   "HOST=db\nPORT=70000",
+			wantErr: `PORT: cannot convert "70000" to uint16`,
+		},
+		{
+			name:    "bad text",
+			input:   "HOST=db\nADDR=nope",
+			wantErr: `ADDR: ParseAddr("nope"): unable to parse IP`,
+		},
+		{
+			name:    "parse error",
+			input:   "HOST",
+			wantErr: "line 1, column 5: missing =",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got testConfig
+			err := UnmarshalTo([]byte(tt.input), &got)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	if err := UnmarshalTo([]byte("A=1"), testConfig{}); err == nil {
+		t.Errorf("expected an error for a non-pointer")
+	}
+	var bad struct {
+		A string `env:"A,optional"`
+	}
+	if err := UnmarshalTo([]byte("A=1"), &bad); err == nil {
+		t.Errorf("expected an error for an unknown tag option")
+	}
+}
+
+func TestMarshalFrom(t *testing.T) {
+	cfg := testConfig{
+		testBase: testBase{Name: "api"},
+		Host:     "db", Port: 5432, Timeout: 90 * time.Second,
+		Addr: netip.MustParseAddr("::1"), Plain: "a b",
+	}
+	b, err := MarshalFrom(&cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "NAME=api\nHOST=db\nPORT=5432\nDEBUG=false\nRATIO=0\nTIMEOUT=1m30s\nADDR=::1\nPlain=\"a b\"\n"
+	if string(b) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", b, want)
+	}
+
+	var got testConfig
+	if err := UnmarshalTo(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, cfg) {
+		t.Errorf("roundtrip got %+v, want %+v", got, cfg)
+	}
+
+	if _, err := MarshalFrom(3); err == nil {
+		t.Errorf("expected an error for a non-struct")
+	}
+}
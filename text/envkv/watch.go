@@ -0,0 +1,126 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package envkv
+
+import (
+	"sync"
+	"time"
+)
+
+// Changes describes how a set of KVs differs from an earlier one. See Diff.
+type Changes struct {
+	Added   []KV // Keys which weren't there before
+	Changed []KV // Keys whose value is different, with the new value
+	Removed []KV // Keys which aren't there any more, with the old value
+}
+
+// Returns true if there are no changes.
+func (c Changes) Empty() bool {
+	return len(c.Added) == 0 && len(c.Changed) == 0 && len(c.Removed) == 0
+}
+
+// Diff returns the changes from old to new. Added and Changed are in the order of new,
+// and Removed is in the order of old.
+func Diff(old, new []KV) Changes {
+	before := NewMap(old)
+	after := NewMap(new)
+	var c Changes
+	for _, kv := range new {
+		if v, ok := before[kv.Key]; !ok {
+			c.Added = append(c.Added, kv)
+		} else if v != kv.Value {
+			c.Changed = append(c.Changed, kv)
+		}
+	}
+	for _, kv := range old {
+		if _, ok := after[kv.Key]; !ok {
+			c.Removed = append(c.Removed, kv)
+		}
+	}
+	return c
+}
+
+// WatchOptions configures Watch.
+type WatchOptions struct {
+	// How often the file is checked. If zero, it is checked every second.
+	Interval time.Duration
+
+	// How the file is parsed. If nil, includes are followed, as Load does.
+	Unmarshal *UnmarshalOptions
+
+	// Called if the file can't be read or parsed after the watch has started, e.g. if it is edited
+	// and left invalid. The last good values stay in effect. If nil, such errors are ignored.
+	OnError func(err error)
+}
+
+// A Watcher reports changes to a file. See Watch.
+type Watcher struct {
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// Watch reads the file at path, calls fn with its contents, and then polls it for changes,
+// calling fn with the new contents, and how they changed, whenever they do. This allows
+// long-running services to be reconfigured without restarting, e.g:
+//
+//	w, err := envkv.Watch("app.envkv", func(kvs []envkv.KV, changes envkv.Changes) {
+//		cfg.Store(newConfig(kvs))
+//	})
+//
+// fn is called from a goroutine belonging to the Watcher, one call at a time.
+// It is an error if the file can't be read at first.
+func Watch(path string, fn func(kvs []KV, changes Changes)) (*Watcher, error) {
+	return WatchOptions{}.Watch(path, fn)
+}
+
+// Watch watches the file at path, as the Watch function does, but using the options in o.
+func (o WatchOptions) Watch(path string, fn func(kvs []KV, changes Changes)) (*Watcher, error) {
+	if o.Interval <= 0 {
+		o.Interval = time.Second
+	}
+	uo := UnmarshalOptions{Include: true}
+	if o.Unmarshal != nil {
+		uo = *o.Unmarshal
+	}
+
+	kvs, err := uo.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	fn(kvs, Diff(nil, kvs))
+
+	w := &Watcher{stop: make(chan struct{}), done: make(chan struct{})}
+	go func() {
+		defer close(w.done)
+		t := time.NewTicker(o.Interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-w.stop:
+				return
+			case <-t.C:
+			}
+			next, err := uo.ReadFile(path)
+			if err != nil {
+				if o.OnError != nil {
+					o.OnError(err)
+				}
+				continue
+			}
+			if changes := Diff(kvs, next); !changes.Empty() {
+				kvs = next
+				fn(kvs, changes)
+			}
+		}
+	}()
+	return w, nil
+}
+
+// Close stops watching, waiting for any call to the callback to finish (so it mustn't be called from it).
+func (w *Watcher) Close() {
+	w.stopOnce.Do(func() { close(w.stop) })
+	<-w.done
+}
@@ -0,0 +1,91 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package envkv
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDiff(t *testing.T) {
+	old := []KV{{"A", "1"}, {"B", "2"}, {"C", "3"}}
+	new := []KV{{"D", "4"}, {"C", "3"}, {"A", "one"}}
+	want := Changes{
+		Added:   []KV{{"D", "4"}},
+		Changed: []KV{{"A", "one"}},
+		Removed: []KV{{"B", "2"}},
+	}
+	if got := Diff(old, new); !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff = %+v, want %+v", got, want)
+	}
+	if !Diff(old, old).Empty() {
+		t.Errorf("Diff(old, old) isn't empty")
+	}
+}
+
+func TestWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.envkv")
+	os.WriteFile(path, []byte("A=1\nB=2\n"), 0644)
+
+	type update struct {
+		kvs     []KV
+		changes Changes
+	}
+	updates := make(chan update, 10)
+	errs := make(chan error, 10)
+	opts := WatchOptions{Interval: time.Millisecond, OnError: func(err error) { errs <- err }}
+	w, err := opts.Watch(path, func(kvs []KV, changes Changes) { updates <- update{kvs, changes} })
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	next := func() update {
+		t.Helper()
+		select {
+		case u := <-updates:
+			return u
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for an update")
+			return update{}
+		}
+	}
+
+	if u := next(); !equalKV(u.kvs, []KV{{"A", "1"}, {"B", "2"}}) || len(u.changes.Added) != 2 {
+		t.Errorf("initial update = %+v", u)
+	}
+
+	// Invalid contents are reported, and don't replace the last good values.
+	os.WriteFile(path, []byte("A=1\nB=\"2\n"), 0644)
+	select {
+	case <-errs:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for an error")
+	}
+
+	os.WriteFile(path, []byte("A=1\nB=3\nC=4\n"), 0644)
+	u := next()
+	want := Changes{Added: []KV{{"C", "4"}}, Changed: []KV{{"B", "3"}}}
+	if !equalKV(u.kvs, []KV{{"A", "1"}, {"B", "3"}, {"C", "4"}}) || !reflect.DeepEqual(u.changes, want) {
+		t.Errorf("update = %+v", u)
+	}
+
+	w.Close()
+	w.Close()
+	os.WriteFile(path, []byte("A=2\n"), 0644)
+	time.Sleep(10 * time.Millisecond)
+	select {
+	case u := <-updates:
+		t.Errorf("update after Close: %+v", u)
+	default:
+	}
+
+	if _, err := Watch(filepath.Join(t.TempDir(), "missing"), func([]KV, Changes) {}); err == nil {
+		t.Errorf("Watch of a missing file should fail")
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package envkv
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+
+	"github.com/rburchell/gosh/fs/fsatomic"
+)
+
+// WriteFile marshals kv, and writes it to the file at path atomically (see fsatomic.WriteFile),
+// so that readers see either the old or the new contents, never a mixture.
+func WriteFile(path string, kv []KV, perm os.FileMode) error {
+	b, err := Marshal(kv)
+	if err != nil {
+		return err
+	}
+	return fsatomic.WriteFile(path, b, perm)
+}
+
+// Update reads the file at path as a Document, calls fn to edit it, and then writes it back atomically,
+// unless fn returns an error. The file is created (with mode 0600) if it doesn't exist.
+//
+// While it runs, Update holds a lock on path+".lock", so concurrent calls to Update (in this process or another)
+// don't lose each other's changes. On platforms without file locking (other than unix), only the
+// write is atomic.
+//
+//	err := envkv.Update("app.envkv", func(doc *envkv.Document) error {
+//		return doc.Set("VERSION", version)
+//	})
+func Update(path string, fn func(doc *Document) error) error {
+	unlock, err := lockFile(path + ".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	perm := os.FileMode(0600)
+	b, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if err == nil {
+		fi, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		perm = fi.Mode().Perm()
+	}
+
+	doc, err := ParseDocument(b)
+	if err != nil {
+		return inFile(pos{file: path}, err)
+	}
+	if err := fn(doc); err != nil {
+		return err
+	}
+	return fsatomic.WriteFile(path, doc.Bytes(), perm)
+}
@@ -0,0 +1,108 @@
+// Copyright 2025 Robin Burchell. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package envkv
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestWriteFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.envkv")
+	kvs := []KV{{"A", "1"}, {"B", "two words"}}
+	if err := WriteFile(path, kvs, 0640); err != nil {
+		t.Fatal(err)
+	}
+	got, err := UnmarshalOptions{}.ReadFile(path)
+	if err != nil || !equalKV(got, kvs) {
+		t.Errorf("read back %+v, %v", got, err)
+	}
+	if fi, _ := os.Stat(path); fi.Mode().Perm() != 0640 {
+		t.Errorf("perm = %v", fi.Mode().Perm())
+	}
+
+	if err := WriteFile(path, []KV{{"bad key", "x"}}, 0640); err == nil {
+		t.Errorf("WriteFile with a bad key should fail")
+	}
+	if got, _ := (UnmarshalOptions{}).ReadFile(path); !equalKV(got, kvs) {
+		t.Errorf("failed WriteFile changed the file: %+v", got)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.envkv")
+
+	// The file is created if needed.
+	err := Update(path, func(doc *Document) error { return doc.Set("A", "1") })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := os.ReadFile(path); string(b) != "A=1\n" {
+		t.Errorf("created %q", b)
+	}
+
+	os.WriteFile(path, []byte("# settings\nA = \"1\" # first\n"), 0644)
+	os.Chmod(path, 0644)
+	err = Update(path, func(doc *Document) error { return doc.Set("B", "2") })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := os.ReadFile(path); string(b) != "# settings\nA = \"1\" # first\nB=2\n" {
+		t.Errorf("updated %q", b)
+	}
+	if fi, _ := os.Stat(path); fi.Mode().Perm() != 0644 {
+		t.Errorf("perm = %v, want it kept", fi.Mode().Perm())
+	}
+
+	// Errors from fn stop the write.
+	errStop := errors.New("stop")
+	err = Update(path, func(doc *Document) error {
+		doc.Delete("A")
+		return errStop
+	})
+	if err != errStop {
+		t.Errorf("Update error = %v, want %v", err, errStop)
+	}
+	if b, _ := os.ReadFile(path); string(b) != "# settings\nA = \"1\" # first\nB=2\n" {
+		t.Errorf("failed Update changed the file: %q", b)
+	}
+
+	os.WriteFile(path, []byte("A=\"1\n"), 0644)
+	if err := Update(path, func(*Document) error { return nil }); err == nil {
+		t.Errorf("Update of an invalid file should fail")
+	}
+}
+
+func TestUpdateConcurrent(t *testing.T) {
+	if runtime.GOOS == "windows" || runtime.GOOS == "plan9" || runtime.GOOS == "js" || runtime.GOOS == "wasip1" {
+		t.Skip("no file locking")
+	}
+	path := filepath.Join(t.TempDir(), "app.envkv")
+	const n = 20
+	var wg sync.WaitGroup
+	for range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := Update(path, func(doc *Document) error {
+				v, _ := doc.Get("COUNT")
+				i, _ := strconv.Atoi(v)
+				return doc.Set("COUNT", strconv.Itoa(i+1))
+			})
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if b, _ := os.ReadFile(path); string(b) != "COUNT="+strconv.Itoa(n)+"\n" {
+		t.Errorf("after %d updates: %q", n, b)
+	}
+}